@@ -0,0 +1,406 @@
+package app
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/go-chi/render"
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/runtime"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultAdminLimit = 100
+	maxAdminLimit     = 1000
+)
+
+// routeAdmin routes the admin dashboard and API, used by operators to inspect
+// and manage specs and instances. It requires the pprof token outside of
+// debug mode.
+func (a *App) routeAdmin(r chi.Router) {
+	r.Use(a.tokenAuthMiddleware, a.precheckDatabaseMiddleware, middleware.NoCache)
+
+	r.Get("/", a.adminDashboard)
+	r.Post("/trigger/{name}", a.adminTrigger)
+
+	r.Get("/specs", a.adminSpecs)
+	r.Get("/instances", a.adminInstances)
+
+	r.Route("/instances/{instanceID}", func(r chi.Router) {
+		// Expiring only touches the database, so it must work when Docker is
+		// down, like for the instances of a node whose daemon has died.
+		r.Post("/expire", a.adminInstanceExpire)
+
+		r.Group(func(r chi.Router) {
+			r.Use(a.precheckDockerMiddleware)
+
+			r.Get("/", a.adminInstance)
+			r.Post("/clean", a.adminInstanceClean)
+		})
+	})
+}
+
+type adminSpec struct {
+	ID             string    `json:"id"`
+	AssignmentName string    `json:"assignmentName"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+type adminInstance struct {
+	ID             string     `json:"id"`
+	SpecID         string     `json:"specID,omitempty"`
+	AssignmentName string     `json:"assignmentName,omitempty"`
+	ImageID        string     `json:"imageID"`
+	ContainerID    string     `json:"containerID"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+	Active         bool       `json:"active"`
+	Cleaned        bool       `json:"cleaned"`
+	Node           string     `json:"node,omitempty"`
+	DockerHost     string     `json:"dockerHost,omitempty"`
+
+	Container *adminContainer `json:"container,omitempty"`
+}
+
+type adminContainer struct {
+	Exists     bool   `json:"exists"`
+	Status     string `json:"status,omitempty"`
+	Running    bool   `json:"running"`
+	ExitCode   int    `json:"exitCode"`
+	OOMKilled  bool   `json:"oomKilled"`
+	StartedAt  string `json:"startedAt,omitempty"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+func newAdminInstance(instance *models.Instance) *adminInstance {
+	ai := &adminInstance{
+		ID:          instance.ID.String(),
+		ImageID:     instance.ImageID,
+		ContainerID: instance.ContainerID,
+		CreatedAt:   instance.CreatedAt,
+		UpdatedAt:   instance.UpdatedAt,
+		ExpiresAt:   instance.ExpiresAt,
+		Active:      instance.Active,
+		Cleaned:     instance.Cleaned,
+		Node:        instance.Node,
+		DockerHost:  instance.DockerHost,
+	}
+
+	if instance.Spec != nil {
+		ai.SpecID = instance.Spec.ID.String()
+		ai.AssignmentName = instance.Spec.AssignmentName
+	}
+
+	return ai
+}
+
+// adminPage parses the limit and offset query parameters.
+func adminPage(r *http.Request) (limit uint64, offset uint64, ok bool) {
+	limit = defaultAdminLimit
+
+	if s := r.FormValue("limit"); s != "" {
+		var err error
+		limit, err = strconv.ParseUint(s, 10, 64)
+		if err != nil || limit == 0 || limit > maxAdminLimit {
+			return 0, 0, false
+		}
+	}
+
+	if s := r.FormValue("offset"); s != "" {
+		var err error
+		offset, err = strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return 0, 0, false
+		}
+	}
+
+	return limit, offset, true
+}
+
+func (a *App) adminSpecs(w http.ResponseWriter, r *http.Request) {
+	logger := ctxlog.FromRequest(r)
+
+	limit, offset, ok := adminPage(r)
+	if !ok {
+		http.Error(w, "invalid limit or offset", http.StatusBadRequest)
+		return
+	}
+
+	specs, err := a.repo.ListSpecs(models.SpecFilter{
+		AssignmentName: r.FormValue("assignment"),
+		Limit:          limit,
+		Offset:         offset,
+	})
+	if err != nil {
+		logger.Error("error querying specs",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]*adminSpec, len(specs))
+	for i, spec := range specs {
+		resp[i] = &adminSpec{
+			ID:             spec.ID.String(),
+			AssignmentName: spec.AssignmentName,
+			CreatedAt:      spec.CreatedAt,
+			UpdatedAt:      spec.UpdatedAt,
+		}
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// adminInstances lists instances, newest first. They can be filtered by
+// assignment name, spec, whether they're active or cleaned, and expiry time
+// (as RFC 3339 times).
+func (a *App) adminInstances(w http.ResponseWriter, r *http.Request) {
+	logger := ctxlog.FromRequest(r)
+
+	limit, offset, ok := adminPage(r)
+	if !ok {
+		http.Error(w, "invalid limit or offset", http.StatusBadRequest)
+		return
+	}
+
+	filter := models.InstanceFilter{
+		AssignmentName: r.FormValue("assignment"),
+		Limit:          limit,
+		Offset:         offset,
+	}
+
+	if s := r.FormValue("spec"); s != "" {
+		specID, err := models.ParseID(s)
+		if err != nil {
+			a.httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.SpecID = specID
+	}
+
+	for _, f := range []struct {
+		name string
+		v    **bool
+	}{
+		{"active", &filter.Active},
+		{"cleaned", &filter.Cleaned},
+	} {
+		s := r.FormValue(f.name)
+		if s == "" {
+			continue
+		}
+
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			http.Error(w, "invalid "+f.name, http.StatusBadRequest)
+			return
+		}
+
+		*f.v = &v
+	}
+
+	for _, f := range []struct {
+		name string
+		t    **time.Time
+	}{
+		{"expiresBefore", &filter.ExpiresBefore},
+		{"expiresAfter", &filter.ExpiresAfter},
+	} {
+		s := r.FormValue(f.name)
+		if s == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			http.Error(w, "invalid "+f.name, http.StatusBadRequest)
+			return
+		}
+
+		*f.t = &t
+	}
+
+	instances, err := a.repo.ListInstances(filter)
+	if err != nil {
+		logger.Error("error querying instances",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]*adminInstance, len(instances))
+	for i, instance := range instances {
+		resp[i] = newAdminInstance(instance)
+	}
+
+	render.JSON(w, r, resp)
+}
+
+// adminFindInstance finds the instance named in the URL, writing an error
+// and returning nil if it can't be found.
+func (a *App) adminFindInstance(w http.ResponseWriter, r *http.Request) *models.Instance {
+	logger := ctxlog.FromRequest(r)
+
+	instanceID, err := models.ParseID(chi.URLParam(r, "instanceID"))
+	if err != nil {
+		a.httpError(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+
+	instance, err := a.repo.FindInstance(instanceID)
+	if err != nil {
+		if err == models.ErrNotFound {
+			http.NotFound(w, r)
+			return nil
+		}
+
+		logger.Error("error querying instance",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return nil
+	}
+
+	return instance
+}
+
+func (a *App) adminInstance(w http.ResponseWriter, r *http.Request) {
+	instance := a.adminFindInstance(w, r)
+	if instance == nil {
+		return
+	}
+
+	// The container can only be inspected by a node which manages it.
+	if !a.managesInstance(instance) {
+		a.forwardInstance(w, r, instance)
+		return
+	}
+
+	ai := newAdminInstance(instance)
+	ai.Container = &adminContainer{}
+
+	c, err := a.rt.Inspect(r.Context(), instance.ContainerID)
+	switch {
+	case err == nil:
+		ai.Container.Exists = true
+		if c.State != nil {
+			ai.Container.Status = c.State.Status
+			ai.Container.Running = c.State.Running
+			ai.Container.ExitCode = c.State.ExitCode
+			ai.Container.OOMKilled = c.State.OOMKilled
+			ai.Container.StartedAt = c.State.StartedAt
+			ai.Container.FinishedAt = c.State.FinishedAt
+		}
+	case runtime.IsNotFound(err):
+	default:
+		ai.Container.Error = err.Error()
+	}
+
+	render.JSON(w, r, ai)
+}
+
+// adminInstanceExpire expires an instance immediately, closing its terminal
+// sessions. It will be cleaned up along with other inactive instances.
+// Another node's instance is expired by that node.
+func (a *App) adminInstanceExpire(w http.ResponseWriter, r *http.Request) {
+	instance := a.adminFindInstance(w, r)
+	if instance == nil {
+		return
+	}
+
+	logger := ctxlog.FromRequest(r).With(
+		zap.String("instance_id", instance.ID.String()),
+	)
+
+	if instance.Cleaned {
+		http.Error(w, "instance has already been cleaned", http.StatusConflict)
+		return
+	}
+
+	// Terminal sessions are held by the node which owns the instance.
+	if a.forwardInstance(w, r, instance) {
+		return
+	}
+
+	a.expireSessions(instance.ID.String())
+
+	if err := a.repo.Expire(instance, time.Now()); err != nil {
+		logger.Error("error marking instance as expired in database",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info("instance expired by admin")
+	a.publishInstance(eventInstanceExpired, instance)
+
+	render.JSON(w, r, newAdminInstance(instance))
+}
+
+// adminInstanceClean cleans an instance immediately, closing its terminal
+// sessions and removing its container and image.
+func (a *App) adminInstanceClean(w http.ResponseWriter, r *http.Request) {
+	instance := a.adminFindInstance(w, r)
+	if instance == nil {
+		return
+	}
+
+	ctx, logger := ctxlog.FromContextWith(r.Context(),
+		zap.String("instance_id", instance.ID.String()),
+	)
+
+	if instance.Cleaned {
+		http.Error(w, "instance has already been cleaned", http.StatusConflict)
+		return
+	}
+
+	if !a.managesInstance(instance) {
+		a.forwardInstance(w, r, instance)
+		return
+	}
+
+	a.expireSessions(instance.ID.String())
+
+	locked, err := a.withClusterLock(ctx, instance, func(current *models.Instance) error {
+		// The instance may have changed (like by a reset replacing its
+		// container) since it was first looked up.
+		instance = current
+
+		if current.Cleaned {
+			return nil
+		}
+
+		return a.lockInstance(current, func() error {
+			// Cleaning on request starts the spec over, unless the
+			// instance had already expired.
+			return a.cleanInstance(ctx, current, instanceExpired(current))
+		})
+	})
+	if err != nil {
+		logger.Error("error cleaning instance",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !locked {
+		http.Error(w, "instance is being cleaned by another node", http.StatusConflict)
+		return
+	}
+
+	logger.Info("instance cleaned by admin")
+
+	render.JSON(w, r, newAdminInstance(instance))
+}
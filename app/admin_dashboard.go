@@ -0,0 +1,205 @@
+package app
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/docker/docker/api/types"
+	units "github.com/docker/go-units"
+	"github.com/go-chi/chi"
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/sched"
+	"github.com/jakebailey/ua/templates"
+	"go.uber.org/zap"
+)
+
+const (
+	adminDashboardInstances    = 100
+	adminDashboardFailedBuilds = 20
+)
+
+// adminTriggerNames are the keys of adminTriggers, in the order they're shown
+// on the dashboard.
+var adminTriggerNames = []string{"clean_inactive", "check_expired", "checks"}
+
+// adminTriggers returns the scheduled tasks which can be run on demand, by
+// name.
+func (a *App) adminTriggers() map[string][]*sched.Runner {
+	return map[string][]*sched.Runner{
+		"clean_inactive": {a.cleanInactiveRunner},
+		"check_expired":  {a.checkExpiredRunner},
+		"checks":         {a.dockerCheckRunner, a.databaseCheckRunner},
+	}
+}
+
+// adminRunners returns the app's scheduled tasks, in the order they're shown
+// on the dashboard. Tasks which are disabled are omitted.
+func (a *App) adminRunners() []templates.AdminRunner {
+	named := []struct {
+		name   string
+		runner *sched.Runner
+	}{
+		{"clean_inactive", a.cleanInactiveRunner},
+		{"check_expired", a.checkExpiredRunner},
+		{"auto_pull", a.autoPullRunner},
+		{"prune", a.pruneRunner},
+		{"pool", a.poolRunner},
+		{"docker_check", a.dockerCheckRunner},
+		{"database_check", a.databaseCheckRunner},
+	}
+
+	var runners []templates.AdminRunner
+
+	for _, n := range named {
+		if n.runner == nil {
+			continue
+		}
+
+		status := n.runner.Status()
+		runners = append(runners, templates.AdminRunner{
+			Name:       n.name,
+			Every:      status.Every,
+			Running:    status.Running,
+			Runs:       status.Runs,
+			LastStart:  status.LastStart,
+			LastFinish: status.LastFinish,
+		})
+	}
+
+	return runners
+}
+
+// adminDashboard renders an HTML overview of the server, with buttons to run
+// the scheduled tasks in adminTriggers.
+func (a *App) adminDashboard(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := ctxlog.FromContext(ctx)
+
+	// A token given in the URL is moved into a cookie, so that it isn't
+	// kept in the browser's history or passed along in links and forms.
+	query := r.URL.Query()
+	if token := query.Get("token"); token != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     tokenCookie,
+			Value:    token,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+		})
+
+		query.Del("token")
+
+		redirect := "/admin/"
+		if len(query) != 0 {
+			redirect += "?" + query.Encode()
+		}
+
+		http.Redirect(w, r, redirect, http.StatusSeeOther)
+		return
+	}
+
+	p := &templates.AdminPage{
+		Message:  r.FormValue("message"),
+		Runners:  a.adminRunners(),
+		Triggers: adminTriggerNames,
+	}
+
+	active := true
+
+	var err error
+
+	p.Instances, err = a.repo.ListInstances(models.InstanceFilter{
+		Active: &active,
+		Limit:  adminDashboardInstances,
+	})
+	if err != nil {
+		logger.Error("error querying instances",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	p.Assignments, err = a.usageStore.Assignments()
+	if err != nil {
+		logger.Error("error querying assignment usage",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	p.FailedBuilds, err = a.buildLogStore.FindFailures(adminDashboardFailedBuilds)
+	if err != nil {
+		logger.Error("error querying failed builds",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Docker may be down; show what's available rather than failing.
+	if du, err := a.cli.DiskUsage(ctx); err != nil {
+		logger.Warn("error querying docker disk usage",
+			zap.Error(err),
+		)
+		p.DiskUsageError = err.Error()
+	} else {
+		p.DiskUsage = adminDiskUsage(du)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	templates.WriteAdmin(w, p)
+}
+
+func adminDiskUsage(du types.DiskUsage) []templates.AdminDiskUsage {
+	var containers, volumes, buildCache int64
+
+	for _, c := range du.Containers {
+		containers += c.SizeRw
+	}
+
+	for _, v := range du.Volumes {
+		if v.UsageData != nil && v.UsageData.Size > 0 {
+			volumes += v.UsageData.Size
+		}
+	}
+
+	for _, b := range du.BuildCache {
+		buildCache += b.Size
+	}
+
+	return []templates.AdminDiskUsage{
+		{Kind: "images", Count: len(du.Images), Size: units.HumanSize(float64(du.LayersSize))},
+		{Kind: "containers", Count: len(du.Containers), Size: units.HumanSize(float64(containers))},
+		{Kind: "volumes", Count: len(du.Volumes), Size: units.HumanSize(float64(volumes))},
+		{Kind: "build cache", Count: len(du.BuildCache), Size: units.HumanSize(float64(buildCache))},
+	}
+}
+
+// adminTrigger runs one of the tasks in adminTriggers, then redirects back to
+// the dashboard.
+func (a *App) adminTrigger(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	runners, ok := a.adminTriggers()[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	for _, runner := range runners {
+		runner.Run()
+	}
+
+	ctxlog.FromRequest(r).Info("task triggered by admin",
+		zap.String("task", name),
+	)
+
+	query := url.Values{}
+	query.Set("message", "Triggered "+name+".")
+
+	http.Redirect(w, r, "/admin/?"+query.Encode(), http.StatusSeeOther)
+}
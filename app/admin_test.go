@@ -0,0 +1,131 @@
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testAdminToken = "admin-token"
+
+// admin sends an admin request authorized with the admin token, returning
+// the response.
+func (ta *testApp) admin(method, path string) (int, []byte) {
+	req, err := http.NewRequest(method, ta.srv.URL+"/admin"+path, nil)
+	if err != nil {
+		ta.t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		ta.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		ta.t.Fatal(err)
+	}
+
+	return resp.StatusCode, b
+}
+
+func TestAdminExpireDockerDown(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	ta.app.config.PProfToken = testAdminToken
+
+	instanceID := ta.createInstance(newSpecID(), map[string]string{"secret": "hunter2"})
+
+	ta.docker.SetDown(true)
+	ta.app.precheckDockerTask()
+
+	if code, body := ta.admin("GET", "/instances/"+instanceID); code != http.StatusInternalServerError {
+		t.Errorf("expected inspecting to need Docker, got %d: %s", code, body)
+	}
+
+	if code, body := ta.admin("POST", "/instances/"+instanceID+"/expire"); code != http.StatusOK {
+		t.Fatalf("expected 200 expiring while Docker is down, got %d: %s", code, body)
+	}
+
+	if instance := ta.instance(instanceID); instance.Active {
+		t.Error("expected expired instance to be inactive")
+	}
+}
+
+func TestAdminTokenCookie(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	ta.app.config.PProfToken = testAdminToken
+
+	noRedirect := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// Logging in with the token in the URL moves it into a cookie.
+	resp, err := noRedirect.Get(ta.srv.URL + "/admin/?token=" + testAdminToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/admin/" {
+		t.Fatalf("expected redirect to /admin/, got %d to %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	var cookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == tokenCookie {
+			cookie = c
+		}
+	}
+	if cookie == nil || !cookie.HttpOnly {
+		t.Fatalf("expected an HTTP-only token cookie, got %v", resp.Cookies())
+	}
+
+	req, err := http.NewRequest("GET", ta.srv.URL+"/admin/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.AddCookie(cookie)
+
+	resp, err = noRedirect.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 for the dashboard with the cookie, got %d: %s", resp.StatusCode, body)
+	}
+
+	if strings.Contains(string(body), testAdminToken) {
+		t.Error("expected the dashboard not to contain the token")
+	}
+
+	req, err = http.NewRequest("POST", ta.srv.URL+"/admin/trigger/checks", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.AddCookie(cookie)
+
+	resp, err = noRedirect.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if location := resp.Header.Get("Location"); resp.StatusCode != http.StatusSeeOther || strings.Contains(location, testAdminToken) {
+		t.Errorf("expected a redirect without the token, got %d to %q", resp.StatusCode, location)
+	}
+}
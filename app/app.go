@@ -3,25 +3,24 @@ package app
 import (
 	"context"
 	"crypto/tls"
-	"database/sql"
 	"encoding/base64"
-	"errors"
 	"net/http"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
-	_ "github.com/lib/pq" // postgresql driver
-
 	"github.com/davecgh/go-spew/spew"
 	"github.com/docker/docker/client"
 	"github.com/go-chi/chi"
-	"github.com/jakebailey/ua/migrations"
 	"github.com/jakebailey/ua/models"
 	"github.com/jakebailey/ua/pkg/docker/dcompat"
+	"github.com/jakebailey/ua/pkg/events"
 	"github.com/jakebailey/ua/pkg/expire"
+	"github.com/jakebailey/ua/pkg/runtime"
 	"github.com/jakebailey/ua/pkg/sched"
+	"github.com/jakebailey/ua/pkg/simplecrypto"
+	"github.com/jakebailey/ua/storage"
 	cache "github.com/patrickmn/go-cache"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
@@ -39,10 +38,20 @@ type App struct {
 	spew   *spew.ConfigState
 
 	cli client.CommonAPIClient
+	rt  runtime.Runtime
+
+	// nodeName and dockerHost are recorded on the instances this node
+	// creates; see node.go.
+	nodeName   string
+	dockerHost string
 
-	db            *sql.DB
-	specStore     *models.SpecStore
-	instanceStore *models.InstanceStore
+	db            *storage.DB
+	locker        locker
+	repo          models.Repository
+	buildLogStore *models.BuildLogStore
+	sidecarStore  *models.SidecarStore
+	snapshotStore *models.SnapshotStore
+	usageStore    *models.UsageStore
 
 	cleanInactiveRunner *sched.Runner
 	checkExpiredRunner  *sched.Runner
@@ -50,13 +59,23 @@ type App struct {
 	wsWG      sync.WaitGroup
 	wsManager *expire.Manager
 
-	aesKey []byte
+	sessionsMu sync.Mutex
+	sessions   map[string]*instanceSessions
+
+	events  *events.Bus
+	metrics *metrics
+
+	keyring     *simplecrypto.Keyring
+	observerKey []byte
 
 	autoPullRunner *sched.Runner
 	autoPullImages *cache.Cache
 
 	pruneRunner *sched.Runner
 
+	pool       *instancePool
+	poolRunner *sched.Runner
+
 	dockerCheckRunner   *sched.Runner
 	dockerOk            atomic.Bool
 	databaseCheckRunner *sched.Runner
@@ -68,9 +87,11 @@ type App struct {
 // Run is called.
 func NewApp(config *Config, options ...Option) (*App, error) {
 	a := &App{
-		config: DefaultConfig,
-		logger: zap.NewNop(),
-		spew:   &spew.ConfigState{Indent: "    ", ContinueOnMethod: true},
+		config:   DefaultConfig,
+		logger:   zap.NewNop(),
+		spew:     &spew.ConfigState{Indent: "    ", ContinueOnMethod: true},
+		sessions: make(map[string]*instanceSessions),
+		events:   events.NewBus(eventsBuffer),
 	}
 
 	if config != nil {
@@ -81,27 +102,25 @@ func NewApp(config *Config, options ...Option) (*App, error) {
 		return nil, err
 	}
 
-	if a.config.AESKey != "" {
-		key, err := base64.StdEncoding.DecodeString(a.config.AESKey)
+	keyring, err := a.config.keyring()
+	if err != nil {
+		return nil, err
+	}
+	a.keyring = keyring
+
+	if a.config.ObserverKey != "" {
+		key, err := base64.StdEncoding.DecodeString(a.config.ObserverKey)
 		if err != nil {
 			return nil, err
 		}
-		a.aesKey = key
+		a.observerKey = key
 	}
 
 	for _, o := range options {
 		o(a)
 	}
 
-	switch len(a.aesKey) {
-	case 0:
-		return nil, errors.New("zero-length aes key")
-	case 16, 24, 32:
-		// Do nothing.
-	default:
-		return nil, errors.New("AES key must be of length 16, 24, or 32")
-	}
-
+	a.metrics = a.newMetrics()
 	a.route()
 
 	return a, nil
@@ -110,6 +129,16 @@ func NewApp(config *Config, options ...Option) (*App, error) {
 // Run runs the app, opening docker/db/etc connections. This function blocks
 // until an error occurs, or the app closes.
 func (a *App) Run() error {
+	if err := a.start(); err != nil {
+		return err
+	}
+
+	return a.serve()
+}
+
+// start opens the app's connections and starts its scheduled tasks, but does
+// not start the HTTP server.
+func (a *App) start() error {
 	if a.cli == nil {
 		cli, err := client.NewClientWithOpts(client.FromEnv)
 		if err != nil {
@@ -123,28 +152,50 @@ func (a *App) Run() error {
 	}
 	a.cli = dcompat.Wrap(a.cli)
 
+	if a.rt == nil {
+		a.rt = runtime.NewDocker(a.cli)
+	}
+
 	var err error
 
+	a.nodeName = a.config.NodeName
+	if a.nodeName == "" {
+		if a.nodeName, err = os.Hostname(); err != nil {
+			a.logger.Error("error getting hostname for node name",
+				zap.Error(err),
+			)
+			return err
+		}
+	}
+	a.dockerHost = a.cli.DaemonHost()
+
+	a.logger.Info("starting node",
+		zap.String("node", a.nodeName),
+		zap.String("docker_host", a.dockerHost),
+	)
+
 	a.precheckDockerTask()
 
-	if a.db, err = sql.Open("postgres", a.config.Database); err != nil {
-		a.logger.Error("error opening database",
-			zap.Error(err),
-		)
-		return err
+	if a.db == nil {
+		if a.db, err = storage.Open(a.config.Database); err != nil {
+			a.logger.Error("error opening database",
+				zap.Error(err),
+			)
+			return err
+		}
 	}
 
 	a.precheckDatabaseTask()
 
 	if a.config.MigrateReset {
-		if err = migrations.Reset(a.db); err != nil {
+		if err = a.db.Reset(); err != nil {
 			a.logger.Error("error resetting database",
 				zap.Error(err),
 			)
 			return err
 		}
 	} else if a.config.MigrateUp {
-		if err = migrations.Up(a.db); err != nil {
+		if err = a.db.Up(); err != nil {
 			a.logger.Error("error migrating database up",
 				zap.Error(err),
 			)
@@ -152,13 +203,16 @@ func (a *App) Run() error {
 		}
 	}
 
-	a.specStore = models.NewSpecStore(a.db)
-	a.instanceStore = models.NewInstanceStore(a.db)
+	a.repo = a.db.Repository
+	a.locker = a.db
+	a.buildLogStore = models.NewBuildLogStore(a.db.DB)
+	a.sidecarStore = models.NewSidecarStore(a.db.DB)
+	a.snapshotStore = models.NewSnapshotStore(a.db.DB)
+	a.usageStore = models.NewUsageStore(a.db.DB)
 
 	if a.config.ForceInactive {
 		// Ensure that the database doesn't have any already active or uncleaned
-		// instances. For now, only one of these servers will run at a time. This
-		// will need to be removed once the platform improves.
+		// instances belonging to this node.
 		//
 		// Update: This should really only be set locally, when the server
 		// doesn't stick around long enough to manage Docker properly.
@@ -182,6 +236,14 @@ func (a *App) Run() error {
 	a.pruneRunner = sched.NewRunner(a.pruneDocker, a.config.PruneEvery)
 	a.pruneRunner.Start()
 
+	a.removeLeftoverPoolContainers()
+
+	if a.config.PoolSize > 0 {
+		a.pool = newInstancePool(a.config.PoolExpiry)
+		a.poolRunner = sched.NewRunner(a.fillPool, a.config.PoolEvery)
+		a.poolRunner.Start()
+	}
+
 	a.wsManager = expire.NewManager(time.Minute, a.config.WebsocketTimeout)
 	a.wsManager.Run()
 
@@ -193,6 +255,10 @@ func (a *App) Run() error {
 	a.databaseCheckRunner = sched.NewRunner(a.precheckDatabaseTask, 30*time.Second)
 	a.databaseCheckRunner.Start()
 
+	return nil
+}
+
+func (a *App) serve() error {
 	errorLog, err := zap.NewStdLogAt(a.logger, zap.DebugLevel)
 	if err != nil {
 		return err
@@ -276,18 +342,28 @@ func (a *App) Shutdown() {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	a.logger.Info("shutting down http server")
-	if err := a.srv.Shutdown(ctx); err != nil {
-		a.logger.Error("error shutting down http server",
-			zap.Error(err),
-		)
+	// Event streams never end on their own.
+	a.events.Close()
+
+	if a.srv != nil {
+		a.logger.Info("shutting down http server")
+		if err := a.srv.Shutdown(ctx); err != nil {
+			a.logger.Error("error shutting down http server",
+				zap.Error(err),
+			)
+		}
 	}
 
 	a.logger.Info("stopping scheduled tasks")
 	a.cleanInactiveRunner.Stop()
 	a.checkExpiredRunner.Stop()
-	a.autoPullRunner.Stop()
+	if a.autoPullRunner != nil {
+		a.autoPullRunner.Stop()
+	}
 	a.pruneRunner.Stop()
+	if a.poolRunner != nil {
+		a.poolRunner.Stop()
+	}
 	a.dockerCheckRunner.Stop()
 	a.databaseCheckRunner.Stop()
 
@@ -299,6 +375,7 @@ func (a *App) Shutdown() {
 	a.wsWG.Wait()
 
 	a.cleanupLeftoverInstances()
+	a.drainPool()
 
 	if a.config.ForceInactive {
 		// This shouldn't be needed, but by this point all instances should be both
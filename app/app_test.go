@@ -0,0 +1,365 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/docker/dockertest"
+	"github.com/jakebailey/ua/pkg/simplecrypto"
+)
+
+// testAssignment is the index.js of the "echo" assignment used in tests. Its
+// instances run "cat", which the fake echoes, and its post-build actions
+// run whatever commands the spec asks for. Services and the grade command
+// are passed through from the spec.
+const testAssignment = `
+exports.generate = function(data) {
+	return {
+		imageName: "alpine",
+		init: false,
+		postBuild: [
+			{
+				action: "write",
+				user: "root",
+				filename: "/secret",
+				contents: data.secret
+			},
+			{
+				action: "exec",
+				user: "root",
+				cmd: data.postBuild || ["true"]
+			}
+		],
+		services: data.services,
+		grade: data.grade,
+		cmd: ["cat"]
+	};
+};
+`
+
+// testApp is an App running against a fake Docker daemon and a throwaway
+// SQLite database, served over a test HTTP server.
+type testApp struct {
+	t *testing.T
+
+	app    *App
+	docker *dockertest.Client
+	srv    *httptest.Server
+	key    []byte
+	dir    string
+
+	// ownsDir is set on the first node, which removes dir when closed.
+	ownsDir bool
+
+	mu    sync.Mutex
+	files map[string]string
+}
+
+func newTestApp(t *testing.T) *testApp {
+	dir, err := ioutil.TempDir("", "ua-app-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assignmentDir := filepath.Join(dir, "assignments", "echo")
+	if err := os.MkdirAll(assignmentDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(assignmentDir, "index.js"), []byte(testAssignment), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+
+	ta := startTestApp(t, dir, key, "a")
+	ta.ownsDir = true
+	return ta
+}
+
+// newNode starts another node sharing the app's database and assignments,
+// with its own fake Docker daemon.
+func (ta *testApp) newNode(name string) *testApp {
+	return startTestApp(ta.t, ta.dir, ta.key, name)
+}
+
+func startTestApp(t *testing.T, dir string, key []byte, nodeName string) *testApp {
+	ta := &testApp{
+		t:      t,
+		docker: dockertest.New(),
+		key:    key,
+		dir:    dir,
+		files:  make(map[string]string),
+	}
+	ta.docker.ExecFunc = ta.exec
+	ta.docker.AddImage("alpine")
+
+	// The server is started first so that the node knows its own URL.
+	ta.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ta.app.router.ServeHTTP(w, r)
+	}))
+
+	config := DefaultConfig
+	config.Database = "sqlite://" + filepath.Join(dir, "ua.db")
+	config.MigrateUp = true
+	config.AssignmentPath = filepath.Join(dir, "assignments")
+	config.AESKey = base64.StdEncoding.EncodeToString(key)
+	config.DisableAutoPull = true
+	config.NodeName = nodeName
+	config.NodeURL = ta.srv.URL
+
+	var err error
+	ta.app, err = NewApp(&config, WithDockerClient(ta.docker))
+	if err != nil {
+		ta.srv.Close()
+		t.Fatal(err)
+	}
+
+	if err := ta.app.start(); err != nil {
+		ta.srv.Close()
+		t.Fatal(err)
+	}
+
+	return ta
+}
+
+// close shuts down the app, which cleans up its remaining instances.
+func (ta *testApp) close() {
+	ta.srv.Close()
+	ta.app.Shutdown()
+
+	if !ta.ownsDir {
+		return
+	}
+
+	if err := os.RemoveAll(ta.dir); err != nil {
+		ta.t.Error(err)
+	}
+}
+
+// exec runs processes in the fake's containers. "cat" echoes its input,
+// "sh -c 'exec cat > file'" (the write action) records the written file,
+// and "true" and "false" exit as expected.
+func (ta *testApp) exec(containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	switch {
+	case len(cmd) == 1 && cmd[0] == "cat":
+		if _, err := io.Copy(stdout, stdin); err != nil {
+			return 1
+		}
+		return 0
+
+	case len(cmd) == 3 && cmd[0] == "sh" && strings.HasPrefix(cmd[2], "exec cat > "):
+		b, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return 1
+		}
+
+		ta.mu.Lock()
+		ta.files[containerID+":"+strings.TrimPrefix(cmd[2], "exec cat > ")] = string(b)
+		ta.mu.Unlock()
+		return 0
+
+	case len(cmd) == 1 && cmd[0] == "true":
+		return 0
+
+	default:
+		_, _ = io.WriteString(stderr, "command failed")
+		return 1
+	}
+}
+
+func (ta *testApp) file(containerID, name string) string {
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+
+	return ta.files[containerID+":"+name]
+}
+
+// post posts an encrypted spec request, returning the response.
+func (ta *testApp) post(path string, specID string, data interface{}) (int, []byte) {
+	payload, err := json.Marshal(&specPostRequest{
+		SpecID:         specID,
+		AssignmentName: "echo",
+		Data:           data,
+	})
+	if err != nil {
+		ta.t.Fatal(err)
+	}
+
+	body, err := simplecrypto.EncodeJSON(ta.key, payload)
+	if err != nil {
+		ta.t.Fatal(err)
+	}
+
+	resp, err := http.Post(ta.srv.URL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		ta.t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		ta.t.Fatal(err)
+	}
+
+	return resp.StatusCode, b
+}
+
+// createInstance posts a spec, and returns the ID of its instance.
+func (ta *testApp) createInstance(specID string, data interface{}) string {
+	code, body := ta.post("/spec", specID, data)
+	if code != http.StatusOK {
+		ta.t.Fatalf("expected 200 creating instance, got %d: %s", code, body)
+	}
+
+	var resp specPostResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		ta.t.Fatal(err)
+	}
+
+	return resp.InstanceID
+}
+
+// clean synchronously cleans a spec's instances.
+func (ta *testApp) clean(specID string) {
+	if code, body := ta.post("/spec/clean?async=false", specID, nil); code != http.StatusOK {
+		ta.t.Fatalf("expected 200 cleaning spec, got %d: %s", code, body)
+	}
+}
+
+// wsClient is a client of an instance's terminal websocket.
+type wsClient struct {
+	t    *testing.T
+	conn net.Conn
+	rw   io.ReadWriter
+}
+
+// dial connects to an instance's terminal websocket, returning the HTTP
+// status code if the upgrade fails.
+func (ta *testApp) dial(instanceID string) (*wsClient, int) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	url := "ws" + strings.TrimPrefix(ta.srv.URL, "http") + "/instance/" + instanceID + "/ws"
+
+	conn, br, _, err := ws.Dial(ctx, url)
+	if err != nil {
+		if se, ok := err.(ws.StatusError); ok {
+			return nil, int(se)
+		}
+		ta.t.Fatal(err)
+	}
+
+	var r io.Reader = conn
+	if br != nil {
+		r = br
+	}
+
+	return &wsClient{
+		t:    ta.t,
+		conn: conn,
+		rw: struct {
+			io.Reader
+			io.Writer
+		}{bufio.NewReader(r), conn},
+	}, http.StatusSwitchingProtocols
+}
+
+func (c *wsClient) send(msg ...interface{}) {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	if err := wsutil.WriteClientText(c.rw, b); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// readStdout reads stdout messages until the expected output has been
+// seen, or the connection closes.
+func (c *wsClient) readStdout(expected string) (string, error) {
+	if err := c.conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		return "", err
+	}
+
+	var out string
+
+	for !strings.Contains(out, expected) {
+		b, err := wsutil.ReadServerText(c.rw)
+		if err != nil {
+			return out, err
+		}
+
+		var msg []string
+		if err := json.Unmarshal(b, &msg); err != nil {
+			return out, err
+		}
+
+		if len(msg) == 2 && msg[0] == "stdout" {
+			out += msg[1]
+		}
+	}
+
+	return out, nil
+}
+
+// waitClosed waits for the server to close the connection.
+func (c *wsClient) waitClosed() {
+	if err := c.conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		c.t.Fatal(err)
+	}
+
+	for {
+		if _, err := wsutil.ReadServerText(c.rw); err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				c.t.Fatal("timed out waiting for websocket to close")
+			}
+			return
+		}
+	}
+}
+
+func (c *wsClient) close() {
+	if err := c.conn.Close(); err != nil {
+		c.t.Error(err)
+	}
+}
+
+// waitFor polls until cond returns true, failing the test after a timeout.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func newSpecID() string {
+	return models.NewID().String()
+}
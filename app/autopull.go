@@ -4,7 +4,6 @@ import (
 	"context"
 	"time"
 
-	"github.com/jakebailey/ua/pkg/docker/image"
 	"go.uber.org/zap"
 )
 
@@ -34,13 +33,16 @@ func (a *App) autoPull() {
 
 		before := time.Now()
 
-		if err := image.Pull(ctx, a.cli, ref); err != nil {
+		if err := a.rt.Pull(ctx, ref); err != nil {
 			logger.Error("error auto-pulling image",
 				zap.Error(err),
 			)
+			a.metrics.autoPulls.WithLabelValues(resultLabel(false)).Inc()
 			continue
 		}
 
+		a.metrics.autoPulls.WithLabelValues(resultLabel(true)).Inc()
+
 		logger.Info("auto-pulled image",
 			zap.Duration("took", time.Since(before)),
 		)
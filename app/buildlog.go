@@ -0,0 +1,115 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/jakebailey/ua/app/specbuild"
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"go.uber.org/zap"
+)
+
+const defaultBuildLogLimit = 20
+
+// insertBuildLog stores the build log for an instance. buildErr is the error
+// returned by the build, if any.
+func (a *App) insertBuildLog(ctx context.Context, specID models.ID, instanceID models.ID, assignmentName string, actionLog *specbuild.ActionLog, buildErr error) {
+	logger := ctxlog.FromContext(ctx)
+
+	actions, err := json.Marshal(actionLog.Entries())
+	if err != nil {
+		logger.Error("error marshalling build log actions",
+			zap.Error(err),
+		)
+		return
+	}
+
+	buildLog := models.NewBuildLog()
+	buildLog.SpecID = specID
+	buildLog.InstanceID = instanceID
+	buildLog.AssignmentName = assignmentName
+	buildLog.Success = buildErr == nil
+	buildLog.Actions = actions
+
+	if buildErr != nil {
+		buildLog.Error = buildErr.Error()
+	}
+
+	if err := a.buildLogStore.Insert(buildLog); err != nil {
+		logger.Error("error inserting build log",
+			zap.Error(err),
+		)
+	}
+}
+
+func (a *App) debugBuildLogs(w http.ResponseWriter, r *http.Request) {
+	logger := ctxlog.FromRequest(r)
+
+	var specID models.ID
+
+	if s := r.FormValue("specID"); s != "" {
+		var err error
+		specID, err = models.ParseID(s)
+		if err != nil {
+			a.httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := defaultBuildLogLimit
+
+	if s := r.FormValue("limit"); s != "" {
+		var err error
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	logs, err := a.buildLogStore.FindRecent(specID, r.FormValue("assignmentName"), limit)
+	if err != nil {
+		logger.Error("error querying build logs",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if logs == nil {
+		logs = []*models.BuildLog{}
+	}
+
+	render.JSON(w, r, logs)
+}
+
+func (a *App) debugBuildLog(w http.ResponseWriter, r *http.Request) {
+	logger := ctxlog.FromRequest(r)
+
+	instanceID, err := models.ParseID(chi.URLParam(r, "instanceID"))
+	if err != nil {
+		a.httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	buildLog, err := a.buildLogStore.FindByInstance(instanceID)
+	if err != nil {
+		if err == models.ErrNotFound {
+			http.NotFound(w, r)
+			return
+		}
+
+		logger.Error("error querying build log",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, buildLog)
+}
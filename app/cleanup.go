@@ -5,31 +5,36 @@ import (
 	"strings"
 	"time"
 
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/api/types/filters"
-	"github.com/docker/docker/client"
 	units "github.com/docker/go-units"
 	"github.com/jakebailey/ua/models"
 	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/runtime"
 	"go.uber.org/zap"
-	kallax "gopkg.in/src-d/go-kallax.v1"
 )
 
-func (a *App) cleanInstance(ctx context.Context, instance *models.Instance) error {
+// cleanInstance removes an instance's container, sidecars, network, and
+// image, marking it cleaned. If snapshot is set (and snapshots are enabled),
+// the container is snapshotted first.
+func (a *App) cleanInstance(ctx context.Context, instance *models.Instance, snapshot bool) error {
 	ctx, logger := ctxlog.FromContextWith(ctx,
 		zap.String("instance_id", instance.ID.String()),
 	)
 
-	cOpts := types.ContainerRemoveOptions{RemoveVolumes: true}
-	iOpts := types.ImageRemoveOptions{PruneChildren: true}
+	if snapshot && a.config.SnapshotInstances {
+		if err := a.snapshotInstance(ctx, instance); err != nil {
+			logger.Error("error snapshotting instance, continuing",
+				zap.Error(err),
+			)
+		}
+	}
 
 	logger.Debug("killing container",
 		zap.String("container_id", instance.ContainerID),
 	)
 
 	// Send KILL, since we don't care about the state of the container anyway and it's faster
-	if err := a.cli.ContainerKill(ctx, instance.ContainerID, "KILL"); err != nil {
-		if !client.IsErrNotFound(err) && !strings.Contains(err.Error(), "not running") {
+	if err := a.rt.Kill(ctx, instance.ContainerID); err != nil {
+		if !runtime.IsNotFound(err) && !strings.Contains(err.Error(), "not running") {
 			logger.Warn("error killing container, will attempt to continue cleaning anyway",
 				zap.Error(err),
 				zap.String("container_id", instance.ContainerID),
@@ -41,8 +46,8 @@ func (a *App) cleanInstance(ctx context.Context, instance *models.Instance) erro
 		zap.String("container_id", instance.ContainerID),
 	)
 
-	if err := a.cli.ContainerRemove(ctx, instance.ContainerID, cOpts); err != nil {
-		if !client.IsErrNotFound(err) {
+	if err := a.rt.Remove(ctx, instance.ContainerID, false); err != nil {
+		if !runtime.IsNotFound(err) {
 			logger.Error("error removing container",
 				zap.Error(err),
 				zap.String("container_id", instance.ContainerID),
@@ -55,12 +60,25 @@ func (a *App) cleanInstance(ctx context.Context, instance *models.Instance) erro
 		)
 	}
 
+	if err := a.cleanSidecars(ctx, instance); err != nil {
+		logger.Error("error removing sidecars",
+			zap.Error(err),
+		)
+		return err
+	}
+
+	if err := a.removeIsolatedNetwork(ctx, "ua-"+instance.ID.String()); err != nil {
+		logger.Warn("error removing isolated network, continuing",
+			zap.Error(err),
+		)
+	}
+
 	logger.Debug("removing image",
 		zap.String("image_id", instance.ImageID),
 	)
 
-	if _, err := a.cli.ImageRemove(ctx, instance.ImageID, iOpts); err != nil {
-		if !client.IsErrNotFound(err) && !strings.Contains(err.Error(), "image is being used by stopped container") {
+	if err := a.rt.RemoveImage(ctx, instance.ImageID); err != nil {
+		if !runtime.IsNotFound(err) && !strings.Contains(err.Error(), "image is being used by stopped container") {
 			logger.Error("error removing image",
 				zap.Error(err),
 				zap.String("image_id", instance.ImageID),
@@ -73,16 +91,15 @@ func (a *App) cleanInstance(ctx context.Context, instance *models.Instance) erro
 		)
 	}
 
-	instance.Active = false
-	instance.Cleaned = true
-
-	if _, err := a.instanceStore.Update(instance, models.Schema.Instance.Active, models.Schema.Instance.Cleaned); err != nil {
+	if err := a.repo.MarkCleaned(instance); err != nil {
 		logger.Error("error marking instance as cleaned in database",
 			zap.Error(err),
 		)
 		return err
 	}
 
+	a.publishInstance(eventInstanceCleaned, instance)
+
 	return nil
 }
 
@@ -92,14 +109,11 @@ func (a *App) checkExpiredInstances() {
 	}
 
 	logger := a.logger
-
-	instanceQuery := models.NewInstanceQuery().
-		FindByActive(true).
-		FindByExpiresAt(kallax.Lt, time.Now())
+	ctx := ctxlog.WithLogger(context.Background(), logger)
 
 	logger.Debug("looking for instances to expire")
 
-	instances, err := a.instanceStore.Find(instanceQuery)
+	instances, err := a.repo.ListExpired(time.Now())
 	if err != nil {
 		logger.Error("error querying for expired instances",
 			zap.Error(err),
@@ -109,22 +123,33 @@ func (a *App) checkExpiredInstances() {
 
 	count := 0
 
-	if err := instances.ForEach(func(instance *models.Instance) error {
-		instance.Active = false
+	for _, instance := range instances {
+		expired := false
 
-		if _, err := a.instanceStore.Update(instance, models.Schema.Instance.Active); err != nil {
+		if _, err := a.withClusterLock(ctx, instance, func(instance *models.Instance) error {
+			// Another node may have expired it already.
+			if !instance.Active {
+				return nil
+			}
+
+			if err := a.repo.MarkInactive(instance); err != nil {
+				return err
+			}
+
+			a.publishInstance(eventInstanceExpired, instance)
+			expired = true
+
+			return nil
+		}); err != nil {
 			logger.Error("error marking instance as inactive in database",
 				zap.Error(err),
+				zap.String("instance_id", instance.ID.String()),
 			)
 		}
 
-		count++
-
-		return nil
-	}); err != nil {
-		logger.Error("error while looping over instances",
-			zap.Error(err),
-		)
+		if expired {
+			count++
+		}
 	}
 
 	if count != 0 {
@@ -137,18 +162,20 @@ func (a *App) checkExpiredInstances() {
 }
 
 func (a *App) cleanInactiveInstances() {
+	// Recordings are kept on disk, so can be pruned even if Docker or the
+	// database are unavailable.
+	a.pruneRecordings()
+
 	if !a.precheckDocker() && !a.precheckDatabase() {
 		return
 	}
 
 	ctx := ctxlog.WithLogger(context.Background(), a.logger)
 
-	instanceQuery := models.NewInstanceQuery().
-		FindByActive(false).
-		FindByCleaned(false)
-
 	a.logger.Debug("cleaning up inactive instances")
-	a.cleanupInstancesByQuery(ctx, instanceQuery)
+	a.cleanupInstances(ctx, a.repo.ListInactiveUncleaned)
+
+	a.pruneSnapshots(ctx)
 }
 
 func (a *App) cleanupLeftoverInstances() {
@@ -158,16 +185,21 @@ func (a *App) cleanupLeftoverInstances() {
 
 	ctx := ctxlog.WithLogger(context.Background(), a.logger)
 
-	instanceQuery := models.NewInstanceQuery().FindByActive(true)
-
 	a.logger.Debug("cleaning up leftover instances")
-	a.cleanupInstancesByQuery(ctx, instanceQuery)
+	a.cleanupInstances(ctx, func() ([]*models.Instance, error) {
+		instances, err := a.repo.ListActive()
+		return a.ownedInstances(instances), err
+	})
 }
 
-func (a *App) cleanupInstancesByQuery(ctx context.Context, instanceQuery *models.InstanceQuery) {
+// cleanupInstances cleans the instances returned by list. Active instances
+// whose containers are managed by another node are marked inactive instead,
+// to be cleaned by that node. Until then, their containers (and any terminal
+// sessions connected to them) keep running on that node.
+func (a *App) cleanupInstances(ctx context.Context, list func() ([]*models.Instance, error)) {
 	logger := ctxlog.FromContext(ctx)
 
-	instances, err := a.instanceStore.Find(instanceQuery)
+	instances, err := list()
 	if err != nil {
 		logger.Error("error querying for leftover instances",
 			zap.Error(err),
@@ -177,22 +209,53 @@ func (a *App) cleanupInstancesByQuery(ctx context.Context, instanceQuery *models
 
 	count := 0
 
-	if err := instances.ForEach(func(instance *models.Instance) error {
-		if err := a.cleanInstance(ctx, instance); err != nil {
+	for _, instance := range instances {
+		if !a.managesInstance(instance) {
+			if !instance.Active {
+				continue
+			}
+
+			if _, err := a.withClusterLock(ctx, instance, func(instance *models.Instance) error {
+				// Another node may have marked it already.
+				if !instance.Active {
+					return nil
+				}
+
+				return a.repo.MarkInactive(instance)
+			}); err != nil {
+				logger.Error("error marking instance as inactive in database",
+					zap.Error(err),
+					zap.String("instance_id", instance.ID.String()),
+				)
+			}
+			continue
+		}
+
+		cleaned := false
+
+		if _, err := a.withClusterLock(ctx, instance, func(instance *models.Instance) error {
+			// Another node may have cleaned it already.
+			if instance.Cleaned {
+				return nil
+			}
+
+			if err := a.cleanInstance(ctx, instance, keepsWork(instance)); err != nil {
+				return err
+			}
+
+			cleaned = true
+			return nil
+		}); err != nil {
 			logger.Error("error cleaning instance",
 				zap.Error(err),
 				zap.String("instance_id", instance.ID.String()),
 			)
-			return nil
+			continue
 		}
 
-		count++
-
-		return nil
-	}); err != nil {
-		logger.Error("error while looping over instances",
-			zap.Error(err),
-		)
+		if cleaned {
+			count++
+		}
 	}
 
 	if count != 0 {
@@ -217,15 +280,9 @@ func (a *App) markAllInstancesCleanedAndInactive() {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
 	defer cancel()
 
-	instanceQuery := models.NewInstanceQuery().
-		Where(kallax.Or(
-			kallax.Eq(models.Schema.Instance.Active, true),
-			kallax.Eq(models.Schema.Instance.Cleaned, false),
-		))
-
 	logger.Debug("marking all instances as cleaned and inactive")
 
-	instances, err := a.instanceStore.Find(instanceQuery)
+	instances, err := a.repo.ListActiveOrUncleaned()
 	if err != nil {
 		logger.Error("error querying for uncleaned or active instances",
 			zap.Error(err),
@@ -233,31 +290,36 @@ func (a *App) markAllInstancesCleanedAndInactive() {
 		return
 	}
 
-	count := 0
+	// Other nodes' instances are left to them.
+	instances = a.ownedInstances(instances)
 
-	if err := instances.ForEach(func(instance *models.Instance) error {
-		instance.Active = false
-		instance.Cleaned = true
+	count := 0
 
-		if err := a.cleanInstance(ctx, instance); err != nil {
-			logger.Error("error forcing instance to be removed from docker",
+	for _, instance := range instances {
+		locked, err := a.withClusterLock(ctx, instance, func(instance *models.Instance) error {
+			if err := a.cleanInstance(ctx, instance, keepsWork(instance)); err != nil {
+				logger.Error("error forcing instance to be removed from docker",
+					zap.Error(err),
+				)
+			}
+
+			return a.repo.MarkCleaned(instance)
+		})
+		if err != nil {
+			logger.Error("error forcing instance to be cleaned and inactive in database",
 				zap.Error(err),
 			)
+			continue
 		}
 
-		if _, err := a.instanceStore.Update(instance, models.Schema.Instance.Active, models.Schema.Instance.Cleaned); err != nil {
-			logger.Error("error forcing instance to be cleaned and inactive in database",
-				zap.Error(err),
+		if !locked {
+			logger.Warn("instance is locked by another node, skipping",
+				zap.String("instance_id", instance.ID.String()),
 			)
+			continue
 		}
 
 		count++
-
-		return nil
-	}); err != nil {
-		logger.Error("error while looping over instances",
-			zap.Error(err),
-		)
 	}
 
 	if count != 0 {
@@ -274,65 +336,35 @@ func (a *App) pruneDocker() {
 		return
 	}
 
-	// Order: containers, networks, volumes, images, then build cache
-	// (from docker system prune).
-
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancel()
 
 	logger := a.logger
 
-	contReport, err := a.cli.ContainersPrune(ctx, filters.NewArgs(
-		filters.Arg("label", "ua.owned=true"),
-		filters.Arg("until", "24h"),
-	))
+	report, err := a.rt.Prune(ctx, 24*time.Hour)
 	if err != nil {
-		logger.Warn("error pruning containers",
+		logger.Warn("error pruning",
 			zap.Error(err),
 		)
-		contReport = types.ContainersPruneReport{}
 	}
 
-	netReport, err := a.cli.NetworksPrune(ctx, filters.NewArgs(
-		filters.Arg("until", "24h"),
-	))
-	if err != nil {
-		logger.Warn("error pruning networks",
-			zap.Error(err),
-		)
-		netReport = types.NetworksPruneReport{}
-	}
-
-	volReport, err := a.cli.VolumesPrune(ctx, filters.NewArgs())
-	if err != nil {
-		logger.Warn("error pruning volumes",
-			zap.Error(err),
-		)
-		volReport = types.VolumesPruneReport{}
-	}
-
-	imgReport, err := a.cli.ImagesPrune(ctx, filters.NewArgs(
-		filters.Arg("dangling", "true"),
-		filters.Arg("until", "24h"),
-	))
-	if err != nil {
-		logger.Warn("error pruning images",
-			zap.Error(err),
-		)
-		imgReport = types.ImagesPruneReport{}
-	}
+	spaceReclaimed := report.SpaceReclaimed
 
-	spaceReclaimed := contReport.SpaceReclaimed + volReport.SpaceReclaimed + imgReport.SpaceReclaimed
+	a.metrics.pruneReclaimed.Add(float64(spaceReclaimed))
+	a.metrics.pruneDeleted.WithLabelValues("containers").Add(float64(len(report.Containers)))
+	a.metrics.pruneDeleted.WithLabelValues("networks").Add(float64(len(report.Networks)))
+	a.metrics.pruneDeleted.WithLabelValues("volumes").Add(float64(len(report.Volumes)))
+	a.metrics.pruneDeleted.WithLabelValues("images").Add(float64(len(report.Images)))
 
-	if spaceReclaimed == 0 && len(netReport.NetworksDeleted) == 0 {
+	if spaceReclaimed == 0 && len(report.Networks) == 0 {
 		return
 	}
 
 	logger.Info("pruned docker system",
 		zap.String("space_reclaimed", units.HumanSize(float64(spaceReclaimed))),
-		zap.Int("containers", len(contReport.ContainersDeleted)),
-		zap.Int("networks", len(netReport.NetworksDeleted)),
-		zap.Int("volumes", len(volReport.VolumesDeleted)),
-		zap.Int("images", len(imgReport.ImagesDeleted)),
+		zap.Int("containers", len(report.Containers)),
+		zap.Int("networks", len(report.Networks)),
+		zap.Int("volumes", len(report.Volumes)),
+		zap.Int("images", len(report.Images)),
 	)
 }
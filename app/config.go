@@ -1,8 +1,16 @@
 package app
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
+
+	units "github.com/docker/go-units"
+	"github.com/jakebailey/ua/pkg/simplecrypto"
 )
 
 // Config is a set of human-readable configuration for the app.
@@ -17,7 +25,9 @@ type Config struct {
 	// KeyFile is a path to a key file for HTTPS.
 	KeyFile string
 
-	// Database is the PostgreSQL database connection string.
+	// Database is the database connection string. Strings with a
+	// "sqlite://" scheme use a SQLite database file (see storage.Open);
+	// all others are PostgreSQL connection strings.
 	Database string
 	// MigrateUp enables upward database migration at startup.
 	MigrateUp bool
@@ -31,8 +41,22 @@ type Config struct {
 	// by the app.
 	StaticPath string
 
-	// AESKey is a base64-encoded string containing the AES key.
+	// AESKey is a base64-encoded string containing the AES key. It is the
+	// primary key, used for messages which don't specify a key ID.
 	AESKey string
+	// AESKeys are additional AES keys, each of the form "id:key" where key
+	// is base64 encoded. Messages which specify a key ID are decrypted with
+	// the matching key, so older keys can be kept after rotation.
+	AESKeys []string
+	// AESActiveKey is the ID of the key in AESKeys used to encrypt messages.
+	// If empty, AESKey is used.
+	AESActiveKey string
+	// DisableLegacyCrypto rejects messages in the legacy (AES-CFB with
+	// HMAC) format, accepting only AES-GCM messages.
+	DisableLegacyCrypto bool
+	// ObserverKey is a base64-encoded string containing the key used to
+	// sign observer tokens. If empty, observer connections are disabled.
+	ObserverKey string
 
 	// CleanInactiveEvery is the period at which the app will clean up
 	// inactive images and containers.
@@ -49,8 +73,66 @@ type Config struct {
 	// ForceInactive enables forced instance inactive marking at startup/shutdown.
 	ForceInactive bool
 
+	// NodeName identifies this node when multiple nodes share a database.
+	// Each instance is owned by the node which created it. If empty, the
+	// hostname is used. Names must be unique and stay the same across
+	// restarts.
+	NodeName string
+	// NodeURL is the base URL other nodes use to reach this node, like
+	// "http://10.0.0.2:8000". Terminal connections to this node's instances
+	// which arrive at other nodes are proxied to it. If empty, they fail.
+	NodeURL string
+
+	// RecordPath is the directory where terminal session recordings are
+	// stored. If empty, sessions are not recorded.
+	RecordPath string
+	// RecordRetention is the duration session recordings are kept before
+	// they are removed.
+	RecordRetention time.Duration
+
+	// SnapshotInstances enables snapshotting expired instances (and those
+	// still active at shutdown) when they are cleaned, so that the spec's
+	// next instance starts with its work.
+	SnapshotInstances bool
+	// SnapshotRetention is the duration snapshots are kept before they are
+	// removed.
+	SnapshotRetention time.Duration
+
 	// DisableLimits disables Docker container limits.
 	DisableLimits bool
+	// MaxMemory is the maximum memory limit an assignment may request, in
+	// a human readable form like "1g". If empty, there is no maximum.
+	MaxMemory string
+	// MaxCPUs is the maximum number of CPUs an assignment may request. If
+	// nonzero, this also limits assignments which don't request a limit.
+	MaxCPUs float64
+	// MaxPids is the maximum process limit an assignment may request. If
+	// nonzero, this also limits assignments which don't request a limit.
+	MaxPids int64
+	// MaxDiskSize is the maximum container disk size an assignment may
+	// request, in a human readable form like "2g". If empty, there is no
+	// maximum.
+	MaxDiskSize string
+	// MaxTmpfsSize is the maximum size of each tmpfs mount an assignment may
+	// request, in a human readable form like "1g". Mounts without a size are
+	// given this size. If empty, there is no maximum.
+	MaxTmpfsSize string
+	// MaxUlimits lists the maximum values of ulimits assignments may request,
+	// as name:max, like "nofile:4096". Ulimits not listed have no maximum.
+	MaxUlimits []string
+
+	// AllowedNetworks lists the Docker networks which assignments may ask to
+	// stay attached to. The special name "isolated" allows assignments to use
+	// a per-instance isolated network.
+	AllowedNetworks []string
+
+	// MaxUploadSize is the maximum size of a file upload to an instance, in
+	// a human readable form like "32m". If empty, there is no maximum.
+	MaxUploadSize string
+	// MaxDownloadSize is the maximum size of a file download from an
+	// instance, in a human readable form like "128m". If empty, there is no
+	// maximum.
+	MaxDownloadSize string
 
 	// DisableAutoPull disables automatic image pulling (for updates).
 	DisableAutoPull bool
@@ -63,6 +145,16 @@ type Config struct {
 	// PruneEvery is the interval at which the server will prune docker.
 	PruneEvery time.Duration
 
+	// PoolSize is the number of ready containers kept for each recently used
+	// prebuilt image. If zero, containers are not pooled.
+	PoolSize int
+	// PoolEvery is the interval at which the pool is refilled (in addition
+	// to whenever a pooled container is claimed).
+	PoolEvery time.Duration
+	// PoolExpiry defines how recently an image must have been used for its
+	// containers to be pooled.
+	PoolExpiry time.Duration
+
 	// Debug enables debug routes.
 	Debug bool
 
@@ -81,10 +173,24 @@ var DefaultConfig = Config{
 	WebsocketTimeout:   time.Hour,
 	InstanceExpire:     4 * time.Hour,
 
+	RecordRetention: 30 * 24 * time.Hour,
+
+	SnapshotRetention: 14 * 24 * time.Hour,
+
+	MaxMemory:    "1g",
+	MaxDiskSize:  "2g",
+	MaxTmpfsSize: "1g",
+
+	MaxUploadSize:   "32m",
+	MaxDownloadSize: "128m",
+
 	AutoPullEvery:  time.Hour,
 	AutoPullExpiry: 30 * time.Minute,
 
 	PruneEvery: time.Hour,
+
+	PoolEvery:  time.Minute,
+	PoolExpiry: time.Hour,
 }
 
 // Verify verifies that the configuration is valid and usable.
@@ -106,5 +212,121 @@ func (c Config) Verify() error {
 		return errors.New("both CertFile and KeyFile must be specified together")
 	}
 
+	if _, err := c.keyring(); err != nil {
+		return err
+	}
+
+	if c.NodeURL != "" {
+		u, err := url.Parse(c.NodeURL)
+		if err != nil {
+			return fmt.Errorf("invalid NodeURL: %v", err)
+		}
+
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid NodeURL %q: must be an http or https URL", c.NodeURL)
+		}
+	}
+
+	if c.MaxMemory != "" {
+		if _, err := units.RAMInBytes(c.MaxMemory); err != nil {
+			return fmt.Errorf("invalid MaxMemory: %v", err)
+		}
+	}
+
+	if c.MaxDiskSize != "" {
+		if _, err := units.RAMInBytes(c.MaxDiskSize); err != nil {
+			return fmt.Errorf("invalid MaxDiskSize: %v", err)
+		}
+	}
+
+	if c.MaxTmpfsSize != "" {
+		if _, err := units.RAMInBytes(c.MaxTmpfsSize); err != nil {
+			return fmt.Errorf("invalid MaxTmpfsSize: %v", err)
+		}
+	}
+
+	if c.MaxCPUs < 0 {
+		return errors.New("MaxCPUs must not be negative")
+	}
+
+	if c.MaxPids < 0 {
+		return errors.New("MaxPids must not be negative")
+	}
+
+	if _, err := c.maxUlimits(); err != nil {
+		return err
+	}
+
+	if c.MaxUploadSize != "" {
+		if _, err := units.RAMInBytes(c.MaxUploadSize); err != nil {
+			return fmt.Errorf("invalid MaxUploadSize: %v", err)
+		}
+	}
+
+	if c.MaxDownloadSize != "" {
+		if _, err := units.RAMInBytes(c.MaxDownloadSize); err != nil {
+			return fmt.Errorf("invalid MaxDownloadSize: %v", err)
+		}
+	}
+
 	return nil
 }
+
+// maxUlimits parses MaxUlimits into a map from ulimit name to maximum.
+func (c Config) maxUlimits() (map[string]int64, error) {
+	maxUlimits := make(map[string]int64, len(c.MaxUlimits))
+
+	for _, s := range c.MaxUlimits {
+		i := strings.LastIndexByte(s, ':')
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid MaxUlimits entry %q: must be name:max", s)
+		}
+
+		max, err := strconv.ParseInt(s[i+1:], 10, 64)
+		if err != nil || max < 0 {
+			return nil, fmt.Errorf("invalid MaxUlimits entry %q: max must be a non-negative integer", s)
+		}
+
+		maxUlimits[s[:i]] = max
+	}
+
+	return maxUlimits, nil
+}
+
+// keyring creates the keyring described by the AES key options.
+func (c Config) keyring() (*simplecrypto.Keyring, error) {
+	if c.AESKey == "" {
+		return nil, errors.New("AES key cannot be empty")
+	}
+
+	primary, err := base64.StdEncoding.DecodeString(c.AESKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AESKey: %v", err)
+	}
+
+	keyring, err := simplecrypto.NewKeyring(primary)
+	if err != nil {
+		return nil, fmt.Errorf("invalid AESKey: %v", err)
+	}
+
+	for _, s := range c.AESKeys {
+		id, key, err := simplecrypto.ParseKey(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AESKeys: %v", err)
+		}
+
+		if err := keyring.Add(id, key); err != nil {
+			return nil, fmt.Errorf("invalid AESKeys: %v", err)
+		}
+	}
+
+	if err := keyring.SetActive(c.AESActiveKey); err != nil {
+		return nil, fmt.Errorf("invalid AESActiveKey %q: %v", c.AESActiveKey, err)
+	}
+
+	if c.DisableLegacyCrypto {
+		keyring.DisableLegacy()
+	}
+
+	return keyring, nil
+}
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"crypto/subtle"
 	"io/ioutil"
 	"net/http"
 	"strings"
@@ -8,7 +9,6 @@ import (
 	"github.com/e-dard/netbug"
 	"github.com/go-chi/chi"
 	"github.com/jakebailey/ua/pkg/ctxlog"
-	"github.com/jakebailey/ua/pkg/simplecrypto"
 	"go.uber.org/zap"
 )
 
@@ -59,14 +59,20 @@ func (a *App) routeDebugProd(r chi.Router) {
 		r.Handle("/pprof/*", http.HandlerFunc(fixer))
 	}
 
-	r.Get("/trigger/checks", func(w http.ResponseWriter, r *http.Request) {
-		logger := ctxlog.FromRequest(r)
+	if len(a.observerKey) != 0 {
+		r.With(a.tokenAuthMiddleware).Get("/observer_token/{instanceID}", a.debugObserverToken)
+	}
 
-		// TODO: Change pprofToken into a generic debug password.
-		if !a.config.Debug && r.FormValue("token") != a.config.PProfToken {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
-		}
+	r.Group(func(r chi.Router) {
+		r.Use(a.tokenAuthMiddleware, a.precheckDatabaseMiddleware)
+		r.Get("/buildlogs", a.debugBuildLogs)
+		r.Get("/buildlogs/{instanceID}", a.debugBuildLog)
+	})
+
+	r.With(a.tokenAuthMiddleware).Get("/events", a.debugEvents)
+
+	r.With(a.tokenAuthMiddleware).Get("/trigger/checks", func(w http.ResponseWriter, r *http.Request) {
+		logger := ctxlog.FromRequest(r)
 
 		a.dockerCheckRunner.Run()
 		a.databaseCheckRunner.Run()
@@ -79,6 +85,49 @@ func (a *App) routeDebugProd(r chi.Router) {
 	})
 }
 
+// tokenCookie is the cookie the admin dashboard stores the pprof token in,
+// so that it doesn't need to be passed along in links and forms.
+const tokenCookie = "ua_token"
+
+// tokenAuthMiddleware only allows requests which provide the pprof token,
+// either as the "token" form value, as a bearer token, or in the token
+// cookie. If the app is in debug mode, all requests are allowed.
+//
+// TODO: Change pprofToken into a generic debug password.
+func (a *App) tokenAuthMiddleware(next http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, r *http.Request) {
+		if a.checkToken(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+	}
+	return http.HandlerFunc(fn)
+}
+
+func (a *App) checkToken(r *http.Request) bool {
+	if a.config.Debug {
+		return true
+	}
+
+	if a.config.PProfToken == "" {
+		return false
+	}
+
+	token := r.FormValue("token")
+	if token == "" {
+		token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if token == "" {
+		if c, err := r.Cookie(tokenCookie); err == nil {
+			token = c.Value
+		}
+	}
+
+	return subtle.ConstantTimeCompare([]byte(token), []byte(a.config.PProfToken)) == 1
+}
+
 func (a *App) triggerCleanInactive(w http.ResponseWriter, r *http.Request) {
 	logger := ctxlog.FromRequest(r)
 
@@ -110,7 +159,7 @@ func (a *App) debugEncrypt(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := simplecrypto.EncodeJSONWriter(a.aesKey, payload, w); err != nil {
+	if err := a.keyring.EncodeJSONWriter(payload, w); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 	}
 
@@ -119,7 +168,7 @@ func (a *App) debugEncrypt(w http.ResponseWriter, r *http.Request) {
 func (a *App) debugDecrypt(w http.ResponseWriter, r *http.Request) {
 	logger := ctxlog.FromRequest(r)
 
-	payload, err := simplecrypto.DecodeJSONReader(a.aesKey, r.Body)
+	payload, err := a.keyring.DecodeJSONReader(r.Body)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
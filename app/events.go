@@ -0,0 +1,155 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"go.uber.org/zap"
+)
+
+// Event types published on the app's event bus.
+const (
+	eventInstanceCreated   = "instance.created"
+	eventInstanceStarted   = "instance.started"
+	eventInstanceStopped   = "instance.stopped"
+	eventInstanceExpired   = "instance.expired"
+	eventInstanceCleaned   = "instance.cleaned"
+	eventWebsocketAttached = "websocket.attached"
+	eventWebsocketDetached = "websocket.detached"
+	eventBuildFailed       = "build.failed"
+)
+
+const (
+	// eventsBuffer is the number of events kept for each subscriber which
+	// hasn't received them yet; further events are dropped.
+	eventsBuffer = 64
+	// eventsKeepAlive is the interval at which comments are sent to idle
+	// event streams, to keep proxies from closing them.
+	eventsKeepAlive = 30 * time.Second
+)
+
+// event is an instance lifecycle event, sent to subscribers of /debug/events.
+type event struct {
+	Type           string    `json:"type"`
+	Time           time.Time `json:"time"`
+	InstanceID     string    `json:"instanceID,omitempty"`
+	SpecID         string    `json:"specID,omitempty"`
+	AssignmentName string    `json:"assignmentName,omitempty"`
+	Session        string    `json:"session,omitempty"`
+	Observer       bool      `json:"observer,omitempty"`
+	Error          string    `json:"error,omitempty"`
+}
+
+func (a *App) publish(e event) {
+	e.Time = time.Now()
+	a.events.Publish(e)
+}
+
+// publishInstance publishes an event about an instance. If the instance's
+// spec was loaded, the event also includes the spec.
+func (a *App) publishInstance(typ string, instance *models.Instance) {
+	e := event{
+		Type:       typ,
+		InstanceID: instance.ID.String(),
+	}
+
+	if instance.Spec != nil {
+		e.SpecID = instance.Spec.ID.String()
+		e.AssignmentName = instance.Spec.AssignmentName
+	}
+
+	a.publish(e)
+}
+
+func (a *App) publishWebsocket(typ string, instance *models.Instance, sessionName string, observer bool) {
+	e := event{
+		Type:       typ,
+		InstanceID: instance.ID.String(),
+		Session:    sessionName,
+		Observer:   observer,
+	}
+
+	if instance.Spec != nil {
+		e.SpecID = instance.Spec.ID.String()
+		e.AssignmentName = instance.Spec.AssignmentName
+	}
+
+	a.publish(e)
+}
+
+func (a *App) publishBuildFailed(specID models.ID, assignmentName string, instance *models.Instance, err error) {
+	a.publish(event{
+		Type:           eventBuildFailed,
+		InstanceID:     instance.ID.String(),
+		SpecID:         specID.String(),
+		AssignmentName: assignmentName,
+		Error:          err.Error(),
+	})
+}
+
+// debugEvents streams events as server-sent events, with the event's type as
+// the SSE event name and its JSON encoding as the data. Events can be limited
+// to one instance with the instanceID query parameter.
+func (a *App) debugEvents(w http.ResponseWriter, r *http.Request) {
+	logger := ctxlog.FromRequest(r)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		a.httpError(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	instanceID := r.FormValue("instanceID")
+
+	sub := a.events.Subscribe()
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+
+		case v, ok := <-sub.C():
+			if !ok {
+				return
+			}
+
+			e := v.(event)
+			if instanceID != "" && e.InstanceID != instanceID {
+				continue
+			}
+
+			data, err := json.Marshal(e)
+			if err != nil {
+				logger.Error("error encoding event",
+					zap.Error(err),
+				)
+				continue
+			}
+
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,586 @@
+package app
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	units "github.com/docker/go-units"
+	"github.com/go-chi/chi"
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/runtime"
+	"go.uber.org/zap"
+)
+
+// specHeader is the header which holds the encrypted spec request for file
+// transfers, as the request body is used for the file itself.
+const specHeader = "X-UA-Spec"
+
+const tarContentType = "application/x-tar"
+
+var errFileTooLarge = errors.New("file too large")
+
+// maxReader reads from r, returning errFileTooLarge once more than max bytes
+// have been read. If max is zero, there is no limit.
+type maxReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (m *maxReader) Read(p []byte) (int, error) {
+	n, err := m.r.Read(p)
+	m.read += int64(n)
+	if m.max > 0 && m.read > m.max {
+		return n, errFileTooLarge
+	}
+	return n, err
+}
+
+// filesInstance authenticates a file transfer request using the spec request
+// in specHeader, and returns the active instance it refers to. The instance
+// must belong to the spec.
+func (a *App) filesInstance(w http.ResponseWriter, r *http.Request) (*models.Instance, bool) {
+	ctx := r.Context()
+	logger := ctxlog.FromContext(ctx)
+
+	header := r.Header.Get(specHeader)
+	if header == "" {
+		http.Error(w, "missing "+specHeader+" header", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	// Only decode the spec request; the instance lookup below fails for
+	// specs which don't exist, so unknown specs are never inserted here.
+	specID, _ := a.specDecodeReader(w, r, strings.NewReader(header))
+	if specID.IsEmpty() {
+		return nil, false
+	}
+
+	instanceID, err := models.ParseID(chi.URLParam(r, "instanceID"))
+	if err != nil {
+		a.httpError(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	instance, err := a.repo.FindActiveInstance(instanceID)
+	if err != nil {
+		if err == models.ErrNotFound {
+			http.NotFound(w, r)
+			return nil, false
+		}
+
+		logger.Error("error querying for instance",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	// Don't reveal that the instance exists to other specs.
+	if instance.Spec.ID != specID {
+		logger.Warn("file transfer for instance of another spec",
+			zap.String("spec_id", specID.String()),
+			zap.String("instance_id", instance.ID.String()),
+		)
+		http.NotFound(w, r)
+		return nil, false
+	}
+
+	if a.forwardInstance(w, r, instance) {
+		return nil, false
+	}
+
+	return instance, true
+}
+
+// filesPath returns the path query parameter, which must be absolute.
+func (a *App) filesPath(w http.ResponseWriter, r *http.Request) (string, bool) {
+	p := r.URL.Query().Get("path")
+	if !path.IsAbs(p) {
+		http.Error(w, "path must be absolute", http.StatusBadRequest)
+		return "", false
+	}
+	return path.Clean(p), true
+}
+
+// instanceFilesUpload copies the request body into an instance's container.
+// If the body is a tar archive (by its content type), then it is extracted
+// into the directory given by path. Otherwise, the body is written as a
+// single file to path. Uploaded files are owned by the instance's user, and
+// must be inside its home or working directory.
+func (a *App) instanceFilesUpload(w http.ResponseWriter, r *http.Request) {
+	instance, ok := a.filesInstance(w, r)
+	if !ok {
+		return
+	}
+
+	p, ok := a.filesPath(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, logger := ctxlog.FromContextWith(r.Context(),
+		zap.String("instance_id", instance.ID.String()),
+		zap.String("container_id", instance.ContainerID),
+		zap.String("path", p),
+	)
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	isTar := contentType == tarContentType
+
+	owner, err := a.lookupInstanceUser(ctx, instance)
+	if err != nil {
+		if runtime.IsNotFound(err) {
+			http.NotFound(w, r)
+			return
+		}
+
+		logger.Error("error looking up instance user",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !owner.canUpload(p, isTar) {
+		http.Error(w, "path must be inside the home or working directory", http.StatusForbidden)
+		return
+	}
+
+	maxSize, _ := units.RAMInBytes(a.config.MaxUploadSize)
+
+	if maxSize > 0 && r.ContentLength > maxSize {
+		http.Error(w, errFileTooLarge.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	body := &maxReader{r: r.Body, max: maxSize}
+
+	var (
+		content io.Reader
+		dst     string
+		tarPipe *io.PipeReader
+		tarDone chan error
+	)
+
+	if isTar {
+		// The archive is rewritten as it's read, so that its files can't be
+		// owned by another user or be setuid or setgid.
+		var pw *io.PipeWriter
+		tarPipe, pw = io.Pipe()
+		tarDone = make(chan error, 1)
+
+		go func() {
+			err := ownTar(pw, body, owner.uid, owner.gid)
+			tarDone <- err
+			pw.CloseWithError(err)
+		}()
+
+		content = tarPipe
+		dst = p
+	} else {
+		content, err = singleFileTar(body, path.Base(p), owner.uid, owner.gid)
+		if err != nil {
+			if err == errFileTooLarge {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			logger.Warn("error reading uploaded file",
+				zap.Error(err),
+			)
+			a.httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		dst = path.Dir(p)
+	}
+
+	err = a.rt.CopyTo(ctx, instance.ContainerID, dst, content)
+
+	if tarPipe != nil {
+		// Unblock the rewrite if the copy stopped reading early, then prefer
+		// its error, which says what was wrong with the archive.
+		tarPipe.Close()
+		if terr := <-tarDone; terr != nil && terr != io.ErrClosedPipe && err != nil {
+			err = terr
+		}
+	}
+
+	if err != nil {
+		if err == errFileTooLarge || body.max > 0 && body.read > body.max {
+			http.Error(w, errFileTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if runtime.IsNotFound(err) {
+			http.Error(w, "path not found", http.StatusNotFound)
+			return
+		}
+
+		logger.Warn("error copying to container",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger.Info("uploaded files to instance",
+		zap.Int64("size", body.read),
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// instanceFilesDownload sends a path from an instance's container. Regular
+// files are sent as-is, unless the archive query parameter is set; anything
+// else is sent as a tar archive. Archives larger than the maximum download
+// size are truncated, as their size isn't known until they are sent.
+func (a *App) instanceFilesDownload(w http.ResponseWriter, r *http.Request) {
+	instance, ok := a.filesInstance(w, r)
+	if !ok {
+		return
+	}
+
+	p, ok := a.filesPath(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, logger := ctxlog.FromContextWith(r.Context(),
+		zap.String("instance_id", instance.ID.String()),
+		zap.String("container_id", instance.ContainerID),
+		zap.String("path", p),
+	)
+
+	rc, stat, err := a.rt.CopyFrom(ctx, instance.ContainerID, p)
+	if err != nil {
+		if runtime.IsNotFound(err) {
+			http.Error(w, "path not found", http.StatusNotFound)
+			return
+		}
+
+		logger.Warn("error copying from container",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer rc.Close()
+
+	maxSize, _ := units.RAMInBytes(a.config.MaxDownloadSize)
+
+	var content io.Reader = rc
+
+	if stat.Mode.IsRegular() && r.URL.Query().Get("archive") == "" {
+		if maxSize > 0 && stat.Size > maxSize {
+			http.Error(w, errFileTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		tr := tar.NewReader(rc)
+		if _, err := tr.Next(); err != nil {
+			logger.Error("error reading archive from container",
+				zap.Error(err),
+			)
+			a.httpError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		content = tr
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.FormatInt(stat.Size, 10))
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": stat.Name}))
+	} else {
+		w.Header().Set("Content-Type", tarContentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": stat.Name + ".tar"}))
+	}
+
+	n, err := io.Copy(w, &maxReader{r: content, max: maxSize})
+	if err != nil {
+		logger.Warn("error sending files",
+			zap.Error(err),
+			zap.Int64("size", n),
+		)
+		return
+	}
+
+	logger.Info("downloaded files from instance",
+		zap.Int64("size", n),
+	)
+}
+
+// singleFileTar reads a file from r and returns a tar archive containing
+// only that file.
+func singleFileTar(r io.Reader, name string, uid, gid int) (io.Reader, error) {
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(contents)),
+		ModTime: time.Now(),
+		Uid:     uid,
+		Gid:     gid,
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return nil, err
+	}
+
+	if _, err := tw.Write(contents); err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	return &buf, nil
+}
+
+// ownTar copies the tar archive read from r to w, making each entry owned by
+// uid and gid and clearing any setuid and setgid bits and extended
+// attributes. Only regular files, directories, and links are allowed, and no
+// entry or link may point outside of the directory it's extracted into.
+func ownTar(w io.Writer, r io.Reader, uid, gid int) error {
+	tr := tar.NewReader(r)
+	tw := tar.NewWriter(w)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeRegA, tar.TypeDir:
+		case tar.TypeSymlink:
+			if path.IsAbs(hdr.Linkname) || escapesDir(path.Join(path.Dir(hdr.Name), hdr.Linkname)) {
+				return fmt.Errorf("%s: link points outside of the archive", hdr.Name)
+			}
+		case tar.TypeLink:
+			if escapesDir(hdr.Linkname) {
+				return fmt.Errorf("%s: link points outside of the archive", hdr.Name)
+			}
+		default:
+			return fmt.Errorf("%s: unsupported file type", hdr.Name)
+		}
+
+		if escapesDir(hdr.Name) {
+			return fmt.Errorf("%s: path is outside of the archive", hdr.Name)
+		}
+
+		hdr.Uid = uid
+		hdr.Gid = gid
+		hdr.Uname = ""
+		hdr.Gname = ""
+		hdr.Mode &^= 06000
+		hdr.Xattrs = nil
+		hdr.PAXRecords = nil
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
+
+// escapesDir returns true if the relative path p refers to something outside
+// of the directory it's relative to.
+func escapesDir(p string) bool {
+	p = path.Clean(p)
+	return path.IsAbs(p) || p == ".." || strings.HasPrefix(p, "../")
+}
+
+// instanceUser is the user an instance's command runs as, which owns the
+// files uploaded to the instance.
+type instanceUser struct {
+	uid, gid int
+	home     string
+	workDir  string
+}
+
+// canUpload returns true if files may be uploaded to p, which must be inside
+// the user's home or working directory. Archives may also be extracted into
+// these directories themselves.
+func (u *instanceUser) canUpload(p string, isTar bool) bool {
+	for _, dir := range []string{u.home, u.workDir} {
+		if dir == "" || dir == "/" {
+			continue
+		}
+
+		dir = path.Clean(dir)
+		if strings.HasPrefix(p, dir+"/") || isTar && p == dir {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lookupInstanceUser looks up the instance's user in its container's passwd
+// and group files.
+func (a *App) lookupInstanceUser(ctx context.Context, instance *models.Instance) (*instanceUser, error) {
+	passwd, err := a.readContainerFile(ctx, instance.ContainerID, "/etc/passwd")
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := a.readContainerFile(ctx, instance.ContainerID, "/etc/group")
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := lookupUser(instance.Command.User, passwd, group)
+	if err != nil {
+		return nil, err
+	}
+
+	u.workDir = instance.Command.WorkingDir
+	return u, nil
+}
+
+// maxAccountFileSize limits the size of the passwd and group files read from
+// a container.
+const maxAccountFileSize = 1 << 20
+
+// readContainerFile reads a regular file from a container. If the file
+// doesn't exist, nil is returned.
+func (a *App) readContainerFile(ctx context.Context, containerID string, p string) ([]byte, error) {
+	rc, stat, err := a.rt.CopyFrom(ctx, containerID, p)
+	if err != nil {
+		if runtime.IsNotFound(err) {
+			// Distinguish a missing container from a missing file.
+			if _, ierr := a.rt.Inspect(ctx, containerID); ierr != nil {
+				return nil, ierr
+			}
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rc.Close()
+
+	if !stat.Mode.IsRegular() {
+		return nil, nil
+	}
+
+	tr := tar.NewReader(rc)
+	if _, err := tr.Next(); err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadAll(io.LimitReader(tr, maxAccountFileSize))
+}
+
+// lookupUser resolves a user in any of the forms Docker accepts ("user",
+// "uid", "user:group", "uid:gid") using the given passwd and group files. An
+// empty user is root.
+func lookupUser(user string, passwd, group []byte) (*instanceUser, error) {
+	name, groupName := user, ""
+	if i := strings.IndexByte(user, ':'); i >= 0 {
+		name, groupName = user[:i], user[i+1:]
+	}
+	if name == "" {
+		name = "0"
+	}
+
+	uid, uidErr := strconv.Atoi(name)
+	u := &instanceUser{uid: uid}
+
+	found := false
+	for _, fields := range accountEntries(passwd, 7) {
+		if fields[0] != name && (uidErr != nil || fields[2] != name) {
+			continue
+		}
+
+		id, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid uid for user %s", fields[0])
+		}
+		gid, err := strconv.Atoi(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid gid for user %s", fields[0])
+		}
+
+		u.uid, u.gid, u.home = id, gid, fields[5]
+		found = true
+		break
+	}
+
+	if !found && uidErr != nil {
+		return nil, fmt.Errorf("unknown user %s", name)
+	}
+
+	if groupName == "" {
+		return u, nil
+	}
+
+	if gid, err := strconv.Atoi(groupName); err == nil {
+		u.gid = gid
+		return u, nil
+	}
+
+	for _, fields := range accountEntries(group, 4) {
+		if fields[0] != groupName {
+			continue
+		}
+
+		gid, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, fmt.Errorf("invalid gid for group %s", groupName)
+		}
+
+		u.gid = gid
+		return u, nil
+	}
+
+	return nil, fmt.Errorf("unknown group %s", groupName)
+}
+
+// accountEntries splits a passwd or group file into its entries, skipping
+// comments and entries which don't have n fields.
+func accountEntries(b []byte, n int) [][]string {
+	var entries [][]string
+
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		fields := strings.Split(line, ":")
+		if len(fields) != n {
+			continue
+		}
+
+		entries = append(entries, fields)
+	}
+
+	return entries
+}
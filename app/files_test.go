@@ -0,0 +1,206 @@
+package app
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/simplecrypto"
+)
+
+func testTar(t *testing.T, hdrs ...*tar.Header) *bytes.Buffer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+
+	for _, hdr := range hdrs {
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Size > 0 {
+			if _, err := tw.Write(make([]byte, hdr.Size)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &buf
+}
+
+func TestOwnTar(t *testing.T) {
+	in := testTar(t,
+		&tar.Header{Name: "bin/", Typeflag: tar.TypeDir, Mode: 0755},
+		&tar.Header{Name: "bin/su", Typeflag: tar.TypeReg, Mode: 06755, Size: 4, Uname: "root", Gname: "root",
+			PAXRecords: map[string]string{"SCHILY.xattr.security.capability": "x"}},
+		&tar.Header{Name: "bin/sh", Typeflag: tar.TypeSymlink, Linkname: "su", Mode: 0777},
+	)
+
+	var out bytes.Buffer
+	if err := ownTar(&out, in, 1000, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	tr := tar.NewReader(&out)
+	n := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+
+		if hdr.Uid != 1000 || hdr.Gid != 100 || hdr.Uname != "" || hdr.Gname != "" {
+			t.Errorf("%s: expected owner 1000:100, got %d:%d (%q:%q)", hdr.Name, hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname)
+		}
+		if hdr.Mode&06000 != 0 {
+			t.Errorf("%s: expected setuid and setgid to be cleared, got mode %o", hdr.Name, hdr.Mode)
+		}
+		if len(hdr.PAXRecords) != 0 {
+			t.Errorf("%s: expected no extended attributes, got %v", hdr.Name, hdr.PAXRecords)
+		}
+	}
+
+	if n != 3 {
+		t.Errorf("expected 3 entries, got %d", n)
+	}
+}
+
+func TestOwnTarRejects(t *testing.T) {
+	tests := map[string]*tar.Header{
+		"parent path":       {Name: "../evil", Typeflag: tar.TypeReg},
+		"absolute symlink":  {Name: "passwd", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+		"escaping symlink":  {Name: "a/link", Typeflag: tar.TypeSymlink, Linkname: "../../etc"},
+		"escaping hardlink": {Name: "link", Typeflag: tar.TypeLink, Linkname: "../etc/shadow"},
+		"device":            {Name: "mem", Typeflag: tar.TypeChar, Devmajor: 1, Devminor: 1},
+	}
+
+	for name, hdr := range tests {
+		if err := ownTar(&bytes.Buffer{}, testTar(t, hdr), 1000, 1000); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLookupUser(t *testing.T) {
+	passwd := []byte("root:x:0:0:root:/root:/bin/sh\n# comment\nstudent:x:1000:100::/home/student:/bin/sh\n")
+	group := []byte("root:x:0:\nusers:x:100:\nwheel:x:10:root\n")
+
+	tests := []struct {
+		user     string
+		uid, gid int
+		home     string
+	}{
+		{"", 0, 0, "/root"},
+		{"root", 0, 0, "/root"},
+		{"student", 1000, 100, "/home/student"},
+		{"1000", 1000, 100, "/home/student"},
+		{"student:wheel", 1000, 10, "/home/student"},
+		{"student:5", 1000, 5, "/home/student"},
+		{"2000", 2000, 0, ""},
+		{"2000:2000", 2000, 2000, ""},
+	}
+
+	for _, test := range tests {
+		u, err := lookupUser(test.user, passwd, group)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.user, err)
+			continue
+		}
+
+		if u.uid != test.uid || u.gid != test.gid || u.home != test.home {
+			t.Errorf("%q: expected %d:%d %q, got %d:%d %q", test.user, test.uid, test.gid, test.home, u.uid, u.gid, u.home)
+		}
+	}
+
+	for _, user := range []string{"nobody", "student:nogroup"} {
+		if _, err := lookupUser(user, passwd, group); err == nil {
+			t.Errorf("%q: expected an error", user)
+		}
+	}
+}
+
+func TestInstanceUserCanUpload(t *testing.T) {
+	u := &instanceUser{home: "/home/student", workDir: "/project/"}
+
+	tests := []struct {
+		path  string
+		isTar bool
+		want  bool
+	}{
+		{"/home/student/main.go", false, true},
+		{"/home/student/src/main.go", false, true},
+		{"/project/main.go", false, true},
+		{"/home/student", true, true},
+		{"/project", true, true},
+		{"/home/student", false, false},
+		{"/home/studentx/main.go", false, false},
+		{"/etc/passwd", false, false},
+		{"/", true, false},
+	}
+
+	for _, test := range tests {
+		if got := u.canUpload(test.path, test.isTar); got != test.want {
+			t.Errorf("%s (tar=%v): expected %v, got %v", test.path, test.isTar, test.want, got)
+		}
+	}
+
+	root := &instanceUser{home: "/", workDir: ""}
+	if root.canUpload("/etc/passwd", false) {
+		t.Error("expected uploads to be refused without a home or working directory")
+	}
+}
+
+func TestFilesUnknownSpec(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	specID := newSpecID()
+
+	payload, err := json.Marshal(&specPostRequest{
+		SpecID:         specID,
+		AssignmentName: "echo",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, err := simplecrypto.EncodeJSON(ta.key, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest("GET", ta.srv.URL+"/instance/"+newSpecID()+"/files?path=/etc/passwd", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(specHeader, string(header))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", resp.StatusCode)
+	}
+
+	id, err := models.ParseID(specID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ta.app.repo.FindSpec(id); err != models.ErrNotFound {
+		t.Errorf("expected the spec not to be inserted, got %v", err)
+	}
+}
@@ -7,14 +7,12 @@ import (
 	"io"
 	"time"
 
-	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/archive"
-	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/jakebailey/ua/pkg/ctxlog"
-	"github.com/jakebailey/ua/pkg/docker/image"
+	"github.com/jakebailey/ua/pkg/runtime"
 	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
 )
 
 // DockerImageName is the image name for the static builder on Docker Hub.
@@ -37,14 +35,14 @@ type Options struct {
 
 // Build builds Go completely static binaries in a docker container, and
 // returns an io.Reader, which contains a gzipped tarball of the binaries.
-func Build(ctx context.Context, cli client.CommonAPIClient, options Options) (io.Reader, error) {
+func Build(ctx context.Context, rt runtime.Runtime, options Options) (io.Reader, error) {
 	logger := ctxlog.FromContext(ctx)
 
 	logger.Debug("gobuild",
 		zap.Any("options", options),
 	)
 
-	if err := image.PullIfNotFound(ctx, cli, DockerImageName); err != nil {
+	if err := rt.EnsureImage(ctx, DockerImageName); err != nil {
 		return nil, err
 	}
 
@@ -65,53 +63,43 @@ func Build(ctx context.Context, cli client.CommonAPIClient, options Options) (io
 		AutoRemove: true,
 	}
 
-	c, err := cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, "")
+	containerID, err := rt.Create(ctx, "", containerConfig, hostConfig)
 	if err != nil {
 		logger.Error("error creating gobuild container",
 			zap.Error(err),
 		)
 		return nil, err
 	}
-	containerID := c.ID
 
 	ctx, logger = ctxlog.FromContextWith(ctx,
 		zap.String("container_id", containerID),
 	)
 
-	attachOptions := types.ContainerAttachOptions{
-		Stream: true,
-		Stdin:  true,
-		Stdout: true,
-		Stderr: true,
-	}
-
-	hj, err := cli.ContainerAttach(ctx, containerID, attachOptions)
+	proc, err := rt.Attach(ctx, containerID)
 	if err != nil {
 		logger.Error("error attaching to gobuild container",
 			zap.Error(err),
 		)
 
-		tryContainerRemove(ctx, cli, containerID)
+		tryContainerRemove(ctx, rt, containerID)
 
 		return nil, err
 	}
-	defer hj.Close()
-
-	resultC, errC := cli.ContainerWait(ctx, containerID, container.WaitConditionRemoved)
+	defer proc.Close()
 
-	if err := cli.ContainerStart(ctx, containerID, types.ContainerStartOptions{}); err != nil {
+	if err := rt.Start(ctx, containerID); err != nil {
 		logger.Error("error starting gobuild container",
 			zap.Error(err),
 		)
 
-		tryContainerRemove(ctx, cli, containerID)
+		tryContainerRemove(ctx, rt, containerID)
 
 		return nil, err
 	}
 
 	go func() {
 		defer func() {
-			if err := hj.CloseWrite(); err != nil {
+			if err := proc.CloseStdin(); err != nil {
 				logger.Warn("error closing gobuild stdin",
 					zap.Error(err),
 				)
@@ -134,65 +122,61 @@ func Build(ctx context.Context, cli client.CommonAPIClient, options Options) (io
 			}
 		}()
 
-		if _, err := io.Copy(hj.Conn, source); err != nil {
+		if _, err := io.Copy(proc.Stdin(), source); err != nil {
 			logger.Warn("error copying gobuild stdin",
 				zap.Error(err),
 			)
 		}
-	}() // Exits when hj.Conn closes, or the source code has been sent.
+	}() // Exits when stdin closes, or the source code has been sent.
 
 	stderr := &bytes.Buffer{}
 	stdout := &bytes.Buffer{}
 
-	go func() {
-		// This is probably the most evil thing I've ever seen inside Docker.
-		// For some stupid reason, stdout and stderr are multiplexed, but only
-		// for this specific type of attach. Hours of debugging later, I read
-		// enough of the Docker CLI source code (hijack.go) and decided to try
-		// it after seeing such a weird function signature.
-		//
-		// Nothing could be further than a "standard copy" than this. !@#$%!
-		if _, err := stdcopy.StdCopy(stdout, stderr, hj.Reader); err != nil {
-			logger.Error("stdcopy error",
-				zap.Error(err),
-			)
-		}
-	}() // Exits when hj.Reader returns EOF.
-
-	select {
-	case result := <-resultC:
-		if result.Error != nil {
-			return nil, fmt.Errorf("%s", result.Error.Message)
-		}
-
-		if result.StatusCode != 0 {
-			return nil, fmt.Errorf("gobuild: status code %d\n%s", result.StatusCode, stderr.String())
-		}
+	var g errgroup.Group
+	g.Go(copyFunc(stdout, proc.Stdout())) // Exits when stdout closes.
+	g.Go(copyFunc(stderr, proc.Stderr())) // Exits when stderr closes.
 
-	case err := <-errC:
-		logger.Error("gobuild errC",
+	statusCode, err := proc.Wait(ctx)
+	if err != nil {
+		logger.Error("gobuild wait error",
 			zap.Error(err),
 		)
 		return nil, err
 	}
 
+	if err := g.Wait(); err != nil {
+		logger.Error("gobuild output error",
+			zap.Error(err),
+		)
+	}
+
+	if statusCode != 0 {
+		return nil, fmt.Errorf("gobuild: status code %d\n%s", statusCode, stderr.String())
+	}
+
 	return stdout, nil
 }
 
-func tryContainerRemove(ctx context.Context, cli client.CommonAPIClient, containerID string) {
+func copyFunc(w io.Writer, r io.Reader) func() error {
+	return func() error {
+		_, err := io.Copy(w, r)
+		return err
+	}
+}
+
+func tryContainerRemove(ctx context.Context, rt runtime.Runtime, containerID string) {
 	logger := ctxlog.FromContext(ctx)
 
-	cOpts := types.ContainerRemoveOptions{RemoveVolumes: true}
 	rctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
 
-	if err := cli.ContainerKill(ctx, containerID, "KILL"); err != nil {
+	if err := rt.Kill(ctx, containerID); err != nil {
 		logger.Warn("error killing gobuild container",
 			zap.Error(err),
 		)
 	}
 
-	if err := cli.ContainerRemove(rctx, containerID, cOpts); err != nil {
+	if err := rt.Remove(rctx, containerID, false); err != nil {
 		logger.Warn("error removing gobuild container",
 			zap.Error(err),
 		)
@@ -3,9 +3,7 @@ package app
 import (
 	"context"
 	"net/http"
-	"time"
 
-	"github.com/docker/docker/api/types"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/gobwas/ws"
@@ -16,7 +14,6 @@ import (
 	"github.com/jakebailey/ua/pkg/expire"
 	"github.com/jakebailey/ua/templates"
 	"go.uber.org/zap"
-	"gopkg.in/src-d/go-kallax.v1"
 )
 
 func (a *App) routeInstance(r chi.Router) {
@@ -25,21 +22,41 @@ func (a *App) routeInstance(r chi.Router) {
 	r.Route("/{instanceID}", func(r chi.Router) {
 		if a.config.Debug {
 			r.Get("/", func(w http.ResponseWriter, r *http.Request) {
-				wsURL := r.Host + r.RequestURI + "/ws"
+				wsURL := r.Host + r.URL.Path + "/ws"
+				if r.URL.RawQuery != "" {
+					wsURL += "?" + r.URL.RawQuery
+				}
 				templates.WriteContainer(w, wsURL)
 			})
 		}
 
 		r.Get("/ws", a.instanceWS)
+
+		r.Get("/files", a.instanceFilesDownload)
+		r.Put("/files", a.instanceFilesUpload)
+
+		if a.config.RecordPath != "" {
+			r.Group(func(r chi.Router) {
+				r.Use(a.tokenAuthMiddleware)
+				r.Get("/recordings", a.instanceRecordings)
+				r.Get("/recordings/{recording}", a.instanceRecording)
+			})
+		}
 	})
 }
 
+var wsUpgrader = ws.HTTPUpgrader{
+	Protocol: func(protocol string) bool {
+		return protocol == proxy.RawProtocol
+	},
+}
+
 func (a *App) instanceWS(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := ctxlog.FromContext(ctx)
 
 	instanceIDStr := chi.URLParam(r, "instanceID")
-	instanceID, err := kallax.NewULIDFromText(instanceIDStr)
+	instanceID, err := models.ParseID(instanceIDStr)
 	if err != nil {
 		logger.Warn("error parsing instanceID",
 			zap.Error(err),
@@ -52,10 +69,9 @@ func (a *App) instanceWS(w http.ResponseWriter, r *http.Request) {
 		zap.String("instance_id", instanceID.String()),
 	)
 
-	instanceQuery := models.NewInstanceQuery().FindByID(instanceID).FindByActive(true).WithSpec()
-	instance, err := a.instanceStore.FindOne(instanceQuery)
+	instance, err := a.repo.FindActiveInstance(instanceID)
 	if err != nil {
-		if err == kallax.ErrNotFound {
+		if err == models.ErrNotFound {
 			http.NotFound(w, r)
 			return
 		}
@@ -68,21 +84,58 @@ func (a *App) instanceWS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if a.forwardInstance(w, r, instance) {
+		return
+	}
+
 	ctx, logger = ctxlog.FromContextWith(ctx,
 		zap.String("spec_id", instance.Spec.ID.String()),
 		zap.String("assignment_name", instance.Spec.AssignmentName),
 	)
 
-	conn, _, _, err := ws.UpgradeHTTP(r, w)
+	sessionName := r.URL.Query().Get("session")
+	if sessionName == "" {
+		sessionName = defaultSessionName
+	} else if !sessionNameRegexp.MatchString(sessionName) {
+		logger.Warn("invalid session name",
+			zap.String("session", sessionName),
+		)
+		a.httpError(w, "invalid session name", http.StatusBadRequest)
+		return
+	}
+
+	logger = logger.With(
+		zap.String("session", sessionName),
+	)
+
+	observer := false
+
+	if token := r.URL.Query().Get("observer"); token != "" {
+		if !a.checkObserverToken(instance.ID.String(), token) {
+			logger.Warn("invalid observer token")
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		observer = true
+	}
+
+	conn, _, hs, err := wsUpgrader.Upgrade(r, w)
 	if err != nil {
-		// No need to write an error, UpgradeHTTP does this itself.
+		// No need to write an error, Upgrade does this itself.
 		logger.Error("error upgrading websocket",
 			zap.Error(err),
 		)
 		return
 	}
 
-	proxyConn := proxy.NewWSConn(conn)
+	var proxyConn *proxy.WSConn
+
+	if hs.Protocol == proxy.RawProtocol {
+		proxyConn = proxy.NewRawWSConn(conn)
+	} else {
+		proxyConn = proxy.NewWSConn(conn)
+	}
 
 	if err := proxyConn.WriteJSON([]string{"stdout", "Please wait..."}); err != nil {
 		logger.Warn("error writing please wait message",
@@ -94,10 +147,20 @@ func (a *App) instanceWS(w http.ResponseWriter, r *http.Request) {
 	ctx = ctxlog.WithLogger(ctx, logger)
 
 	a.wsWG.Add(1)
-	go a.handleInstance(ctx, proxyConn, instance)
+
+	if observer {
+		go a.handleObserver(ctx, proxyConn, instance, sessionName)
+		return
+	}
+
+	go a.handleInstance(ctx, proxyConn, instance, sessionName)
 }
 
-func (a *App) handleInstance(ctx context.Context, conn proxy.Conn, instance *models.Instance) {
+// handleInstance proxies a terminal session into an instance. An instance
+// may have multiple sessions, distinguished by name, each running its own
+// command. A new connection for a session which is already open replaces
+// the old connection.
+func (a *App) handleInstance(ctx context.Context, conn proxy.Conn, instance *models.Instance, sessionName string) {
 	defer a.wsWG.Done()
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -108,8 +171,18 @@ func (a *App) handleInstance(ctx context.Context, conn proxy.Conn, instance *mod
 		zap.String("container_id", instance.ContainerID),
 	)
 
+	// The instance is acquired before the session's token, as acquiring the
+	// token waits for any connection it replaces to exit. Were the instance
+	// acquired afterward, that connection would be its last user, and the
+	// container would be needlessly stopped and started again.
+	//
+	// Errors are logged by acquireInstance; proxying will fail on its own
+	// if the container isn't running.
+	_ = a.acquireInstance(ctx, instance)
+	defer a.releaseInstance(ctx, instance)
+
 	token := a.wsManager.Acquire(
-		instance.ID.String(),
+		sessionKey(instance.ID.String(), sessionName),
 		func() {
 			logger.Debug("websocket expired")
 			if err := errhack.IgnoreClose(conn.Close()); err != nil {
@@ -121,43 +194,53 @@ func (a *App) handleInstance(ctx context.Context, conn proxy.Conn, instance *mod
 	)
 	defer a.wsManager.Release(token)
 
-	instance.ExpiresAt = nil
-	if _, err := a.instanceStore.Update(instance, models.Schema.Instance.ExpiresAt); err != nil {
-		logger.Error("error disabling expiry for instance",
-			zap.Error(err),
-		)
-	}
+	session := proxy.NewTeeConn(conn)
+	a.setSession(instance.ID.String(), sessionName, session)
+	defer a.removeSession(instance.ID.String(), sessionName, session)
 
-	if err := a.cli.ContainerStart(ctx, instance.ContainerID, types.ContainerStartOptions{}); err != nil {
-		logger.Error("error starting container",
-			zap.Error(err),
-		)
-	}
+	a.publishWebsocket(eventWebsocketAttached, instance, sessionName, false)
+	defer a.publishWebsocket(eventWebsocketDetached, instance, sessionName, false)
+
+	websockets := a.metrics.websockets.WithLabelValues("terminal")
+	websockets.Inc()
+	defer websockets.Dec()
 
 	conn = tokenProxyConn{
-		Conn:  conn,
+		Conn:  session,
 		token: token,
 	}
 
 	proxyCmd := proxy.Command(instance.Command)
 
-	if err := proxy.Proxy(ctx, instance.ContainerID, conn, a.cli, proxyCmd); err != nil {
-		logger.Error("error proxying container",
-			zap.Error(err),
-		)
-	}
-
-	instance.ExpiresAt = a.instanceExpireTime()
-
-	if _, err := a.instanceStore.Update(instance, models.Schema.Instance.ExpiresAt); err != nil {
-		logger.Error("error adding ExpiresAt to instance",
-			zap.Error(err),
-		)
+	var rec proxy.Recorder
+
+	if a.config.RecordPath != "" {
+		w, f, err := a.startRecording(instance.ID.String())
+		if err != nil {
+			logger.Error("error starting session recording",
+				zap.Error(err),
+			)
+		} else {
+			rec = w
+
+			defer func() {
+				if err := w.Err(); err != nil {
+					logger.Warn("error writing session recording",
+						zap.Error(err),
+					)
+				}
+
+				if err := f.Close(); err != nil {
+					logger.Error("error closing session recording",
+						zap.Error(err),
+					)
+				}
+			}()
+		}
 	}
 
-	second := time.Second
-	if err := a.cli.ContainerStop(ctx, instance.ContainerID, &second); err != nil {
-		logger.Error("error stopping container",
+	if err := proxy.Proxy(ctx, instance.ContainerID, conn, a.rt, proxyCmd, rec); err != nil {
+		logger.Error("error proxying container",
 			zap.Error(err),
 		)
 	}
@@ -168,7 +251,7 @@ type tokenProxyConn struct {
 	token *expire.Token
 }
 
-var _ proxy.Conn = tokenProxyConn{}
+var _ proxy.RawConn = tokenProxyConn{}
 
 func (t tokenProxyConn) ReadJSON(v interface{}) error {
 	t.token.Update()
@@ -179,3 +262,17 @@ func (t tokenProxyConn) WriteJSON(v interface{}) error {
 	t.token.Update()
 	return t.Conn.WriteJSON(v)
 }
+
+func (t tokenProxyConn) Raw() bool {
+	return proxy.AsRaw(t.Conn) != nil
+}
+
+func (t tokenProxyConn) ReadRaw(v interface{}) (data []byte, isData bool, err error) {
+	t.token.Update()
+	return t.Conn.(proxy.RawConn).ReadRaw(v)
+}
+
+func (t tokenProxyConn) WriteRaw(p []byte) error {
+	t.token.Update()
+	return t.Conn.(proxy.RawConn).WriteRaw(p)
+}
@@ -0,0 +1,461 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/jakebailey/ua/models"
+)
+
+func (ta *testApp) instance(instanceID string) *models.Instance {
+	id, err := models.ParseID(instanceID)
+	if err != nil {
+		ta.t.Fatal(err)
+	}
+
+	instance, err := ta.app.repo.FindInstance(id)
+	if err != nil {
+		ta.t.Fatal(err)
+	}
+
+	return instance
+}
+
+func TestInstanceLifecycle(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	specID := newSpecID()
+	instanceID := ta.createInstance(specID, map[string]string{"secret": "hunter2"})
+
+	instance := ta.instance(instanceID)
+	if !instance.Active || instance.Cleaned {
+		t.Fatalf("expected new instance to be active and not cleaned, got active=%v cleaned=%v", instance.Active, instance.Cleaned)
+	}
+
+	ctr, ok := ta.docker.Container(instance.ContainerID)
+	if !ok {
+		t.Fatal("expected instance container to exist")
+	}
+
+	if ctr.Running {
+		t.Error("expected instance container to be stopped after setup")
+	}
+
+	if len(ctr.Networks) != 0 {
+		t.Errorf("expected instance container to have no networks, got %v", ctr.Networks)
+	}
+
+	if got := ta.file(instance.ContainerID, "/secret"); got != "hunter2" {
+		t.Errorf("expected post-build to write secret, got %q", got)
+	}
+
+	if again := ta.createInstance(specID, nil); again != instanceID {
+		t.Errorf("expected the active instance %s to be reused, got %s", instanceID, again)
+	}
+
+	c, code := ta.dial(instanceID)
+	if code != http.StatusSwitchingProtocols {
+		t.Fatalf("expected websocket upgrade, got %d", code)
+	}
+
+	waitFor(t, "container to start", func() bool {
+		ctr, _ := ta.docker.Container(instance.ContainerID)
+		return ctr.Running
+	})
+
+	c.send("stdin", "hello")
+	if out, err := c.readStdout("hello"); err != nil {
+		t.Fatalf("expected echoed output, got %q and error %v", out, err)
+	}
+
+	c.close()
+
+	waitFor(t, "container to stop", func() bool {
+		ctr, _ := ta.docker.Container(instance.ContainerID)
+		return !ctr.Running
+	})
+
+	if ta.instance(instanceID).ExpiresAt == nil {
+		t.Error("expected instance to expire after its last session closed")
+	}
+
+	ta.clean(specID)
+
+	instance = ta.instance(instanceID)
+	if instance.Active || !instance.Cleaned {
+		t.Errorf("expected cleaned instance to be inactive and cleaned, got active=%v cleaned=%v", instance.Active, instance.Cleaned)
+	}
+
+	if n := len(ta.docker.Containers()); n != 0 {
+		t.Errorf("expected no containers after cleaning, got %d", n)
+	}
+
+	if tags := ta.docker.Tags(); len(tags) != 1 || tags[0] != "alpine:latest" {
+		t.Errorf("expected only the base image to be left, got %v", tags)
+	}
+
+	if _, code := ta.dial(instanceID); code != http.StatusNotFound {
+		t.Errorf("expected 404 connecting to a cleaned instance, got %d", code)
+	}
+
+	if again := ta.createInstance(specID, map[string]string{"secret": "hunter2"}); again == instanceID {
+		t.Error("expected a new instance after cleaning")
+	}
+}
+
+func TestInstanceCleanWhileConnected(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	specID := newSpecID()
+	instanceID := ta.createInstance(specID, map[string]string{"secret": "hunter2"})
+
+	c, code := ta.dial(instanceID)
+	if code != http.StatusSwitchingProtocols {
+		t.Fatalf("expected websocket upgrade, got %d", code)
+	}
+	defer c.close()
+
+	c.send("stdin", "hello")
+	if out, err := c.readStdout("hello"); err != nil {
+		t.Fatalf("expected echoed output, got %q and error %v", out, err)
+	}
+
+	ta.clean(specID)
+
+	c.waitClosed()
+
+	instance := ta.instance(instanceID)
+	if instance.Active || !instance.Cleaned {
+		t.Errorf("expected cleaned instance to be inactive and cleaned, got active=%v cleaned=%v", instance.Active, instance.Cleaned)
+	}
+
+	if n := len(ta.docker.Containers()); n != 0 {
+		t.Errorf("expected no containers after cleaning, got %d", n)
+	}
+}
+
+func TestInstanceSessions(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	instanceID := ta.createInstance(newSpecID(), map[string]string{"secret": "hunter2"})
+	containerID := ta.instance(instanceID).ContainerID
+
+	c1, _ := ta.dial(instanceID)
+	c1.send("stdin", "one")
+	if out, err := c1.readStdout("one"); err != nil {
+		t.Fatalf("expected echoed output, got %q and error %v", out, err)
+	}
+
+	// A second connection to the same session replaces the first.
+	c2, _ := ta.dial(instanceID)
+	defer c2.close()
+
+	c2.send("stdin", "two")
+	if out, err := c2.readStdout("two"); err != nil {
+		t.Fatalf("expected echoed output, got %q and error %v", out, err)
+	}
+
+	c1.close()
+
+	// The second connection still holds the container.
+	c2.send("stdin", "three")
+	if out, err := c2.readStdout("three"); err != nil {
+		t.Fatalf("expected echoed output after first connection closed, got %q and error %v", out, err)
+	}
+
+	if ctr, _ := ta.docker.Container(containerID); !ctr.Running {
+		t.Error("expected container to be running")
+	}
+}
+
+func TestInstanceSessionReplaceKeepsContainer(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	instanceID := ta.createInstance(newSpecID(), map[string]string{"secret": "hunter2"})
+	containerID := ta.instance(instanceID).ContainerID
+
+	c1, _ := ta.dial(instanceID)
+	defer c1.close()
+
+	c1.send("stdin", "one")
+	if out, err := c1.readStdout("one"); err != nil {
+		t.Fatalf("expected echoed output, got %q and error %v", out, err)
+	}
+
+	// Replacing the only connection must not stop the container in between.
+	c2, _ := ta.dial(instanceID)
+	defer c2.close()
+
+	c1.waitClosed()
+
+	c2.send("stdin", "two")
+	if out, err := c2.readStdout("two"); err != nil {
+		t.Fatalf("expected echoed output, got %q and error %v", out, err)
+	}
+
+	// Once for setup, and once for the first connection.
+	if ctr, _ := ta.docker.Container(containerID); !ctr.Running || ctr.Starts != 2 {
+		t.Errorf("expected container to be running and started twice, got running=%v starts=%d", ctr.Running, ctr.Starts)
+	}
+}
+
+func TestInstanceBuildFailure(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	specID := newSpecID()
+
+	code, _ := ta.post("/spec", specID, map[string]interface{}{
+		"secret":    "hunter2",
+		"postBuild": []string{"false"},
+	})
+	if code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for failed build, got %d", code)
+	}
+
+	if n := len(ta.docker.Containers()); n != 0 {
+		t.Errorf("expected failed build to leave no containers, got %d", n)
+	}
+
+	if tags := ta.docker.Tags(); len(tags) != 1 || tags[0] != "alpine:latest" {
+		t.Errorf("expected failed build to leave only the base image, got %v", tags)
+	}
+
+	instances, err := ta.app.repo.ListInstances(models.InstanceFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(instances) != 0 {
+		t.Errorf("expected failed build to leave no instances, got %d", len(instances))
+	}
+}
+
+func TestInstanceInvalidServiceNames(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	code, _ := ta.post("/spec", newSpecID(), map[string]interface{}{
+		"secret": "hunter2",
+		"services": []map[string]string{
+			{"name": "db", "imageName": "alpine"},
+			{"name": "db", "imageName": "alpine"},
+		},
+	})
+	if code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for duplicate service names, got %d", code)
+	}
+
+	logs, err := ta.app.buildLogStore.FindFailures(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logs) != 1 || !strings.Contains(logs[0].Error, "not unique") {
+		t.Errorf("expected build to fail on the duplicate name, got %+v", logs)
+	}
+
+	if n := len(ta.docker.Containers()); n != 0 {
+		t.Errorf("expected no containers to be created, got %d", n)
+	}
+}
+
+func TestInstanceCleanMissingContainer(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	specID := newSpecID()
+	instanceID := ta.createInstance(specID, map[string]string{"secret": "hunter2"})
+
+	// Containers may be removed out from under ua, e.g. by a manual prune.
+	if err := ta.docker.ContainerRemove(context.Background(), ta.instance(instanceID).ContainerID, types.ContainerRemoveOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ta.clean(specID)
+
+	if instance := ta.instance(instanceID); !instance.Cleaned {
+		t.Error("expected instance with a missing container to be cleaned")
+	}
+
+	if tags := ta.docker.Tags(); len(tags) != 1 || tags[0] != "alpine:latest" {
+		t.Errorf("expected only the base image to be left, got %v", tags)
+	}
+}
+
+func TestInstanceResetSnapshot(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	ta.app.config.SnapshotInstances = true
+
+	specID := newSpecID()
+	data := map[string]string{"secret": "hunter2"}
+	instanceID := ta.createInstance(specID, data)
+
+	// Resetting an instance built from the assignment runs its post-build
+	// actions again.
+	if code, body := ta.post("/spec/reset", specID, nil); code != http.StatusOK {
+		t.Fatalf("expected 200 resetting instance, got %d: %s", code, body)
+	}
+
+	instance := ta.instance(instanceID)
+	if got := ta.file(instance.ContainerID, "/secret"); got != "hunter2" {
+		t.Errorf("expected reset to write secret again, got %q", got)
+	}
+
+	ctr, ok := ta.docker.Container(instance.ContainerID)
+	if !ok {
+		t.Fatal("expected reset container to exist")
+	}
+	baseImageID := ctr.ImageID
+
+	past := time.Now().Add(-time.Minute)
+	instance.ExpiresAt = &past
+	if err := ta.app.repo.SetExpiresAt(instance); err != nil {
+		t.Fatal(err)
+	}
+
+	ta.app.checkExpiredInstances()
+	ta.app.cleanInactiveInstances()
+
+	restoredID := ta.createInstance(specID, data)
+	if restoredID == instanceID {
+		t.Fatal("expected a new instance after expiry")
+	}
+
+	// The snapshot already contains the results of the post-build actions,
+	// so restoring doesn't run them.
+	restored := ta.instance(restoredID)
+	if got := ta.file(restored.ContainerID, "/secret"); got != "" {
+		t.Errorf("expected restoring from a snapshot to skip post-build, got secret %q", got)
+	}
+
+	if code, body := ta.post("/spec/reset", specID, nil); code != http.StatusOK {
+		t.Fatalf("expected 200 resetting restored instance, got %d: %s", code, body)
+	}
+
+	reset := ta.instance(restoredID)
+	if reset.ContainerID == restored.ContainerID {
+		t.Fatal("expected reset to replace the container")
+	}
+
+	// Resetting goes back to the assignment's image, not the snapshot.
+	if got := ta.file(reset.ContainerID, "/secret"); got != "hunter2" {
+		t.Errorf("expected resetting a restored instance to write secret again, got %q", got)
+	}
+
+	ctr, ok = ta.docker.Container(reset.ContainerID)
+	if !ok {
+		t.Fatal("expected reset container to exist")
+	}
+
+	if ctr.ImageID != baseImageID {
+		t.Errorf("expected reset container to use the base image %s, got %s", baseImageID, ctr.ImageID)
+	}
+
+	if _, ok := ta.docker.Container(restored.ContainerID); ok {
+		t.Error("expected the restored container to be removed")
+	}
+}
+
+func TestInstanceResetFailure(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	specID := newSpecID()
+	instanceID := ta.createInstance(specID, map[string]string{"secret": "hunter2"})
+	before := ta.instance(instanceID)
+
+	// Make the post-build actions fail on reset.
+	ta.docker.ExecFunc = func(containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		return 1
+	}
+
+	if code, _ := ta.post("/spec/reset", specID, nil); code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 for a failed reset, got %d", code)
+	}
+
+	after := ta.instance(instanceID)
+	if !after.Active {
+		t.Fatal("expected a failed reset to leave the instance active")
+	}
+
+	if after.ContainerID != before.ContainerID {
+		t.Errorf("expected a failed reset to keep the old container, got %s", after.ContainerID)
+	}
+
+	ctr, ok := ta.docker.Container(before.ContainerID)
+	if !ok {
+		t.Fatal("expected the old container to still exist")
+	}
+
+	if ctr.Name != "ua-"+instanceID {
+		t.Errorf("expected the old container to keep its name, got %s", ctr.Name)
+	}
+
+	if n := len(ta.docker.Containers()); n != 1 {
+		t.Errorf("expected only the old container to be left, got %d", n)
+	}
+}
+
+func TestInstanceSnapshotShutdown(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	ta.app.config.SnapshotInstances = true
+
+	data := map[string]string{"secret": "hunter2"}
+	leftoverSpecID := newSpecID()
+	leftoverID := ta.createInstance(leftoverSpecID, data)
+
+	// Shutting down cleans the remaining active instances, which haven't
+	// expired but still hold their users' work.
+	ta.app.cleanupLeftoverInstances()
+
+	if !ta.instance(leftoverID).Cleaned {
+		t.Fatal("expected active instance to be cleaned at shutdown")
+	}
+
+	id, err := models.ParseID(leftoverSpecID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ta.app.snapshotStore.FindLatest(id); err != nil {
+		t.Errorf("expected instance active at shutdown to be snapshotted, got %v", err)
+	}
+
+	restoredID := ta.createInstance(leftoverSpecID, data)
+	if got := ta.file(ta.instance(restoredID).ContainerID, "/secret"); got != "" {
+		t.Errorf("expected the next instance to be restored from the snapshot, got secret %q", got)
+	}
+
+	// Forcing instances inactive snapshots them the same way.
+	forcedSpecID := newSpecID()
+	forcedID := ta.createInstance(forcedSpecID, data)
+
+	ta.app.markAllInstancesCleanedAndInactive()
+
+	if !ta.instance(forcedID).Cleaned {
+		t.Fatal("expected forced instance to be cleaned")
+	}
+
+	id, err = models.ParseID(forcedSpecID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ta.app.snapshotStore.FindLatest(id); err != nil {
+		t.Errorf("expected instance forced inactive to be snapshotted, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	units "github.com/docker/go-units"
+	"github.com/jakebailey/ua/app/specbuild"
+)
+
+const (
+	defaultMemory            = 16 * units.MiB
+	defaultMemoryReservation = 4 * units.MiB
+	defaultDiskSize          = 500 * units.MB
+)
+
+// applyLimits sets the resource limits of an instance's container. Invalid
+// limits (like negative values) are rejected, requested limits are clamped
+// to the configured maximums, and unset limits use the server's defaults.
+// If limits are disabled, only tmpfs mounts are applied, unclamped.
+func (a *App) applyLimits(hostConfig *container.HostConfig, limits *specbuild.Limits) error {
+	if limits == nil {
+		limits = &specbuild.Limits{}
+	}
+
+	if err := limits.Check(); err != nil {
+		return err
+	}
+
+	// These have been checked by Config.Verify.
+	maxMemory, _ := units.RAMInBytes(a.config.MaxMemory)
+	maxDiskSize, _ := units.RAMInBytes(a.config.MaxDiskSize)
+	maxTmpfsSize, _ := units.RAMInBytes(a.config.MaxTmpfsSize)
+	maxUlimits, _ := a.config.maxUlimits()
+
+	if len(limits.Tmpfs) != 0 && hostConfig.Tmpfs == nil {
+		hostConfig.Tmpfs = make(map[string]string, len(limits.Tmpfs))
+	}
+
+	for path, options := range limits.Tmpfs {
+		if !a.config.DisableLimits {
+			options = clampTmpfsSize(options, maxTmpfsSize)
+		}
+		hostConfig.Tmpfs[path] = options
+	}
+
+	if a.config.DisableLimits {
+		return nil
+	}
+
+	memory, _ := limits.MemoryBytes()
+	diskSize, _ := limits.DiskSizeBytes()
+
+	if memory == 0 {
+		memory = defaultMemory
+	}
+	memory = clampInt64(memory, maxMemory)
+
+	if diskSize == 0 {
+		diskSize = defaultDiskSize
+	}
+	diskSize = clampInt64(diskSize, maxDiskSize)
+
+	cpus := limits.CPUs
+	if cpus == 0 || (a.config.MaxCPUs > 0 && cpus > a.config.MaxCPUs) {
+		cpus = a.config.MaxCPUs
+	}
+
+	pids := limits.Pids
+	if pids == 0 || (a.config.MaxPids > 0 && pids > a.config.MaxPids) {
+		pids = a.config.MaxPids
+	}
+
+	hostConfig.Resources.CPUShares = 2
+	hostConfig.Resources.Memory = memory
+	hostConfig.Resources.NanoCPUs = int64(cpus * 1e9)
+
+	if memory > defaultMemoryReservation {
+		hostConfig.Resources.MemoryReservation = defaultMemoryReservation
+	}
+
+	if pids > 0 {
+		hostConfig.Resources.PidsLimit = &pids
+	}
+
+	for _, u := range limits.Ulimits {
+		soft, hard := u.Soft, u.Hard
+		if max, ok := maxUlimits[u.Name]; ok {
+			soft = clampInt64(soft, max)
+			hard = clampInt64(hard, max)
+		}
+
+		hostConfig.Resources.Ulimits = append(hostConfig.Resources.Ulimits, &units.Ulimit{
+			Name: u.Name,
+			Soft: soft,
+			Hard: hard,
+		})
+	}
+
+	hostConfig.StorageOpt = map[string]string{
+		"size": strconv.FormatInt(diskSize, 10),
+	}
+
+	return nil
+}
+
+// clampTmpfsSize rewrites tmpfs mount options so that the mount's size is at
+// most max, adding a size if the options have none. If max isn't positive,
+// the options are returned as is.
+func clampTmpfsSize(options string, max int64) string {
+	if max <= 0 {
+		return options
+	}
+
+	// This has been checked by Limits.Check.
+	size, _ := specbuild.TmpfsSize(options)
+	if size != 0 && size <= max {
+		return options
+	}
+
+	var clamped []string
+	for _, option := range strings.Split(options, ",") {
+		if option != "" && !strings.HasPrefix(option, "size=") {
+			clamped = append(clamped, option)
+		}
+	}
+
+	return strings.Join(append(clamped, "size="+strconv.FormatInt(max, 10)), ",")
+}
+
+// clampInt64 returns v, or max if v is larger and max is positive.
+func clampInt64(v int64, max int64) int64 {
+	if max > 0 && v > max {
+		return max
+	}
+	return v
+}
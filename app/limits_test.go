@@ -0,0 +1,75 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/jakebailey/ua/app/specbuild"
+)
+
+func TestApplyLimits(t *testing.T) {
+	config := DefaultConfig
+	config.MaxCPUs = 1
+	config.MaxPids = 100
+	config.MaxTmpfsSize = "64m"
+	config.MaxUlimits = []string{"nofile:1024"}
+
+	a := &App{config: config}
+
+	var hostConfig container.HostConfig
+	err := a.applyLimits(&hostConfig, &specbuild.Limits{
+		Memory: "4g",
+		CPUs:   2,
+		Pids:   1000,
+		Ulimits: []specbuild.Ulimit{
+			{Name: "nofile", Soft: 512, Hard: 4096},
+			{Name: "core", Soft: 0, Hard: 0},
+		},
+		Tmpfs: map[string]string{
+			"/tmp":   "size=1g,mode=1777",
+			"/cache": "",
+			"/small": "size=1m",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := hostConfig.Resources.Memory; got != 1<<30 {
+		t.Errorf("expected memory to be clamped to 1GiB, got %d", got)
+	}
+
+	if got := hostConfig.Resources.NanoCPUs; got != 1e9 {
+		t.Errorf("expected CPUs to be clamped to 1, got %d nanocpus", got)
+	}
+
+	if got := hostConfig.Resources.PidsLimit; got == nil || *got != 100 {
+		t.Errorf("expected pids to be clamped to 100, got %v", got)
+	}
+
+	ulimits := hostConfig.Resources.Ulimits
+	if len(ulimits) != 2 || ulimits[0].Soft != 512 || ulimits[0].Hard != 1024 || ulimits[1].Name != "core" {
+		t.Errorf("expected nofile hard limit to be clamped to 1024, got %v", ulimits)
+	}
+
+	for path, expected := range map[string]string{
+		"/tmp":   "mode=1777,size=67108864",
+		"/cache": "size=67108864",
+		"/small": "size=1m",
+	} {
+		if got := hostConfig.Tmpfs[path]; got != expected {
+			t.Errorf("expected tmpfs %s options %q, got %q", path, expected, got)
+		}
+	}
+
+	for _, limits := range []*specbuild.Limits{
+		{CPUs: -1},
+		{Pids: -1},
+		{Ulimits: []specbuild.Ulimit{{Name: "nofile", Soft: -1, Hard: -1}}},
+		{Tmpfs: map[string]string{"/tmp": "size=lots"}},
+	} {
+		if err := a.applyLimits(&container.HostConfig{}, limits); err == nil {
+			t.Errorf("expected error applying %+v", limits)
+		}
+	}
+}
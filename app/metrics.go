@@ -0,0 +1,134 @@
+package app
+
+import (
+	"net/http"
+
+	"github.com/jakebailey/ua/app/specbuild"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "ua"
+
+// metrics are the app's Prometheus metrics, served at /metrics. They are
+// kept in their own registry rather than the global one, so that multiple
+// apps can exist in one process.
+type metrics struct {
+	registry *prometheus.Registry
+
+	buildDuration  *prometheus.HistogramVec
+	actionDuration *prometheus.HistogramVec
+	websockets     *prometheus.GaugeVec
+	autoPulls      *prometheus.CounterVec
+	pruneReclaimed prometheus.Counter
+	pruneDeleted   *prometheus.CounterVec
+}
+
+func (a *App) newMetrics() *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+
+		buildDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "instance_build_duration_seconds",
+			Help:      "Time taken to build instances, by result.",
+			Buckets:   prometheus.ExponentialBuckets(0.25, 2, 10),
+		}, []string{"result"}),
+
+		actionDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "postbuild_action_duration_seconds",
+			Help:      "Time taken to perform post-build actions, by action and result.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 4, 8),
+		}, []string{"action", "result"}),
+
+		websockets: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "websockets_active",
+			Help:      "Number of connected websockets, by kind (terminal or observer).",
+		}, []string{"kind"}),
+
+		autoPulls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "autopull_total",
+			Help:      "Number of images auto-pulled, by result.",
+		}, []string{"result"}),
+
+		pruneReclaimed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "prune_reclaimed_bytes_total",
+			Help:      "Disk space reclaimed by pruning Docker.",
+		}),
+
+		pruneDeleted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "prune_deleted_total",
+			Help:      "Number of Docker objects deleted by pruning, by kind.",
+		}, []string{"kind"}),
+	}
+
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.buildDuration,
+		m.actionDuration,
+		m.websockets,
+		m.autoPulls,
+		m.pruneReclaimed,
+		m.pruneDeleted,
+
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "websocket_manager_entries",
+			Help:      "Number of websockets tracked for expiry.",
+		}, func() float64 {
+			if a.wsManager == nil {
+				return 0
+			}
+			return float64(a.wsManager.Len())
+		}),
+
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "docker_up",
+			Help:      "Whether the last Docker precheck succeeded.",
+		}, func() float64 {
+			return boolFloat(a.dockerOk.Load())
+		}),
+
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Name:      "database_up",
+			Help:      "Whether the last database precheck succeeded.",
+		}, func() float64 {
+			return boolFloat(a.databaseOk.Load())
+		}),
+	)
+
+	return m
+}
+
+func (m *metrics) handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// observeActions records the durations of the actions in an action log.
+func (m *metrics) observeActions(actionLog *specbuild.ActionLog) {
+	for _, entry := range actionLog.Entries() {
+		m.actionDuration.WithLabelValues(entry.Action, resultLabel(entry.Error == "")).Observe(entry.Duration.Seconds())
+	}
+}
+
+func resultLabel(success bool) string {
+	if success {
+		return "success"
+	}
+	return "failure"
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
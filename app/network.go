@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jakebailey/ua/app/specbuild"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/runtime"
+	"go.uber.org/zap"
+)
+
+// isolatedNetworkAllowance is the entry in AllowedNetworks which allows
+// per-instance isolated networks.
+const isolatedNetworkAllowance = "isolated"
+
+func (a *App) networkAllowed(name string) bool {
+	for _, allowed := range a.config.AllowedNetworks {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNetwork checks that the requested network is allowed.
+func (a *App) checkNetwork(network *specbuild.Network) error {
+	switch {
+	case network == nil:
+		return nil
+	case network.Isolated:
+		if !a.networkAllowed(isolatedNetworkAllowance) {
+			return fmt.Errorf("isolated networks are not allowed")
+		}
+	case network.Name != "":
+		if !a.networkAllowed(network.Name) {
+			return fmt.Errorf("network %q is not allowed", network.Name)
+		}
+	}
+	return nil
+}
+
+// instanceNetwork prepares the network for an instance's container, creating
+// its isolated network if requested, and returns the name of the network the
+// container should be attached to, or "" if the container should have no
+// network.
+func (a *App) instanceNetwork(ctx context.Context, instanceName string, network *specbuild.Network) (string, error) {
+	if err := a.checkNetwork(network); err != nil {
+		return "", err
+	}
+
+	switch {
+	case network == nil:
+		return "", nil
+	case network.Isolated:
+		return a.createIsolatedNetwork(ctx, instanceName)
+	default:
+		return network.Name, nil
+	}
+}
+
+// createIsolatedNetwork creates an internal network for an instance, which
+// shares its name. If the network already exists (as the instance's container
+// is being recreated), it is reused.
+func (a *App) createIsolatedNetwork(ctx context.Context, instanceName string) (string, error) {
+	logger := ctxlog.FromContext(ctx)
+
+	err := a.rt.CreateNetwork(ctx, instanceName, runtime.NetworkOptions{
+		Internal: true,
+		Labels: map[string]string{
+			"ua.owned": "true",
+		},
+	})
+	if err != nil {
+		logger.Error("error creating isolated network",
+			zap.Error(err),
+		)
+		return "", err
+	}
+
+	return instanceName, nil
+}
+
+// removeIsolatedNetwork removes an instance's isolated network, if it has
+// one.
+func (a *App) removeIsolatedNetwork(ctx context.Context, instanceName string) error {
+	if err := a.rt.RemoveNetwork(ctx, instanceName); err != nil && !runtime.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
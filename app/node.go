@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"go.uber.org/zap"
+)
+
+// Multiple nodes may share a database. Each instance is owned by the node
+// which created it, and its terminal sessions are tracked in that node's
+// memory, so requests which use an instance's container are forwarded to
+// its owner. Scheduled cleanup runs on every node, and takes a lock shared
+// through the database before acting on an instance.
+
+// forwardedHeader is set on requests forwarded from one node to another,
+// naming the node which forwarded it, so that requests aren't forwarded in
+// a loop.
+const forwardedHeader = "X-UA-Forwarded-By"
+
+// ownsInstance returns true if the instance belongs to this node. Instances
+// created before nodes were recorded belong to every node.
+func (a *App) ownsInstance(instance *models.Instance) bool {
+	return instance.Node == "" || instance.Node == a.nodeName
+}
+
+// ownedInstances filters instances down to those owned by this node.
+func (a *App) ownedInstances(instances []*models.Instance) []*models.Instance {
+	var owned []*models.Instance
+	for _, instance := range instances {
+		if a.ownsInstance(instance) {
+			owned = append(owned, instance)
+		}
+	}
+	return owned
+}
+
+// managesInstance returns true if this node can manage the instance's
+// container, either because it owns the instance, or because the container
+// is on a remote Docker host which this node also uses. Local Docker hosts
+// have the same address on every machine, so say nothing about where the
+// container is.
+func (a *App) managesInstance(instance *models.Instance) bool {
+	if a.ownsInstance(instance) {
+		return true
+	}
+
+	return instance.DockerHost == a.dockerHost && !isLocalDockerHost(a.dockerHost)
+}
+
+func isLocalDockerHost(host string) bool {
+	return host == "" || strings.HasPrefix(host, "unix://") || strings.HasPrefix(host, "npipe://")
+}
+
+// forwardInstance proxies a request for an instance owned by another node
+// to that node, returning true if the request has been handled. Websockets
+// are proxied as well.
+func (a *App) forwardInstance(w http.ResponseWriter, r *http.Request, instance *models.Instance) bool {
+	if a.ownsInstance(instance) {
+		return false
+	}
+
+	logger := ctxlog.FromRequest(r).With(
+		zap.String("instance_id", instance.ID.String()),
+		zap.String("node", instance.Node),
+	)
+
+	if by := r.Header.Get(forwardedHeader); by != "" {
+		logger.Error("request for another node's instance was forwarded here",
+			zap.String("forwarded_by", by),
+		)
+		http.Error(w, "instance is owned by another node", http.StatusBadGateway)
+		return true
+	}
+
+	if instance.NodeURL == "" {
+		logger.Error("instance is owned by a node without a URL")
+		http.Error(w, "instance is owned by an unreachable node", http.StatusBadGateway)
+		return true
+	}
+
+	target, err := url.Parse(instance.NodeURL)
+	if err != nil {
+		logger.Error("error parsing node URL",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return true
+	}
+
+	logger.Debug("forwarding request to owning node",
+		zap.String("node_url", instance.NodeURL),
+	)
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		logger.Error("error forwarding request to owning node",
+			zap.Error(err),
+		)
+		http.Error(w, "error reaching owning node", http.StatusBadGateway)
+	}
+
+	r.Header.Set(forwardedHeader, a.nodeName)
+	proxy.ServeHTTP(w, r)
+
+	return true
+}
+
+// locker takes the named locks shared by every node. It is the database,
+// unless replaced (as in tests, to act as if another node holds a lock).
+type locker interface {
+	TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error)
+}
+
+// withClusterLock runs fn while holding a lock on the instance shared with
+// every other node, so that no two nodes act on it at once. fn is given the
+// instance as it is once the lock is taken, as another node may have just
+// changed it. If another node holds the lock, fn isn't run, and false is
+// returned.
+func (a *App) withClusterLock(ctx context.Context, instance *models.Instance, fn func(*models.Instance) error) (bool, error) {
+	unlock, ok, err := a.locker.TryLock(ctx, "instance:"+instance.ID.String())
+	if err != nil || !ok {
+		return false, err
+	}
+	defer unlock()
+
+	current, err := a.repo.FindInstance(instance.ID)
+	if err != nil {
+		return true, err
+	}
+
+	return true, fn(current)
+}
@@ -0,0 +1,192 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNodeForwarding(t *testing.T) {
+	a := newTestApp(t)
+	defer a.close()
+
+	b := a.newNode("b")
+	defer b.close()
+
+	specID := newSpecID()
+	instanceID := b.createInstance(specID, map[string]string{"secret": "hunter2"})
+
+	instance := a.instance(instanceID)
+	if instance.Node != "b" || instance.NodeURL != b.srv.URL {
+		t.Errorf("expected instance to be owned by b at %s, got %q at %q", b.srv.URL, instance.Node, instance.NodeURL)
+	}
+
+	if instance.DockerHost != b.docker.DaemonHost() {
+		t.Errorf("expected instance to record Docker host %q, got %q", b.docker.DaemonHost(), instance.DockerHost)
+	}
+
+	if _, ok := b.docker.Container(instance.ContainerID); !ok {
+		t.Fatal("expected instance container to exist on b")
+	}
+
+	if n := len(a.docker.Containers()); n != 0 {
+		t.Errorf("expected no containers on a, got %d", n)
+	}
+
+	// Connecting through a is proxied to b, which runs the container.
+	c, code := a.dial(instanceID)
+	if code != http.StatusSwitchingProtocols {
+		t.Fatalf("expected websocket upgrade through a, got %d", code)
+	}
+
+	c.send("stdin", "hello")
+	if out, err := c.readStdout("hello"); err != nil {
+		t.Fatalf("expected echoed output, got %q and error %v", out, err)
+	}
+
+	c.close()
+
+	waitFor(t, "container to stop", func() bool {
+		ctr, _ := b.docker.Container(instance.ContainerID)
+		return !ctr.Running
+	})
+
+	// A request which has already been forwarded is not forwarded again.
+	req, err := http.NewRequest("GET", a.srv.URL+"/instance/"+instanceID+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(forwardedHeader, "b")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected 502 for a forwarding loop, got %d", resp.StatusCode)
+	}
+
+	// Cleaning through a only marks the instance inactive; b's cleanup
+	// removes the container.
+	a.clean(specID)
+
+	instance = a.instance(instanceID)
+	if instance.Active || instance.Cleaned {
+		t.Errorf("expected instance to be inactive and not cleaned, got active=%v cleaned=%v", instance.Active, instance.Cleaned)
+	}
+
+	a.app.cleanInactiveInstances()
+
+	if a.instance(instanceID).Cleaned {
+		t.Error("expected a to leave b's instance alone")
+	}
+
+	b.app.cleanInactiveInstances()
+
+	if !a.instance(instanceID).Cleaned {
+		t.Error("expected b to clean its instance")
+	}
+
+	if n := len(b.docker.Containers()); n != 0 {
+		t.Errorf("expected no containers on b after cleaning, got %d", n)
+	}
+}
+
+// heldLocker acts as if another node holds every lock.
+type heldLocker struct{}
+
+func (heldLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	return nil, false, nil
+}
+
+func TestNodeLockContended(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	ta.app.config.PProfToken = testAdminToken
+
+	specID := newSpecID()
+	instanceID := ta.createInstance(specID, map[string]string{"secret": "hunter2"})
+	before := ta.instance(instanceID)
+
+	ta.app.locker = heldLocker{}
+
+	if code, body := ta.admin("POST", "/instances/"+instanceID+"/clean"); code != http.StatusConflict {
+		t.Errorf("expected 409 cleaning a locked instance, got %d: %s", code, body)
+	}
+
+	if code, body := ta.post("/spec/reset", specID, nil); code != http.StatusConflict {
+		t.Errorf("expected 409 resetting a locked instance, got %d: %s", code, body)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	before.ExpiresAt = &past
+	if err := ta.app.repo.SetExpiresAt(before); err != nil {
+		t.Fatal(err)
+	}
+
+	ta.app.checkExpiredInstances()
+	ta.app.cleanupLeftoverInstances()
+	ta.app.markAllInstancesCleanedAndInactive()
+
+	after := ta.instance(instanceID)
+	if !after.Active || after.Cleaned {
+		t.Errorf("expected a locked instance to be left alone, got active=%v cleaned=%v", after.Active, after.Cleaned)
+	}
+
+	if after.ContainerID != before.ContainerID {
+		t.Errorf("expected a locked instance to keep its container, got %s", after.ContainerID)
+	}
+
+	if _, ok := ta.docker.Container(before.ContainerID); !ok {
+		t.Error("expected a locked instance's container to still exist")
+	}
+
+	// Once the lock is free, the instance is handled as usual.
+	ta.app.locker = ta.app.db
+
+	ta.app.checkExpiredInstances()
+	ta.app.cleanInactiveInstances()
+
+	if !ta.instance(instanceID).Cleaned {
+		t.Error("expected the instance to be cleaned once unlocked")
+	}
+}
+
+func TestNodeExpireForwarded(t *testing.T) {
+	a := newTestApp(t)
+	defer a.close()
+
+	b := a.newNode("b")
+	defer b.close()
+
+	a.app.config.PProfToken = testAdminToken
+	b.app.config.PProfToken = testAdminToken
+
+	instanceID := b.createInstance(newSpecID(), map[string]string{"secret": "hunter2"})
+
+	c, code := b.dial(instanceID)
+	if code != http.StatusSwitchingProtocols {
+		t.Fatalf("expected websocket upgrade, got %d", code)
+	}
+	defer c.close()
+
+	c.send("stdin", "hello")
+	if out, err := c.readStdout("hello"); err != nil {
+		t.Fatalf("expected echoed output, got %q and error %v", out, err)
+	}
+
+	// Expiring through a is forwarded to b, which closes the session.
+	if code, body := a.admin("POST", "/instances/"+instanceID+"/expire"); code != http.StatusOK {
+		t.Fatalf("expected 200 expiring through a, got %d: %s", code, body)
+	}
+
+	c.waitClosed()
+
+	if a.instance(instanceID).Active {
+		t.Error("expected the instance to be inactive")
+	}
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/docker/proxy"
+	"github.com/jakebailey/ua/pkg/errhack"
+	"github.com/jakebailey/ua/pkg/simplecrypto"
+	"go.uber.org/zap"
+)
+
+const defaultObserverTokenExpiry = 4 * time.Hour
+
+// observerToken creates a token which allows its holder to observe an
+// instance until the given expiry time. The token is the expiry as a Unix
+// timestamp, followed by an HMAC of the instance ID and expiry signed using
+// the observer key. This key is separate from the AES key, so holding a spec
+// does not allow a user to create observer tokens.
+func (a *App) observerToken(instanceID string, expires time.Time) string {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	mac := simplecrypto.HMAC(a.observerKey, observerTokenMessage(instanceID, exp))
+	return exp + "." + base64.RawURLEncoding.EncodeToString(mac)
+}
+
+// checkObserverToken reports whether the token is a valid, unexpired
+// observer token for the instance.
+func (a *App) checkObserverToken(instanceID string, token string) bool {
+	if len(a.observerKey) == 0 {
+		return false
+	}
+
+	i := strings.IndexByte(token, '.')
+	if i < 0 {
+		return false
+	}
+
+	exp := token[:i]
+
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > expUnix {
+		return false
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil {
+		return false
+	}
+
+	return simplecrypto.CheckMAC(a.observerKey, observerTokenMessage(instanceID, exp), mac)
+}
+
+func observerTokenMessage(instanceID string, exp string) []byte {
+	return []byte("observe:" + instanceID + ":" + exp)
+}
+
+type observerTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (a *App) debugObserverToken(w http.ResponseWriter, r *http.Request) {
+	instanceID, err := models.ParseID(chi.URLParam(r, "instanceID"))
+	if err != nil {
+		a.httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	expiry := defaultObserverTokenExpiry
+
+	if s := r.FormValue("expires"); s != "" {
+		expiry, err = time.ParseDuration(s)
+		if err != nil {
+			a.httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	expiresAt := time.Now().Add(expiry)
+
+	render.JSON(w, r, &observerTokenResponse{
+		Token:     a.observerToken(instanceID.String(), expiresAt),
+		ExpiresAt: expiresAt,
+	})
+}
+
+// handleObserver attaches a connection to one of an instance's active
+// sessions as a read-only observer. Anything the observer sends is discarded.
+func (a *App) handleObserver(ctx context.Context, conn proxy.Conn, instance *models.Instance, sessionName string) {
+	defer a.wsWG.Done()
+
+	logger := ctxlog.FromContext(ctx).With(
+		zap.String("instance_id", instance.ID.String()),
+	)
+
+	session := a.session(instance.ID.String(), sessionName)
+	if session == nil || !session.Attach(conn) {
+		if err := conn.WriteJSON([]string{"stdout", "\r\nNo active session to observe.\r\n"}); err != nil {
+			logger.Warn("error writing no session message",
+				zap.Error(err),
+			)
+		}
+
+		if err := errhack.IgnoreClose(conn.Close()); err != nil {
+			logger.Error("error closing observer connection",
+				zap.Error(err),
+			)
+		}
+		return
+	}
+	defer session.Detach(conn)
+
+	logger.Info("observer attached")
+	defer logger.Info("observer detached")
+
+	a.publishWebsocket(eventWebsocketAttached, instance, sessionName, true)
+	defer a.publishWebsocket(eventWebsocketDetached, instance, sessionName, true)
+
+	websockets := a.metrics.websockets.WithLabelValues("observer")
+	websockets.Inc()
+	defer websockets.Dec()
+
+	// Raw clients send their input as binary messages, which aren't JSON.
+	raw := proxy.AsRaw(conn)
+
+	var buf []interface{}
+	for {
+		var err error
+		if raw != nil {
+			_, _, err = raw.ReadRaw(&buf)
+		} else {
+			err = conn.ReadJSON(&buf)
+		}
+
+		if err != nil {
+			if !conn.IsClose(err) {
+				logger.Debug("observer read error",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+	}
+}
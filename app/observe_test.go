@@ -0,0 +1,89 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/jakebailey/ua/pkg/docker/proxy"
+)
+
+func TestObserverRaw(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	ta.app.observerKey = []byte("observer key")
+
+	instanceID := ta.createInstance(newSpecID(), map[string]string{"secret": "hunter2"})
+
+	c, code := ta.dial(instanceID)
+	if code != http.StatusSwitchingProtocols {
+		t.Fatalf("expected websocket upgrade, got %d", code)
+	}
+	defer c.close()
+
+	c.send("stdin", "hello")
+	if out, err := c.readStdout("hello"); err != nil {
+		t.Fatalf("expected echoed output, got %q and error %v", out, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token := ta.app.observerToken(instanceID, time.Now().Add(time.Minute))
+	url := "ws" + strings.TrimPrefix(ta.srv.URL, "http") + "/instance/" + instanceID + "/ws?observer=" + token
+
+	dialer := ws.Dialer{Protocols: []string{proxy.RawProtocol}}
+	conn, br, hs, err := dialer.Dial(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if hs.Protocol != proxy.RawProtocol {
+		t.Fatalf("expected the raw protocol, got %q", hs.Protocol)
+	}
+
+	var r io.Reader = conn
+	if br != nil {
+		r = br
+	}
+	rw := struct {
+		io.Reader
+		io.Writer
+	}{bufio.NewReader(r), conn}
+
+	// Raw clients send terminal data as binary messages, which observers
+	// must be able to send (and have ignored) without being disconnected.
+	if err := wsutil.WriteClientBinary(rw, []byte("ignored")); err != nil {
+		t.Fatal(err)
+	}
+
+	c.send("stdin", "world")
+
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The observed session doesn't use raw framing, so its output may come
+	// as JSON text messages as well as binary ones.
+	var out string
+	for !strings.Contains(out, "world") {
+		data, _, err := wsutil.ReadServerData(rw)
+		if err != nil {
+			t.Fatalf("expected observer to see output, got %q and error %v", out, err)
+		}
+
+		out += string(data)
+	}
+
+	if strings.Contains(out, "ignored") {
+		t.Errorf("expected observer input to be ignored, got %q", out)
+	}
+}
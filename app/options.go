@@ -3,6 +3,8 @@ package app
 import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/docker/docker/client"
+	"github.com/jakebailey/ua/pkg/runtime"
+	"github.com/jakebailey/ua/storage"
 	"go.uber.org/zap"
 )
 
@@ -35,3 +37,19 @@ func WithDockerClient(cli client.CommonAPIClient) Option {
 		a.cli = cli
 	}
 }
+
+// WithRuntime sets the container runtime used to build, run, and clean up
+// instances. If not provided, the Docker client is used.
+func WithRuntime(rt runtime.Runtime) Option {
+	return func(a *App) {
+		a.rt = rt
+	}
+}
+
+// WithDB sets the database used in the app. If not provided, the database
+// is opened using the configured connection string.
+func WithDB(db *storage.DB) Option {
+	return func(a *App) {
+		a.db = db
+	}
+}
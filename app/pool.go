@@ -0,0 +1,341 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jakebailey/ua/app/specbuild"
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/patrickmn/go-cache"
+	"go.uber.org/zap"
+)
+
+const (
+	poolLabel           = "ua.pool"
+	poolContainerPrefix = "ua-pool-"
+
+	// poolNodeLabel names the node which created a pooled container, as
+	// nodes may share a Docker host.
+	poolNodeLabel = "ua.pool.node"
+)
+
+// instancePool holds running containers which are ready to be claimed by
+// new instances. Containers are only pooled for assignments which use a
+// prebuilt image, as their containers don't depend on the spec until the
+// post-build actions are run.
+//
+// Pooled containers are keyed by the image and the container settings
+// which come from the generate output. A key is pooled only if it has been
+// used recently.
+type instancePool struct {
+	mu         sync.Mutex
+	containers map[poolKey][]string
+
+	used *cache.Cache
+}
+
+type poolKey struct {
+	ImageName string
+	Init      bool
+
+	// Limits is the JSON encoding of the requested limits.
+	Limits string
+}
+
+func (k poolKey) String() string {
+	return k.ImageName + " init=" + strconv.FormatBool(k.Init) + " limits=" + k.Limits
+}
+
+func newInstancePool(expiry time.Duration) *instancePool {
+	return &instancePool{
+		containers: make(map[poolKey][]string),
+		used:       cache.New(expiry, time.Minute),
+	}
+}
+
+func newPoolKey(gen *specbuild.GenerateOutput) (poolKey, bool) {
+	if gen.ImageName == "" {
+		return poolKey{}, false
+	}
+
+	limits, err := json.Marshal(gen.Limits)
+	if err != nil {
+		return poolKey{}, false
+	}
+
+	return poolKey{
+		ImageName: gen.ImageName,
+		Init:      gen.Init != nil && *gen.Init,
+		Limits:    string(limits),
+	}, true
+}
+
+func (p *instancePool) take(key poolKey) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ids := p.containers[key]
+	if len(ids) == 0 {
+		return "", false
+	}
+
+	id := ids[len(ids)-1]
+	p.containers[key] = ids[:len(ids)-1]
+	return id, true
+}
+
+func (p *instancePool) add(key poolKey, id string) {
+	p.mu.Lock()
+	p.containers[key] = append(p.containers[key], id)
+	p.mu.Unlock()
+}
+
+func (p *instancePool) count(key poolKey) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.containers[key])
+}
+
+// removeUnused removes and returns the containers for keys which haven't
+// been used recently.
+func (p *instancePool) removeUnused() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var ids []string
+
+	for key, keyIDs := range p.containers {
+		if _, ok := p.used.Get(key.String()); ok {
+			continue
+		}
+
+		ids = append(ids, keyIDs...)
+		delete(p.containers, key)
+	}
+
+	return ids
+}
+
+func (p *instancePool) removeAll() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var ids []string
+
+	for key, keyIDs := range p.containers {
+		ids = append(ids, keyIDs...)
+		delete(p.containers, key)
+	}
+
+	return ids
+}
+
+// poolClaim claims a pooled container for the generate output, renaming it
+// to the given name. If no container is available, poolClaim returns false.
+func (a *App) poolClaim(ctx context.Context, gen *specbuild.GenerateOutput, containerName string) (string, bool) {
+	if a.pool == nil {
+		return "", false
+	}
+
+	key, ok := newPoolKey(gen)
+	if !ok {
+		return "", false
+	}
+
+	logger := ctxlog.FromContext(ctx).With(
+		zap.String("pool_key", key.String()),
+	)
+
+	a.pool.used.SetDefault(key.String(), key)
+
+	// Refill the pool (or create it for the first time) in the background.
+	defer a.poolRunner.Run()
+
+	for {
+		id, ok := a.pool.take(key)
+		if !ok {
+			logger.Debug("no pooled container available")
+			return "", false
+		}
+
+		if err := a.rt.Rename(ctx, id, containerName); err != nil {
+			logger.Warn("error renaming pooled container, removing",
+				zap.Error(err),
+				zap.String("container_id", id),
+			)
+			a.removePoolContainers(ctx, []string{id})
+			continue
+		}
+
+		logger.Debug("claimed pooled container",
+			zap.String("container_id", id),
+		)
+
+		return id, true
+	}
+}
+
+// fillPool creates containers for recently used pool keys until each has
+// PoolSize containers, and removes containers for keys no longer in use.
+func (a *App) fillPool() {
+	if !a.precheckDocker() {
+		return
+	}
+
+	ctx := ctxlog.WithLogger(context.Background(), a.logger)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	if unused := a.pool.removeUnused(); len(unused) != 0 {
+		a.logger.Debug("removing unused pooled containers",
+			zap.Int("count", len(unused)),
+		)
+		a.removePoolContainers(ctx, unused)
+	}
+
+	for _, item := range a.pool.used.Items() {
+		key := item.Object.(poolKey)
+
+		logger := a.logger.With(
+			zap.String("pool_key", key.String()),
+		)
+
+		created := 0
+
+		for a.pool.count(key) < a.config.PoolSize {
+			id, err := a.createPoolContainer(ctx, key)
+			if err != nil {
+				logger.Error("error creating pooled container",
+					zap.Error(err),
+				)
+				break
+			}
+
+			a.pool.add(key, id)
+			created++
+		}
+
+		if created != 0 {
+			logger.Info("created pooled containers",
+				zap.Int("count", created),
+			)
+		}
+	}
+}
+
+func (a *App) createPoolContainer(ctx context.Context, key poolKey) (string, error) {
+	logger := ctxlog.FromContext(ctx)
+
+	if err := a.rt.EnsureImage(ctx, key.ImageName); err != nil {
+		return "", err
+	}
+
+	a.autoPullMark(key.ImageName)
+
+	var limits *specbuild.Limits
+	if err := json.Unmarshal([]byte(key.Limits), &limits); err != nil {
+		return "", err
+	}
+
+	init := key.Init
+
+	containerConfig, hostConfig, err := a.instanceContainerConfig(key.ImageName, &init, limits)
+	if err != nil {
+		return "", err
+	}
+	containerConfig.Labels[poolLabel] = "true"
+	containerConfig.Labels[poolNodeLabel] = a.nodeName
+
+	name := poolContainerPrefix + models.NewID().String()
+
+	id, err := a.rt.Create(ctx, name, containerConfig, hostConfig)
+	if err != nil {
+		return "", err
+	}
+
+	if err := a.rt.Start(ctx, id); err != nil {
+		logger.Warn("error starting pooled container",
+			zap.Error(err),
+		)
+		a.removePoolContainers(ctx, []string{id})
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (a *App) removePoolContainers(ctx context.Context, ids []string) {
+	logger := ctxlog.FromContext(ctx)
+
+	for _, id := range ids {
+		if err := a.rt.Remove(ctx, id, true); err != nil {
+			logger.Warn("error removing pooled container",
+				zap.Error(err),
+				zap.String("container_id", id),
+			)
+		}
+	}
+}
+
+// drainPool removes all pooled containers.
+func (a *App) drainPool() {
+	if a.pool == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	ctx = ctxlog.WithLogger(ctx, a.logger)
+
+	a.removePoolContainers(ctx, a.pool.removeAll())
+}
+
+// removeLeftoverPoolContainers removes pooled containers left behind by a
+// previous run of this node. Containers which were claimed by an instance
+// have been renamed, so are left alone, as are other nodes' containers.
+func (a *App) removeLeftoverPoolContainers() {
+	if !a.precheckDocker() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	ctx = ctxlog.WithLogger(ctx, a.logger)
+
+	containers, err := a.rt.List(ctx, map[string]string{
+		poolLabel:     "true",
+		poolNodeLabel: a.nodeName,
+	})
+	if err != nil {
+		a.logger.Warn("error listing leftover pooled containers",
+			zap.Error(err),
+		)
+		return
+	}
+
+	var ids []string
+
+	for _, c := range containers {
+		if strings.HasPrefix(c.Name, poolContainerPrefix) {
+			ids = append(ids, c.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return
+	}
+
+	a.logger.Info("removing leftover pooled containers",
+		zap.Int("count", len(ids)),
+	)
+
+	a.removePoolContainers(ctx, ids)
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+func TestRemoveLeftoverPoolContainers(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	// Nodes may share a Docker host, so only this node's leftover pooled
+	// containers are removed.
+	for name, node := range map[string]string{
+		poolContainerPrefix + "a": "a",
+		poolContainerPrefix + "b": "b",
+	} {
+		config := &container.Config{
+			Image: "alpine",
+			Labels: map[string]string{
+				poolLabel:     "true",
+				poolNodeLabel: node,
+			},
+		}
+
+		if _, err := ta.docker.ContainerCreate(context.Background(), config, nil, nil, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ta.app.removeLeftoverPoolContainers()
+
+	if _, ok := ta.docker.Container(poolContainerPrefix + "a"); ok {
+		t.Error("expected the node's leftover pooled container to be removed")
+	}
+
+	if _, ok := ta.docker.Container(poolContainerPrefix + "b"); !ok {
+		t.Error("expected another node's pooled container to be left alone")
+	}
+}
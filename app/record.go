@@ -0,0 +1,238 @@
+package app
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/asciicast"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"go.uber.org/zap"
+)
+
+const recordingExt = ".cast"
+
+// startRecording creates a new asciicast recording for an instance, stored
+// as RecordPath/instanceID/timestamp.cast. The returned file must be closed
+// once the session is over.
+func (a *App) startRecording(instanceID string) (*asciicast.Writer, *os.File, error) {
+	dir := filepath.Join(a.config.RecordPath, instanceID)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, nil, err
+	}
+
+	name := time.Now().UTC().Format("20060102T150405.000000000") + recordingExt
+
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rec, err := asciicast.NewWriter(f, asciicast.Header{
+		Width:  80,
+		Height: 24,
+		Title:  instanceID,
+	})
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+
+	return rec, f, nil
+}
+
+type recordingInfo struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+func (a *App) instanceRecordings(w http.ResponseWriter, r *http.Request) {
+	logger := ctxlog.FromRequest(r)
+
+	dir, ok := a.recordingDir(w, r)
+	if !ok {
+		return
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.NotFound(w, r)
+			return
+		}
+
+		logger.Error("error opening recording directory",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	infos, err := f.Readdir(-1)
+	if err != nil {
+		logger.Error("error reading recording directory",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	recordings := make([]recordingInfo, 0, len(infos))
+
+	for _, info := range infos {
+		if info.IsDir() || filepath.Ext(info.Name()) != recordingExt {
+			continue
+		}
+
+		recordings = append(recordings, recordingInfo{
+			Name:    strings.TrimSuffix(info.Name(), recordingExt),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+
+	render.JSON(w, r, recordings)
+}
+
+func (a *App) instanceRecording(w http.ResponseWriter, r *http.Request) {
+	logger := ctxlog.FromRequest(r)
+
+	dir, ok := a.recordingDir(w, r)
+	if !ok {
+		return
+	}
+
+	name := chi.URLParam(r, "recording")
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := os.Open(filepath.Join(dir, name+recordingExt))
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.NotFound(w, r)
+			return
+		}
+
+		logger.Error("error opening recording",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		logger.Error("error getting recording info",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-asciicast")
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+// recordingDir returns the directory holding an instance's recordings.
+// Recordings are kept by the node which ran the sessions, so requests for
+// another node's instance are forwarded to it.
+func (a *App) recordingDir(w http.ResponseWriter, r *http.Request) (string, bool) {
+	instanceID, err := models.ParseID(chi.URLParam(r, "instanceID"))
+	if err != nil {
+		a.httpError(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+
+	instance, err := a.repo.FindInstance(instanceID)
+	if err != nil {
+		if err == models.ErrNotFound {
+			http.NotFound(w, r)
+			return "", false
+		}
+
+		ctxlog.FromRequest(r).Error("error querying for instance",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return "", false
+	}
+
+	if a.forwardInstance(w, r, instance) {
+		return "", false
+	}
+
+	return filepath.Join(a.config.RecordPath, instanceID.String()), true
+}
+
+// pruneRecordings removes session recordings older than RecordRetention, as
+// well as any instance recording directories left empty.
+func (a *App) pruneRecordings() {
+	if a.config.RecordPath == "" {
+		return
+	}
+
+	logger := a.logger.With(
+		zap.String("record_path", a.config.RecordPath),
+	)
+
+	threshold := time.Now().Add(-a.config.RecordRetention)
+
+	dirs, err := filepath.Glob(filepath.Join(a.config.RecordPath, "*"))
+	if err != nil {
+		logger.Error("error listing recording directories",
+			zap.Error(err),
+		)
+		return
+	}
+
+	count := 0
+
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*"+recordingExt))
+		if err != nil {
+			logger.Error("error listing recordings",
+				zap.Error(err),
+			)
+			continue
+		}
+
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil || info.ModTime().After(threshold) {
+				continue
+			}
+
+			if err := os.Remove(file); err != nil {
+				logger.Warn("error removing recording",
+					zap.Error(err),
+					zap.String("filename", file),
+				)
+				continue
+			}
+
+			count++
+		}
+
+		// Only succeeds if the directory is empty.
+		_ = os.Remove(dir)
+	}
+
+	if count != 0 {
+		logger.Info("pruned recordings",
+			zap.Int("count", count),
+		)
+	} else {
+		logger.Debug("no recordings to prune")
+	}
+}
@@ -17,7 +17,7 @@ func (a *App) route() {
 	cors := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Connection", "Upgrade"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "Connection", "Upgrade", specHeader},
 		ExposedHeaders:   []string{"Link", "X-Request-ID"},
 		AllowCredentials: true,
 		MaxAge:           300,
@@ -30,6 +30,7 @@ func (a *App) route() {
 	r.Use(uamid.Recoverer)
 
 	r.Route("/health", a.routeHealth)
+	r.With(a.tokenAuthMiddleware).Handle("/metrics", a.metrics.handler())
 
 	if a.config.StaticPath == "" {
 		r.Handle("/static/*", http.StripPrefix("/static", http.FileServer(static.FS(false))))
@@ -46,6 +47,8 @@ func (a *App) route() {
 		r.Route("/instance", a.routeInstance)
 	})
 
+	r.Route("/admin", a.routeAdmin)
+
 	if a.config.Debug {
 		r.Route("/debug", a.routeDebug)
 	} else {
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/docker/proxy"
+	"go.uber.org/zap"
+)
+
+// defaultSessionName is the name of the session used when a client doesn't
+// ask for a specific one.
+const defaultSessionName = "default"
+
+var sessionNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// instanceSessions tracks the users of a running instance, i.e. its
+// terminal sessions. The instance's container is started when it gains its
+// first user, and stopped when it loses its last.
+type instanceSessions struct {
+	// mu is held while the container is being started or stopped.
+	mu      sync.Mutex
+	started bool
+
+	// These fields are protected by App.sessionsMu.
+	refs  int
+	conns map[string]*proxy.TeeConn
+}
+
+func sessionKey(instanceID string, name string) string {
+	return instanceID + "/" + name
+}
+
+// acquireInstance marks the instance as in use, starting its container and
+// disabling its expiry if it was not already in use. Every call to
+// acquireInstance must be followed by a call to releaseInstance, even if an
+// error is returned.
+func (a *App) acquireInstance(ctx context.Context, instance *models.Instance) error {
+	logger := ctxlog.FromContext(ctx)
+	id := instance.ID.String()
+
+	a.sessionsMu.Lock()
+	s := a.sessions[id]
+	if s == nil {
+		s = &instanceSessions{
+			conns: make(map[string]*proxy.TeeConn),
+		}
+		a.sessions[id] = s
+	}
+	s.refs++
+	a.sessionsMu.Unlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The container may have been replaced (see specReset) since the
+	// instance was loaded.
+	if current, err := a.repo.FindInstance(instance.ID); err != nil {
+		logger.Warn("error querying for instance container",
+			zap.Error(err),
+		)
+	} else {
+		instance.ContainerID = current.ContainerID
+	}
+
+	if s.started {
+		return nil
+	}
+
+	instance.ExpiresAt = nil
+	if err := a.repo.SetExpiresAt(instance); err != nil {
+		logger.Error("error disabling expiry for instance",
+			zap.Error(err),
+		)
+	}
+
+	// Services are started first, so they're available to the instance.
+	a.startSidecars(ctx, instance)
+
+	if err := a.rt.Start(ctx, instance.ContainerID); err != nil {
+		logger.Error("error starting container",
+			zap.Error(err),
+		)
+		return err
+	}
+
+	s.started = true
+	a.publishInstance(eventInstanceStarted, instance)
+	return nil
+}
+
+// releaseInstance releases an instance acquired via acquireInstance. If this
+// was the last user of the instance, its container is stopped and its expiry
+// time is set.
+func (a *App) releaseInstance(ctx context.Context, instance *models.Instance) {
+	logger := ctxlog.FromContext(ctx)
+	id := instance.ID.String()
+
+	a.sessionsMu.Lock()
+	s := a.sessions[id]
+	a.sessionsMu.Unlock()
+
+	if s == nil {
+		logger.Warn("releasing instance which was never acquired")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a.sessionsMu.Lock()
+	s.refs--
+	last := s.refs == 0
+	a.sessionsMu.Unlock()
+
+	if !last {
+		return
+	}
+
+	instance.ExpiresAt = a.instanceExpireTime()
+
+	if err := a.repo.SetExpiresAt(instance); err != nil {
+		logger.Error("error adding ExpiresAt to instance",
+			zap.Error(err),
+		)
+	}
+
+	if err := a.rt.Stop(ctx, instance.ContainerID, time.Second); err != nil {
+		logger.Error("error stopping container",
+			zap.Error(err),
+		)
+	}
+
+	a.stopSidecars(ctx, instance)
+
+	a.publishInstance(eventInstanceStopped, instance)
+
+	s.started = false
+
+	// Another user may have acquired the instance while it was stopping;
+	// if so, it will start the container again once s.mu is released.
+	a.sessionsMu.Lock()
+	if s.refs == 0 && a.sessions[id] == s {
+		delete(a.sessions, id)
+	}
+	a.sessionsMu.Unlock()
+}
+
+// lockInstance prevents an instance's container from being started or
+// stopped until fn returns, so that fn can replace it. The container is
+// treated as stopped afterward, and will be started by its next user.
+func (a *App) lockInstance(instance *models.Instance, fn func() error) error {
+	id := instance.ID.String()
+
+	a.sessionsMu.Lock()
+	s := a.sessions[id]
+	if s == nil {
+		s = &instanceSessions{
+			conns: make(map[string]*proxy.TeeConn),
+		}
+		a.sessions[id] = s
+	}
+	s.refs++
+	a.sessionsMu.Unlock()
+
+	s.mu.Lock()
+	err := fn()
+	s.started = false
+	s.mu.Unlock()
+
+	a.sessionsMu.Lock()
+	s.refs--
+	if s.refs == 0 && a.sessions[id] == s {
+		delete(a.sessions, id)
+	}
+	a.sessionsMu.Unlock()
+
+	return err
+}
+
+// expireSessions closes all of an instance's terminal sessions.
+func (a *App) expireSessions(instanceID string) {
+	var names []string
+
+	a.sessionsMu.Lock()
+	if s := a.sessions[instanceID]; s != nil {
+		for name := range s.conns {
+			names = append(names, name)
+		}
+	}
+	a.sessionsMu.Unlock()
+
+	for _, name := range names {
+		a.wsManager.ExpireAndRemove(sessionKey(instanceID, name))
+	}
+}
+
+// setSession registers the connection of a terminal session, so that
+// observers can attach to it. The instance must have been acquired.
+func (a *App) setSession(instanceID string, name string, conn *proxy.TeeConn) {
+	a.sessionsMu.Lock()
+	if s := a.sessions[instanceID]; s != nil {
+		s.conns[name] = conn
+	}
+	a.sessionsMu.Unlock()
+}
+
+// removeSession removes a terminal session, but only if it is still the
+// provided connection (as a newer connection may have taken over).
+func (a *App) removeSession(instanceID string, name string, conn *proxy.TeeConn) {
+	a.sessionsMu.Lock()
+	if s := a.sessions[instanceID]; s != nil && s.conns[name] == conn {
+		delete(s.conns, name)
+	}
+	a.sessionsMu.Unlock()
+}
+
+// session returns the connection for a terminal session, or nil if there is
+// no such session.
+func (a *App) session(instanceID string, name string) *proxy.TeeConn {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+
+	if s := a.sessions[instanceID]; s != nil {
+		return s.conns[name]
+	}
+	return nil
+}
@@ -0,0 +1,263 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/jakebailey/ua/app/specbuild"
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/runtime"
+	"go.uber.org/zap"
+)
+
+// requestedNetwork returns the network requested by the generate output.
+// Services require an isolated network, so one is used if the output has
+// services but didn't request a network.
+func requestedNetwork(gen *specbuild.GenerateOutput) (*specbuild.Network, error) {
+	if len(gen.Services) == 0 {
+		return gen.Network, nil
+	}
+
+	if gen.Network != nil && gen.Network.Name != "" {
+		return nil, fmt.Errorf("services cannot be used with network %q", gen.Network.Name)
+	}
+
+	return &specbuild.Network{Isolated: true}, nil
+}
+
+// createSidecars creates, sets up, and stops the containers for an
+// instance's services, attaching them to the instance's network. If any
+// service fails, all of the created sidecars are removed.
+func (a *App) createSidecars(ctx context.Context, assignmentPath string, instanceName string, networkName string, services []specbuild.Service) ([]*models.Sidecar, error) {
+	var sidecars []*models.Sidecar
+
+	for _, svc := range services {
+		sc, err := a.createSidecar(ctx, assignmentPath, instanceName, networkName, svc)
+		if err != nil {
+			a.removeSidecars(ctx, sidecars)
+			return nil, fmt.Errorf("service %s: %v", svc.Name, err)
+		}
+
+		sidecars = append(sidecars, sc)
+	}
+
+	return sidecars, nil
+}
+
+func (a *App) createSidecar(ctx context.Context, assignmentPath string, instanceName string, networkName string, svc specbuild.Service) (*models.Sidecar, error) {
+	name := instanceName + "-" + svc.Name
+
+	ctx, logger := ctxlog.FromContextWith(ctx,
+		zap.String("service", svc.Name),
+	)
+
+	if err := specbuild.TagImage(ctx, a.rt, svc.ImageName, name, true); err != nil {
+		return nil, err
+	}
+	a.autoPullMark(svc.ImageName)
+
+	sc := models.NewSidecar()
+	sc.Name = svc.Name
+	sc.ImageID = name
+
+	init := svc.Init
+	if init == nil {
+		truth := true
+		init = &truth
+	}
+
+	containerConfig := &container.Config{
+		Image:    name,
+		Hostname: svc.Name,
+		User:     svc.User,
+		Cmd:      svc.Cmd,
+		Env:      svc.Env,
+		Labels: map[string]string{
+			"ua.owned": "true",
+		},
+	}
+	hostConfig := &container.HostConfig{
+		Init: init,
+	}
+
+	if err := a.applyLimits(hostConfig, svc.Limits); err != nil {
+		a.removeSidecars(ctx, []*models.Sidecar{sc})
+		return nil, err
+	}
+
+	containerID, err := a.rt.Create(ctx, name, containerConfig, hostConfig)
+	if err != nil {
+		logger.Error("error creating sidecar container",
+			zap.Error(err),
+		)
+		a.removeSidecars(ctx, []*models.Sidecar{sc})
+		return nil, err
+	}
+	sc.ContainerID = containerID
+
+	if err := a.setupSidecar(ctx, assignmentPath, networkName, sc, svc); err != nil {
+		logger.Warn("sidecar setup failed, attempting to remove",
+			zap.Error(err),
+		)
+		a.removeSidecars(ctx, []*models.Sidecar{sc})
+		return nil, err
+	}
+
+	return sc, nil
+}
+
+func (a *App) setupSidecar(ctx context.Context, assignmentPath string, networkName string, sc *models.Sidecar, svc specbuild.Service) error {
+	logger := ctxlog.FromContext(ctx)
+
+	if err := a.rt.Start(ctx, sc.ContainerID); err != nil {
+		return err
+	}
+
+	specbuild.WalkActions(svc.PostBuild, func(_ []int, ac *specbuild.Action) {
+		if ac.Action == "gobuild" {
+			ac.SrcPath = filepath.Join(assignmentPath, "gosrc")
+		}
+	})
+
+	if err := specbuild.PerformActions(ctx, a.rt, sc.ContainerID, svc.PostBuild); err != nil {
+		logger.Error("error performing sidecar post-build actions",
+			zap.Error(err),
+		)
+		return err
+	}
+
+	if err := a.rt.Connect(ctx, networkName, sc.ContainerID, []string{svc.Name}); err != nil {
+		logger.Error("error connecting sidecar network",
+			zap.Error(err),
+		)
+		return err
+	}
+
+	if err := a.rt.Disconnect(ctx, "bridge", sc.ContainerID); err != nil {
+		logger.Error("error disconnecting network",
+			zap.Error(err),
+		)
+		return err
+	}
+
+	if err := a.rt.Stop(ctx, sc.ContainerID, runtime.DefaultStopTimeout); err != nil {
+		logger.Error("error stopping sidecar container",
+			zap.Error(err),
+		)
+		return err
+	}
+
+	return nil
+}
+
+// removeSidecars removes sidecar containers and images, returning the first
+// error encountered. Containers and images which don't exist are ignored.
+func (a *App) removeSidecars(ctx context.Context, sidecars []*models.Sidecar) error {
+	logger := ctxlog.FromContext(ctx)
+
+	// Use another context just in case the old context was cancelled.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var firstErr error
+
+	for _, sc := range sidecars {
+		if sc.ContainerID != "" {
+			if err := a.rt.Remove(ctx, sc.ContainerID, true); err != nil && !runtime.IsNotFound(err) {
+				logger.Error("error removing sidecar container",
+					zap.Error(err),
+					zap.String("container_id", sc.ContainerID),
+				)
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+		}
+
+		if err := a.rt.RemoveImage(ctx, sc.ImageID); err != nil && !runtime.IsNotFound(err) {
+			logger.Error("error removing sidecar image",
+				zap.Error(err),
+				zap.String("image_id", sc.ImageID),
+			)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// insertSidecars stores the sidecars created for an instance.
+func (a *App) insertSidecars(instance *models.Instance, sidecars []*models.Sidecar) error {
+	for _, sc := range sidecars {
+		sc.InstanceID = instance.ID
+
+		if err := a.sidecarStore.Insert(sc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *App) instanceSidecars(ctx context.Context, instance *models.Instance) []*models.Sidecar {
+	sidecars, err := a.sidecarStore.FindByInstance(instance.ID)
+	if err != nil {
+		ctxlog.FromContext(ctx).Error("error querying for sidecars",
+			zap.Error(err),
+		)
+	}
+	return sidecars
+}
+
+// startSidecars starts an instance's sidecar containers.
+func (a *App) startSidecars(ctx context.Context, instance *models.Instance) {
+	logger := ctxlog.FromContext(ctx)
+
+	for _, sc := range a.instanceSidecars(ctx, instance) {
+		if err := a.rt.Start(ctx, sc.ContainerID); err != nil {
+			logger.Error("error starting sidecar container",
+				zap.Error(err),
+				zap.String("service", sc.Name),
+			)
+		}
+	}
+}
+
+// stopSidecars stops an instance's sidecar containers.
+func (a *App) stopSidecars(ctx context.Context, instance *models.Instance) {
+	logger := ctxlog.FromContext(ctx)
+
+	for _, sc := range a.instanceSidecars(ctx, instance) {
+		if err := a.rt.Stop(ctx, sc.ContainerID, time.Second); err != nil {
+			logger.Error("error stopping sidecar container",
+				zap.Error(err),
+				zap.String("service", sc.Name),
+			)
+		}
+	}
+}
+
+// cleanSidecars removes an instance's sidecars, marking them as cleaned.
+func (a *App) cleanSidecars(ctx context.Context, instance *models.Instance) error {
+	for _, sc := range a.instanceSidecars(ctx, instance) {
+		if err := a.removeSidecars(ctx, []*models.Sidecar{sc}); err != nil {
+			if !strings.Contains(err.Error(), "image is being used by stopped container") {
+				return err
+			}
+		}
+
+		if err := a.sidecarStore.MarkCleaned(sc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/runtime"
+	"go.uber.org/zap"
+)
+
+const (
+	snapshotImagePrefix = "ua-snapshot-"
+
+	// snapshotLabel is set on snapshot images (and so on the images of
+	// instances restored from them) to the ID of the snapshot.
+	snapshotLabel = "ua.snapshot"
+)
+
+// instanceExpired returns true if the instance was made inactive because it
+// expired, rather than being cleaned on request.
+func instanceExpired(instance *models.Instance) bool {
+	return instance.ExpiresAt != nil && !instance.ExpiresAt.After(time.Now())
+}
+
+// keepsWork returns true if an instance's work should be kept in a snapshot
+// when it's cleaned: it either expired, or is still active and is only being
+// cleaned because the node is shutting down. Instances cleaned on request
+// are meant to be started over.
+func keepsWork(instance *models.Instance) bool {
+	return instance.Active || instanceExpired(instance)
+}
+
+// snapshotInstance commits an instance's container into a snapshot image,
+// which will be used to create the spec's next instance. The instance's spec
+// must have been loaded.
+func (a *App) snapshotInstance(ctx context.Context, instance *models.Instance) error {
+	if instance.Spec == nil {
+		return errors.New("instance spec not loaded")
+	}
+
+	snapshot := models.NewSnapshot()
+	snapshot.SpecID = instance.Spec.ID
+	snapshot.InstanceID = instance.ID
+	snapshot.ImageID = snapshotImagePrefix + snapshot.ID.String()
+
+	ctx, logger := ctxlog.FromContextWith(ctx,
+		zap.String("snapshot_id", snapshot.ID.String()),
+	)
+
+	_, err := a.rt.Commit(ctx, instance.ContainerID, runtime.CommitOptions{
+		Reference: snapshot.ImageID,
+		Comment:   "snapshot of instance " + instance.ID.String(),
+		Labels: map[string]string{
+			snapshotLabel: snapshot.ID.String(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := a.snapshotStore.Insert(snapshot); err != nil {
+		a.removeSnapshots(ctx, []*models.Snapshot{snapshot})
+		return err
+	}
+
+	logger.Info("snapshotted instance")
+
+	return nil
+}
+
+// latestSnapshot returns the snapshot a new instance of the spec should be
+// created from, or nil if there is none.
+func (a *App) latestSnapshot(ctx context.Context, specID models.ID) *models.Snapshot {
+	if !a.config.SnapshotInstances {
+		return nil
+	}
+
+	snapshot, err := a.snapshotStore.FindLatest(specID)
+	if err != nil {
+		if err != models.ErrNotFound {
+			ctxlog.FromContext(ctx).Error("error querying for snapshot",
+				zap.Error(err),
+			)
+		}
+		return nil
+	}
+
+	return snapshot
+}
+
+// removeSnapshots removes snapshot images, marking the snapshots as cleaned.
+// Images which no longer exist are ignored.
+func (a *App) removeSnapshots(ctx context.Context, snapshots []*models.Snapshot) {
+	logger := ctxlog.FromContext(ctx)
+
+	for _, snapshot := range snapshots {
+		if err := a.rt.RemoveImage(ctx, snapshot.ImageID); err != nil && !runtime.IsNotFound(err) {
+			logger.Warn("error removing snapshot image",
+				zap.Error(err),
+				zap.String("image_id", snapshot.ImageID),
+			)
+			continue
+		}
+
+		if err := a.snapshotStore.MarkCleaned(snapshot); err != nil {
+			logger.Error("error marking snapshot as cleaned in database",
+				zap.Error(err),
+				zap.String("snapshot_id", snapshot.ID.String()),
+			)
+		}
+	}
+}
+
+// discardSnapshots removes all of a spec's snapshots, so that its next
+// instance starts fresh.
+func (a *App) discardSnapshots(ctx context.Context, specID models.ID) {
+	snapshots, err := a.snapshotStore.FindBySpec(specID)
+	if err != nil {
+		ctxlog.FromContext(ctx).Error("error querying for snapshots",
+			zap.Error(err),
+		)
+		return
+	}
+
+	a.removeSnapshots(ctx, snapshots)
+}
+
+// pruneSnapshots removes snapshots older than SnapshotRetention, and those
+// superseded by a newer snapshot of the same spec.
+func (a *App) pruneSnapshots(ctx context.Context) {
+	logger := ctxlog.FromContext(ctx)
+
+	snapshots, err := a.snapshotStore.FindExpired(time.Now().Add(-a.config.SnapshotRetention))
+	if err != nil {
+		logger.Error("error querying for expired snapshots",
+			zap.Error(err),
+		)
+		return
+	}
+
+	if len(snapshots) == 0 {
+		return
+	}
+
+	a.removeSnapshots(ctx, snapshots)
+
+	logger.Info("pruned snapshots",
+		zap.Int("count", len(snapshots)),
+	)
+}
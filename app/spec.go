@@ -1,12 +1,12 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"io/ioutil"
 	"net/http"
-	"path/filepath"
-	"sort"
-	"strings"
 	"time"
 
 	"github.com/go-chi/chi"
@@ -15,15 +15,12 @@ import (
 	"github.com/jakebailey/ua/app/specbuild"
 	"github.com/jakebailey/ua/models"
 	"github.com/jakebailey/ua/pkg/ctxlog"
-	"github.com/jakebailey/ua/pkg/simplecrypto"
 	"github.com/jakebailey/ua/templates"
-	uuid "github.com/satori/go.uuid"
 	"go.uber.org/zap"
-	"gopkg.in/src-d/go-kallax.v1"
 )
 
 var (
-	nilULID = kallax.ULID(uuid.Nil)
+	nilID models.ID
 )
 
 func (a *App) routeSpec(r chi.Router) {
@@ -35,10 +32,12 @@ func (a *App) routeSpec(r chi.Router) {
 
 	r.Post("/", a.specPost)
 	r.Post("/clean", a.specClean)
+	r.Post("/grade", a.specGrade)
+	r.Post("/reset", a.specReset)
 }
 
 func (a *App) specGet(w http.ResponseWriter, _ *http.Request) {
-	specID := kallax.NewULID().String()
+	specID := models.NewID().String()
 	templates.WriteSpec(w, specID)
 }
 
@@ -52,17 +51,74 @@ type specPostResponse struct {
 	InstanceID string `json:"instanceID"`
 }
 
-func (a *App) specProcessRequest(w http.ResponseWriter, r *http.Request) kallax.ULID {
+// specProcessRequest decrypts and validates the spec request in the request
+// body, inserting the spec if it's new, and returns the spec's ID. If this
+// fails, an error is written and an empty ID is returned. The body is kept,
+// so that the request can be forwarded to another node.
+func (a *App) specProcessRequest(w http.ResponseWriter, r *http.Request) models.ID {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		a.httpError(w, err.Error(), http.StatusBadRequest)
+		return nilID
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return a.specProcessReader(w, r, bytes.NewReader(body))
+}
+
+// specProcessReader is specProcessRequest, but reads the encrypted request
+// from body rather than the request body.
+func (a *App) specProcessReader(w http.ResponseWriter, r *http.Request, body io.Reader) models.ID {
+	specID, req := a.specDecodeReader(w, r, body)
+	if specID.IsEmpty() {
+		return nilID
+	}
+
+	logger := ctxlog.FromContext(r.Context()).With(
+		zap.String("spec_id", specID.String()),
+	)
+
+	if _, err := a.repo.FindSpec(specID); err != nil {
+		if err != models.ErrNotFound {
+			logger.Error("error querying for spec",
+				zap.Error(err),
+			)
+			a.httpError(w, err.Error(), http.StatusInternalServerError)
+			return nilID
+		}
+
+		spec := &models.Spec{
+			ID:             specID,
+			AssignmentName: req.AssignmentName,
+			Data:           req.Data,
+		}
+
+		if err := a.repo.InsertSpec(spec); err != nil {
+			logger.Error("error inserting spec",
+				zap.Error(err),
+			)
+			a.httpError(w, err.Error(), http.StatusInternalServerError)
+			return nilID
+		}
+	}
+
+	return specID
+}
+
+// specDecodeReader decrypts and validates the spec request read from body,
+// without looking up or inserting the spec. If this fails, an error is
+// written and an empty ID is returned.
+func (a *App) specDecodeReader(w http.ResponseWriter, r *http.Request, body io.Reader) (models.ID, *specPostRequest) {
 	ctx := r.Context()
 	logger := ctxlog.FromContext(ctx)
 
-	payload, err := simplecrypto.DecodeJSONReader(a.aesKey, r.Body)
+	payload, err := a.keyring.DecodeJSONReader(body)
 	if err != nil {
 		logger.Warn("error decrypting payload",
 			zap.Error(err),
 		)
 		a.httpError(w, err.Error(), http.StatusBadRequest)
-		return nilULID
+		return nilID, nil
 	}
 
 	var req specPostRequest
@@ -72,61 +128,31 @@ func (a *App) specProcessRequest(w http.ResponseWriter, r *http.Request) kallax.
 			zap.Error(jerr),
 		)
 		a.httpError(w, jerr.Error(), http.StatusBadRequest)
-		return nilULID
+		return nilID, nil
 	}
 
 	if req.SpecID == "" {
 		http.Error(w, "spec ID cannot be blank", http.StatusBadRequest)
-		return nilULID
+		return nilID, nil
 	}
 
 	if req.AssignmentName == "" {
 		http.Error(w, "assignment name cannot be blank", http.StatusBadRequest)
-		return nilULID
+		return nilID, nil
 	}
 
-	specID, err := kallax.NewULIDFromText(req.SpecID)
+	specID, err := models.ParseID(req.SpecID)
 	if err != nil {
 		a.httpError(w, err.Error(), http.StatusBadRequest)
-		return nilULID
+		return nilID, nil
 	}
 
-	logger = logger.With(
-		zap.String("spec_id", specID.String()),
-	)
-
 	if specID.IsEmpty() {
 		http.Error(w, "spec ID cannot be all zero", http.StatusBadRequest)
-		return nilULID
+		return nilID, nil
 	}
 
-	specQuery := models.NewSpecQuery().FindByID(specID)
-
-	if _, err := a.specStore.FindOne(specQuery); err != nil {
-		if err != kallax.ErrNotFound {
-			logger.Error("error querying for spec",
-				zap.Error(err),
-			)
-			a.httpError(w, err.Error(), http.StatusInternalServerError)
-			return nilULID
-		}
-
-		spec := &models.Spec{
-			ID:             specID,
-			AssignmentName: req.AssignmentName,
-			Data:           req.Data,
-		}
-
-		if err := a.specStore.Insert(spec); err != nil {
-			logger.Error("error inserting spec",
-				zap.Error(err),
-			)
-			a.httpError(w, err.Error(), http.StatusInternalServerError)
-			return nilULID
-		}
-	}
-
-	return specID
+	return specID, &req
 }
 
 func (a *App) specPost(w http.ResponseWriter, r *http.Request) {
@@ -156,11 +182,24 @@ func (a *App) specPost(w http.ResponseWriter, r *http.Request) {
 	render.JSON(w, r, resp)
 }
 
-func (a *App) getActiveInstance(ctx context.Context, specID kallax.ULID) (*models.Instance, error) {
+func (a *App) getActiveInstance(ctx context.Context, specID models.ID) (*models.Instance, error) {
+	logger := ctxlog.FromContext(ctx)
+
+	instance, err := a.findActiveInstance(ctx, specID)
+	if err == models.ErrNotFound {
+		logger.Debug("no active instance found, creating a new instance")
+		return a.createInstance(ctx, specID)
+	}
+
+	return instance, err
+}
+
+// findActiveInstance finds the active instance for a spec, returning
+// models.ErrNotFound if there is none.
+func (a *App) findActiveInstance(ctx context.Context, specID models.ID) (*models.Instance, error) {
 	logger := ctxlog.FromContext(ctx)
 
-	instanceQuery := models.NewInstanceQuery().FindBySpec(specID).FindByActive(true)
-	instances, err := a.instanceStore.FindAll(instanceQuery)
+	instances, err := a.repo.FindActiveBySpec(specID)
 	if err != nil {
 		logger.Error("error querying for instances",
 			zap.Error(err),
@@ -170,33 +209,24 @@ func (a *App) getActiveInstance(ctx context.Context, specID kallax.ULID) (*model
 
 	instancesLen := len(instances)
 	if instancesLen == 0 {
-		logger.Debug("no active instance found, creating a new instance")
-		return a.createInstance(ctx, specID)
+		return nil, models.ErrNotFound
 	}
 
 	if instancesLen != 1 {
 		logger.Warn("found multiple active instances, using most recently created",
 			zap.Int("instances_len", instancesLen),
 		)
-
-		sort.Slice(instances, func(i, j int) bool {
-			return instances[i].CreatedAt.After(instances[j].CreatedAt)
-		})
 	}
 
-	logger.Debug("reusing active instance")
+	logger.Debug("found active instance")
 
 	return instances[0], nil
 }
 
-func (a *App) createInstance(ctx context.Context, specID kallax.ULID) (*models.Instance, error) {
+func (a *App) createInstance(ctx context.Context, specID models.ID) (*models.Instance, error) {
 	logger := ctxlog.FromContext(ctx)
 
-	specQuery := models.NewSpecQuery().FindByID(specID).Select(
-		models.Schema.Spec.AssignmentName,
-		models.Schema.Spec.Data,
-	)
-	spec, err := a.specStore.FindOne(specQuery)
+	spec, err := a.repo.FindSpec(specID)
 	if err != nil {
 		logger.Error("error querying spec for build info",
 			zap.Error(err),
@@ -205,36 +235,65 @@ func (a *App) createInstance(ctx context.Context, specID kallax.ULID) (*models.I
 	}
 
 	instance := models.NewInstance()
+	instance.Spec = spec
 
 	ctx, logger = ctxlog.FromContextWith(ctx,
 		zap.String("assignment_name", spec.AssignmentName),
 		zap.String("instance_id", instance.ID.String()),
 	)
 
-	pathSlice := []string{a.config.AssignmentPath}
-	pathSlice = append(pathSlice, strings.Split(spec.AssignmentName, ".")...)
-	path := filepath.Join(pathSlice...)
+	path := specbuild.AssignmentPath(a.config.AssignmentPath, spec.AssignmentName)
 
 	imageTag := "ua-" + instance.ID.String()
 	containerName := imageTag
 
 	before := time.Now()
 
-	imageID, containerID, iCmd, err := a.specCreate(ctx, path, spec.Data, imageTag, containerName)
+	actionLog := &specbuild.ActionLog{}
+
+	snapshot := a.latestSnapshot(ctx, specID)
+	if snapshot != nil {
+		logger.Debug("restoring instance from snapshot",
+			zap.String("snapshot_id", snapshot.ID.String()),
+		)
+	}
+
+	imageID, containerID, iCmd, sidecars, err := a.specCreate(specbuild.WithActionLog(ctx, actionLog), path, spec.Data, imageTag, containerName, snapshot)
+	if err != specbuild.ErrNoJS {
+		a.insertBuildLog(ctx, specID, instance.ID, spec.AssignmentName, actionLog, err)
+		a.metrics.observeActions(actionLog)
+	}
 	if err != nil {
 		if err != specbuild.ErrNoJS {
+			if snapshot != nil {
+				// Don't let a broken snapshot prevent the spec from
+				// ever getting a new instance.
+				logger.Warn("error creating instance from snapshot, discarding snapshot",
+					zap.Error(err),
+				)
+				a.removeSnapshots(ctx, []*models.Snapshot{snapshot})
+			}
+			a.metrics.buildDuration.WithLabelValues(resultLabel(false)).Observe(time.Since(before).Seconds())
+			a.publishBuildFailed(specID, spec.AssignmentName, instance, err)
 			return nil, err
 		}
 
 		logger.Debug("building legacy image")
 
+		// Legacy images are always built from scratch, leaving any
+		// snapshot to be pruned.
+		snapshot = nil
+
 		imageID, containerID, iCmd, err = a.specLegacyCreate(ctx, path, spec.Data, imageTag, containerName)
 		if err != nil {
+			a.metrics.buildDuration.WithLabelValues(resultLabel(false)).Observe(time.Since(before).Seconds())
+			a.publishBuildFailed(specID, spec.AssignmentName, instance, err)
 			return nil, err
 		}
 	}
 
 	took := time.Since(before)
+	a.metrics.buildDuration.WithLabelValues(resultLabel(true)).Observe(took.Seconds())
 
 	logger = logger.With(
 		zap.String("image_id", imageID),
@@ -248,6 +307,9 @@ func (a *App) createInstance(ctx context.Context, specID kallax.ULID) (*models.I
 	instance.ContainerID = containerID
 	instance.ExpiresAt = a.instanceExpireTime()
 	instance.Active = true
+	instance.Node = a.nodeName
+	instance.NodeURL = a.config.NodeURL
+	instance.DockerHost = a.dockerHost
 
 	if iCmd != nil {
 		instance.Command = *iCmd
@@ -255,24 +317,42 @@ func (a *App) createInstance(ctx context.Context, specID kallax.ULID) (*models.I
 		logger.Warn("no instance command provided, results may be undefined")
 	}
 
-	if err := a.specStore.Transaction(func(specStore *models.SpecStore) error {
-		specQuery := models.NewSpecQuery().FindByID(specID)
-		spec, err := specStore.FindOne(specQuery)
-		if err != nil {
-			return err
-		}
-
-		spec.Instances = append(spec.Instances, instance)
-
-		_, err = specStore.Update(spec)
-		return err
-	}); err != nil {
+	if err := a.repo.InsertInstance(instance); err != nil {
 		logger.Error("error inserting new instance",
 			zap.Error(err),
 		)
 		return nil, err
 	}
 
+	if err := a.insertSidecars(instance, sidecars); err != nil {
+		logger.Error("error inserting sidecars, marking instance inactive",
+			zap.Error(err),
+		)
+
+		a.removeSidecars(ctx, sidecars)
+
+		if uerr := a.repo.MarkInactive(instance); uerr != nil {
+			logger.Error("error marking instance as inactive in database",
+				zap.Error(uerr),
+			)
+		}
+
+		return nil, err
+	}
+
+	// The instance now holds the snapshot's work (and the snapshot's image,
+	// under its own tag).
+	if snapshot != nil {
+		a.removeSnapshots(ctx, []*models.Snapshot{snapshot})
+	}
+
+	a.publish(event{
+		Type:           eventInstanceCreated,
+		InstanceID:     instance.ID.String(),
+		SpecID:         specID.String(),
+		AssignmentName: spec.AssignmentName,
+	})
+
 	return instance, nil
 }
 
@@ -297,8 +377,11 @@ func (a *App) specClean(w http.ResponseWriter, r *http.Request) {
 		defer cancel()
 
 		ctx = ctxlog.WithLogger(ctx, logger)
-		instanceQuery := models.NewInstanceQuery().FindBySpec(specID).FindByCleaned(false)
-		a.cleanupInstancesByQuery(ctx, instanceQuery)
+		a.cleanupInstances(ctx, func() ([]*models.Instance, error) {
+			return a.repo.ListUncleanedBySpec(specID)
+		})
+
+		a.discardSnapshots(ctx, specID)
 	}
 
 	if async {
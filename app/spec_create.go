@@ -6,135 +6,169 @@ import (
 	"path/filepath"
 	"time"
 
-	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
-	units "github.com/docker/go-units"
 	"github.com/jakebailey/ua/app/gobuild"
 	"github.com/jakebailey/ua/app/specbuild"
 	"github.com/jakebailey/ua/models"
 	"github.com/jakebailey/ua/pkg/ctxlog"
-	"github.com/jakebailey/ua/pkg/docker/image"
+	"github.com/jakebailey/ua/pkg/runtime"
 	"go.uber.org/zap"
 )
 
-func (a *App) specCreate(ctx context.Context, assignmentPath string, specData interface{}, imageTag string, containerName string) (imageID, containerID string, iCmd *models.InstanceCommand, err error) {
+// specCreate builds a new instance's image and container. If snapshot is
+// non-nil, the image is created from the snapshot instead, which already
+// contains the results of the post-build actions.
+func (a *App) specCreate(ctx context.Context, assignmentPath string, specData interface{}, imageTag string, containerName string, snapshot *models.Snapshot) (imageID, containerID string, iCmd *models.InstanceCommand, sidecars []*models.Sidecar, err error) {
 	logger := ctxlog.FromContext(ctx)
 
 	out, err := specbuild.Generate(ctx, assignmentPath, specData)
 	if err != nil {
-		return "", "", nil, err
+		return "", "", nil, nil, err
 	}
 
-	switch {
-	case out.ImageName != "":
-		if err = specbuild.TagImage(ctx, a.cli, out.ImageName, imageTag, true); err != nil {
-			return "", "", nil, err
-		}
+	if err := specbuild.CheckServiceNames(out.Services); err != nil {
+		return "", "", nil, nil, err
+	}
 
-		imageID = imageTag
-		a.autoPullMark(out.ImageName)
+	out.Network, err = requestedNetwork(out)
+	if err != nil {
+		return "", "", nil, nil, err
+	}
 
-	case out.Dockerfile != "":
-		contextPath := filepath.Join(assignmentPath, "context")
+	if err := a.checkNetwork(out.Network); err != nil {
+		return "", "", nil, nil, err
+	}
 
-		imageID, err = image.Build(ctx, a.cli, imageTag, out.Dockerfile, contextPath)
-		if err != nil {
-			return "", "", nil, err
+	if snapshot != nil {
+		if err = a.rt.Tag(ctx, snapshot.ImageID, imageTag); err != nil {
+			return "", "", nil, nil, err
 		}
 
-	default:
-		logger.Error("not enough info to build image (image name, dockerfile, etc)")
-		return "", "", nil, errors.New("TODO: no way to build image")
+		imageID = imageTag
+		out.PostBuild = nil
+	} else {
+		imageID, err = a.specBuildImage(ctx, assignmentPath, out, imageTag)
+		if err != nil {
+			return "", "", nil, nil, err
+		}
 	}
 
 	ctx, logger = ctxlog.FromContextWith(ctx,
 		zap.String("image_id", imageID),
 	)
 
-	containerID, iCmd, err = a.specCreateContainer(ctx, assignmentPath, containerName, imageID, out)
+	containerID, iCmd, sidecars, err = a.specCreateContainer(ctx, assignmentPath, containerName, imageID, out, snapshot == nil)
 	if err != nil {
 		logger.Warn("specCreate failed, attempting to remove built image")
 
-		iOpts := types.ImageRemoveOptions{PruneChildren: true}
-
 		// Use another context just in case the old context was cancelled.
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		if _, removeErr := a.cli.ImageRemove(ctx, imageID, iOpts); removeErr != nil {
+		if removeErr := a.rt.RemoveImage(ctx, imageID); removeErr != nil {
 			logger.Warn("failed to remove image",
 				zap.Error(removeErr),
 			)
 		}
 	}
 
-	return imageID, containerID, iCmd, err
+	return imageID, containerID, iCmd, sidecars, err
 }
 
-func (a *App) specCreateContainer(ctx context.Context, assignmentPath string, containerName string, imageID string, gen *specbuild.GenerateOutput) (containerID string, iCmd *models.InstanceCommand, err error) {
-	logger := ctxlog.FromContext(ctx)
+// specBuildImage builds the assignment's image (or tags the image it names)
+// as imageTag, and returns the image's ID.
+func (a *App) specBuildImage(ctx context.Context, assignmentPath string, out *specbuild.GenerateOutput, imageTag string) (string, error) {
+	switch {
+	case out.ImageName != "":
+		if err := specbuild.TagImage(ctx, a.rt, out.ImageName, imageTag, true); err != nil {
+			return "", err
+		}
 
-	containerConfig := &container.Config{
-		Image:     imageID,
-		OpenStdin: true,
-		Cmd:       []string{"/bin/cat"},
-		Labels: map[string]string{
-			"ua.owned": "true",
-		},
+		a.autoPullMark(out.ImageName)
+		return imageTag, nil
+
+	case out.Dockerfile != "":
+		contextPath := filepath.Join(assignmentPath, "context")
+		return a.rt.Build(ctx, imageTag, out.Dockerfile, contextPath)
+
+	default:
+		ctxlog.FromContext(ctx).Error("not enough info to build image (image name, dockerfile, etc)")
+		return "", errors.New("TODO: no way to build image")
 	}
-	hostConfig := &container.HostConfig{
-		Init: gen.Init,
+}
+
+func (a *App) specCreateContainer(ctx context.Context, assignmentPath string, containerName string, imageID string, gen *specbuild.GenerateOutput, poolable bool) (containerID string, iCmd *models.InstanceCommand, sidecars []*models.Sidecar, err error) {
+	logger := ctxlog.FromContext(ctx)
+
+	var pooled bool
+	if poolable {
+		containerID, pooled = a.poolClaim(ctx, gen, containerName)
 	}
 
-	if !a.config.DisableLimits {
-		hostConfig.Resources.CPUShares = 2
-		hostConfig.Resources.Memory = 16 * units.MiB
-		hostConfig.Resources.MemoryReservation = 4 * units.MiB
-		hostConfig.StorageOpt = map[string]string{
-			"size": "500M",
+	if !pooled {
+		containerConfig, hostConfig, err := a.instanceContainerConfig(imageID, gen.Init, gen.Limits)
+		if err != nil {
+			return "", nil, nil, err
 		}
-	}
 
-	c, err := a.cli.ContainerCreate(ctx, containerConfig, hostConfig, nil, containerName)
-	if err != nil {
-		logger.Error("error creating container",
-			zap.Error(err),
-		)
-		return "", nil, err
+		containerID, err = a.rt.Create(ctx, containerName, containerConfig, hostConfig)
+		if err != nil {
+			logger.Error("error creating container",
+				zap.Error(err),
+			)
+			return "", nil, nil, err
+		}
 	}
-	containerID = c.ID
 
 	ctx, logger = ctxlog.FromContextWith(ctx,
 		zap.String("container_id", containerID),
+		zap.Bool("pooled", pooled),
 	)
 
-	if err := a.specCreateContainerSetup(ctx, assignmentPath, containerID, gen); err != nil {
+	// Pooled containers are already running.
+	err = a.specCreateContainerSetup(ctx, assignmentPath, containerName, containerID, gen, !pooled)
+
+	// Services use the instance's isolated network, which shares its name.
+	if err == nil && len(gen.Services) != 0 {
+		sidecars, err = a.createSidecars(ctx, assignmentPath, containerName, containerName, gen.Services)
+	}
+
+	if err != nil {
 		logger.Warn("setup failed, attempting to remove",
 			zap.Error(err),
 		)
 
-		cOpts := types.ContainerRemoveOptions{RemoveVolumes: true}
-
 		// Use another context just in case the old context was cancelled.
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		if kerr := a.cli.ContainerKill(ctx, containerID, "KILL"); kerr != nil {
+		if kerr := a.rt.Kill(ctx, containerID); kerr != nil {
 			logger.Warn("failed to kill container",
 				zap.Error(kerr),
 			)
 		}
 
-		if rerr := a.cli.ContainerRemove(ctx, containerID, cOpts); rerr != nil {
+		if rerr := a.rt.Remove(ctx, containerID, false); rerr != nil {
 			logger.Warn("failed to remove container",
 				zap.Error(rerr),
 			)
 		}
 
-		return "", nil, err
+		if nerr := a.removeIsolatedNetwork(ctx, containerName); nerr != nil {
+			logger.Warn("failed to remove isolated network",
+				zap.Error(nerr),
+			)
+		}
+
+		return "", nil, nil, err
 	}
 
-	iCmd = &models.InstanceCommand{
+	return containerID, instanceCommand(gen), sidecars, nil
+}
+
+// instanceCommand returns the command run for users of an instance.
+func instanceCommand(gen *specbuild.GenerateOutput) *models.InstanceCommand {
+	iCmd := &models.InstanceCommand{
 		User:       gen.User,
 		Cmd:        gen.Cmd,
 		Env:        gen.Env,
@@ -147,47 +181,80 @@ func (a *App) specCreateContainer(ctx context.Context, assignmentPath string, co
 		iCmd.Cmd = append([]string{"/sbin/docker-init", "-s", "--"}, iCmd.Cmd...)
 	}
 
-	return containerID, iCmd, nil
+	return iCmd
 }
 
-func (a *App) specCreateContainerSetup(ctx context.Context, assignmentPath string, containerID string, gen *specbuild.GenerateOutput) error {
-	logger := ctxlog.FromContext(ctx)
+// instanceContainerConfig returns the configuration used to create an
+// instance's container.
+func (a *App) instanceContainerConfig(imageID string, init *bool, limits *specbuild.Limits) (*container.Config, *container.HostConfig, error) {
+	containerConfig := &container.Config{
+		Image:     imageID,
+		OpenStdin: true,
+		Cmd:       []string{"/bin/cat"},
+		Labels: map[string]string{
+			"ua.owned": "true",
+		},
+	}
+	hostConfig := &container.HostConfig{
+		Init: init,
+	}
 
-	if err := a.cli.ContainerStart(ctx, containerID, types.ContainerStartOptions{}); err != nil {
-		return err
+	if err := a.applyLimits(hostConfig, limits); err != nil {
+		return nil, nil, err
 	}
 
-	var gobuildHack func(actions []specbuild.Action)
+	return containerConfig, hostConfig, nil
+}
+
+func (a *App) specCreateContainerSetup(ctx context.Context, assignmentPath string, containerName string, containerID string, gen *specbuild.GenerateOutput, start bool) error {
+	logger := ctxlog.FromContext(ctx)
 
-	gobuildHack = func(actions []specbuild.Action) {
-		for i, ac := range actions {
-			switch ac.Action {
-			case "parallel":
-				gobuildHack(ac.Subactions)
-			case "gobuild":
-				actions[i].SrcPath = filepath.Join(assignmentPath, "gosrc")
-				a.autoPullMark(gobuild.DockerImageName)
-			}
+	if start {
+		if err := a.rt.Start(ctx, containerID); err != nil {
+			return err
 		}
 	}
 
-	gobuildHack(gen.PostBuild)
+	specbuild.WalkActions(gen.PostBuild, func(_ []int, ac *specbuild.Action) {
+		if ac.Action == "gobuild" {
+			ac.SrcPath = filepath.Join(assignmentPath, "gosrc")
+			a.autoPullMark(gobuild.DockerImageName)
+		}
+	})
 
-	if err := specbuild.PerformActions(ctx, a.cli, containerID, gen.PostBuild); err != nil {
+	if err := specbuild.PerformActions(ctx, a.rt, containerID, gen.PostBuild); err != nil {
 		logger.Error("error performing post-build actions, will attempt to cleanup",
 			zap.Error(err),
 		)
 		return err
 	}
 
-	if err := a.cli.NetworkDisconnect(ctx, "bridge", containerID, true); err != nil {
-		logger.Error("error disconnecting network",
-			zap.Error(err),
-		)
+	networkName, err := a.instanceNetwork(ctx, containerName, gen.Network)
+	if err != nil {
 		return err
 	}
 
-	if err := a.cli.ContainerStop(ctx, containerID, nil); err != nil {
+	if networkName != "" && networkName != "bridge" {
+		if err := a.rt.Connect(ctx, networkName, containerID, nil); err != nil {
+			logger.Error("error connecting network",
+				zap.Error(err),
+				zap.String("network", networkName),
+			)
+			return err
+		}
+	}
+
+	// The bridge network is only used during setup, unless requested.
+	if networkName != "bridge" {
+		if err := a.rt.Disconnect(ctx, "bridge", containerID); err != nil {
+			logger.Error("error disconnecting network",
+				zap.Error(err),
+			)
+			return err
+		}
+	}
+
+	if err := a.rt.Stop(ctx, containerID, runtime.DefaultStopTimeout); err != nil {
 		logger.Error("error stopping container",
 			zap.Error(err),
 		)
@@ -0,0 +1,260 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/jakebailey/ua/app/specbuild"
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/docker/dexec"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultGradeTimeout = time.Minute
+	maxGradeTimeout     = 10 * time.Minute
+
+	// maxGradeOutput is the maximum number of bytes of stdout and stderr
+	// (each) kept from a grade command; the rest is discarded.
+	maxGradeOutput = 1 << 20
+
+	// gradeIDEnv is set in a grade command's environment to an ID unique to
+	// the run. Any processes it starts inherit it, so that they can all be
+	// found and killed if the command times out.
+	gradeIDEnv = "UA_GRADE_ID"
+
+	// gradeKillScript kills every process whose environment holds the grade
+	// ID given as its first argument. It repeats until none are left, to
+	// catch processes forked while it was running.
+	gradeKillScript = `for i in 1 2 3 4 5 6 7 8 9 10; do
+	found=
+	for p in /proc/[0-9]*; do
+		if tr '\0' '\n' 2>/dev/null < "$p/environ" | grep -qx "` + gradeIDEnv + `=$1"; then
+			kill -KILL "${p#/proc/}" 2>/dev/null && found=1
+		fi
+	done
+	[ -z "$found" ] && exit 0
+done
+exit 1`
+
+	// gradeKillTimeout limits how long killing a timed out grade command
+	// may take.
+	gradeKillTimeout = 10 * time.Second
+)
+
+type specGradeResponse struct {
+	ExitCode  int             `json:"exitCode"`
+	Stdout    string          `json:"stdout"`
+	Stderr    string          `json:"stderr"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	TimedOut  bool            `json:"timedOut,omitempty"`
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+// specGrade runs the assignment's grade command in the spec's active
+// instance, and returns its output.
+func (a *App) specGrade(w http.ResponseWriter, r *http.Request) {
+	specID := a.specProcessRequest(w, r)
+	if specID.IsEmpty() {
+		return
+	}
+
+	ctx, logger := ctxlog.FromContextWith(r.Context(),
+		zap.String("spec_id", specID.String()),
+	)
+
+	spec, err := a.repo.FindSpec(specID)
+	if err != nil {
+		logger.Error("error querying spec for grade info",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	ctx, logger = ctxlog.FromContextWith(ctx,
+		zap.String("assignment_name", spec.AssignmentName),
+	)
+
+	out, err := specbuild.Generate(ctx, specbuild.AssignmentPath(a.config.AssignmentPath, spec.AssignmentName), spec.Data)
+	if err != nil {
+		if err == specbuild.ErrNoJS {
+			http.Error(w, "assignment has no grade command", http.StatusNotFound)
+			return
+		}
+
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if out.Grade == nil || len(out.Grade.Cmd) == 0 {
+		http.Error(w, "assignment has no grade command", http.StatusNotFound)
+		return
+	}
+
+	instance, err := a.findActiveInstance(ctx, specID)
+	if err != nil {
+		if err == models.ErrNotFound {
+			http.Error(w, "spec has no active instance", http.StatusNotFound)
+			return
+		}
+
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if a.forwardInstance(w, r, instance) {
+		return
+	}
+
+	ctx, logger = ctxlog.FromContextWith(ctx,
+		zap.String("instance_id", instance.ID.String()),
+		zap.String("container_id", instance.ContainerID),
+	)
+
+	defer a.releaseInstance(ctx, instance)
+	if err := a.acquireInstance(ctx, instance); err != nil {
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := a.runGrade(ctx, instance.ContainerID, out.Grade)
+	if err != nil {
+		logger.Error("error running grade command",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	render.JSON(w, r, resp)
+}
+
+func (a *App) runGrade(ctx context.Context, containerID string, grade *specbuild.GradeCommand) (*specGradeResponse, error) {
+	logger := ctxlog.FromContext(ctx)
+
+	timeout := defaultGradeTimeout
+	if grade.Timeout > 0 {
+		timeout = time.Duration(grade.Timeout) * time.Second
+	}
+	if timeout > maxGradeTimeout {
+		timeout = maxGradeTimeout
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: maxGradeOutput}
+	stderr := &limitedBuffer{limit: maxGradeOutput}
+
+	gradeID := models.NewID().String()
+
+	env := make([]string, 0, len(grade.Env)+1)
+	env = append(env, grade.Env...)
+	env = append(env, gradeIDEnv+"="+gradeID)
+
+	ec := dexec.Config{
+		User:       grade.User,
+		Cmd:        grade.Cmd,
+		Env:        env,
+		WorkingDir: grade.WorkingDir,
+		Stdout:     stdout,
+		Stderr:     stderr,
+	}
+
+	before := time.Now()
+	err := dexec.Exec(execCtx, a.rt, containerID, ec)
+	took := time.Since(before)
+
+	resp := &specGradeResponse{}
+
+	switch err := err.(type) {
+	case nil:
+	case dexec.ExitCodeError:
+		resp.ExitCode = int(err)
+	default:
+		if execCtx.Err() != context.DeadlineExceeded {
+			return nil, err
+		}
+		resp.ExitCode = -1
+		resp.TimedOut = true
+
+		// Giving up on the exec leaves its processes running.
+		a.killGrade(ctx, containerID, gradeID)
+	}
+
+	resp.Stdout = stdout.String()
+	resp.Stderr = stderr.String()
+	resp.Truncated = stdout.truncated || stderr.truncated
+
+	if grade.Structured && !resp.TimedOut {
+		if json.Valid([]byte(resp.Stdout)) {
+			resp.Result = json.RawMessage(resp.Stdout)
+		} else {
+			logger.Warn("grade command output is not valid JSON")
+		}
+	}
+
+	logger.Info("grade command finished",
+		zap.Int("exit_code", resp.ExitCode),
+		zap.Bool("timed_out", resp.TimedOut),
+		zap.Duration("took", took),
+	)
+
+	return resp, nil
+}
+
+// killGrade kills the processes of a timed out grade command.
+func (a *App) killGrade(ctx context.Context, containerID string, gradeID string) {
+	logger := ctxlog.FromContext(ctx)
+
+	// The request's context may be done by now.
+	ctx, cancel := context.WithTimeout(context.Background(), gradeKillTimeout)
+	defer cancel()
+
+	ec := dexec.Config{
+		User: "root",
+		Cmd:  []string{"sh", "-c", gradeKillScript, "sh", gradeID},
+	}
+
+	if err := dexec.Exec(ctx, a.rt, containerID, ec); err != nil {
+		logger.Error("error killing timed out grade command",
+			zap.Error(err),
+		)
+	}
+}
+
+// limitedBuffer is a concurrency-safe buffer which stores at most limit
+// bytes, silently discarding the rest.
+type limitedBuffer struct {
+	mu        sync.Mutex
+	buf       []byte
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := len(p)
+
+	if room := b.limit - len(b.buf); n > room {
+		p = p[:room]
+		b.truncated = true
+	}
+
+	b.buf = append(b.buf, p...)
+	return n, nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
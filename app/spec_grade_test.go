@@ -0,0 +1,86 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGradeTimeout(t *testing.T) {
+	ta := newTestApp(t)
+	defer ta.close()
+
+	specID := newSpecID()
+	ta.createInstance(specID, map[string]interface{}{
+		"secret": "hunter2",
+		"grade": map[string]interface{}{
+			"cmd":     []string{"sleep", "60"},
+			"timeout": 1,
+		},
+	})
+
+	// The grade command runs until its processes are killed. It has to be
+	// killed in the container, as giving up on the exec leaves it running.
+	killed := make(chan struct{})
+	var once sync.Once
+
+	ta.docker.ExecFunc = func(containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		switch {
+		case cmd[0] == "sleep":
+			<-killed
+			return 137
+
+		case len(cmd) == 5 && cmd[0] == "sh" && cmd[2] == gradeKillScript:
+			// Only kill the grade command if given its ID.
+			for _, e := range ta.docker.Execs() {
+				if e.Config.Cmd[0] != "sleep" {
+					continue
+				}
+
+				for _, env := range e.Config.Env {
+					if env == gradeIDEnv+"="+cmd[4] {
+						once.Do(func() { close(killed) })
+						return 0
+					}
+				}
+			}
+			return 1
+
+		default:
+			return ta.exec(containerID, cmd, stdin, stdout, stderr)
+		}
+	}
+	defer once.Do(func() { close(killed) })
+
+	code, body := ta.post("/spec/grade", specID, nil)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 grading, got %d: %s", code, body)
+	}
+
+	var resp specGradeResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if !resp.TimedOut {
+		t.Errorf("expected grade command to time out, got %+v", resp)
+	}
+
+	waitFor(t, "grade command to be killed", func() bool {
+		for _, e := range ta.docker.Execs() {
+			if strings.Join(e.Config.Cmd, " ") == "sleep 60" && e.Running {
+				return false
+			}
+		}
+		return true
+	})
+
+	for _, e := range ta.docker.Execs() {
+		if len(e.Config.Cmd) > 2 && e.Config.Cmd[2] == gradeKillScript && e.Config.User != "root" {
+			t.Errorf("expected grade command to be killed as root, got %q", e.Config.User)
+		}
+	}
+}
@@ -2,11 +2,11 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
-	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
-	units "github.com/docker/go-units"
+	"github.com/jakebailey/ua/app/specbuild"
 	"github.com/jakebailey/ua/models"
 	"github.com/jakebailey/ua/pkg/ctxlog"
 	"github.com/jakebailey/ua/pkg/docker/image"
@@ -16,7 +16,15 @@ import (
 func (a *App) specLegacyCreate(ctx context.Context, assignmentPath string, specData interface{}, imageTag string, containerName string) (imageID, containerID string, iCmd *models.InstanceCommand, err error) {
 	logger := ctxlog.FromContext(ctx)
 
-	imageID, err = image.BuildLegacy(ctx, a.cli, assignmentPath, imageTag, specData)
+	dockerfile, contextPath, err := image.RenderLegacy(assignmentPath, specData)
+	if err != nil {
+		logger.Error("error rendering Dockerfile template",
+			zap.Error(err),
+		)
+		return "", "", nil, err
+	}
+
+	imageID, err = a.rt.Build(ctx, imageTag, dockerfile, contextPath)
 	if err != nil {
 		logger.Error("error building image",
 			zap.Error(err),
@@ -34,13 +42,11 @@ func (a *App) specLegacyCreate(ctx context.Context, assignmentPath string, specD
 			zap.Error(err),
 		)
 
-		iOpts := types.ImageRemoveOptions{PruneChildren: true}
-
 		// Use another context just in case the old context was cancelled.
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		if _, removeErr := a.cli.ImageRemove(ctx, imageID, iOpts); err != nil {
+		if removeErr := a.rt.RemoveImage(ctx, imageID); removeErr != nil {
 			logger.Warn("failed to remove image",
 				zap.Error(removeErr),
 			)
@@ -68,27 +74,73 @@ func (a *App) specLegacyCreateContainer(ctx context.Context, imageID string, con
 		NetworkMode: "none",
 	}
 
-	if initCmd, ok := image.GetLabel(ctx, a.cli, imageID, "ua.initCmd"); ok {
+	labels, err := a.rt.ImageLabels(ctx, imageID)
+	if err != nil {
+		logger.Error("error reading image labels",
+			zap.Error(err),
+		)
+		return "", nil, err
+	}
+
+	if initCmd := labels["ua.initCmd"]; initCmd != "" {
 		containerConfig.Cmd = []string{"/sbin/docker-init", "-s", "--", "/bin/sh", "-c", initCmd}
 	}
 
-	if !a.config.DisableLimits {
-		hostConfig.Resources.CPUShares = 2
-		hostConfig.Resources.Memory = 16 * units.MiB
-		hostConfig.Resources.MemoryReservation = 4 * units.MiB
-		hostConfig.StorageOpt = map[string]string{
-			"size": "500M",
+	var limits *specbuild.Limits
+
+	if limitsJSON := labels["ua.limits"]; limitsJSON != "" {
+		if err := json.Unmarshal([]byte(limitsJSON), &limits); err != nil {
+			logger.Error("error decoding ua.limits label",
+				zap.Error(err),
+			)
+			return "", nil, err
 		}
 	}
 
-	c, createErr := a.cli.ContainerCreate(ctx, &containerConfig, &hostConfig, nil, containerName)
+	if err := a.applyLimits(&hostConfig, limits); err != nil {
+		logger.Error("error applying limits",
+			zap.Error(err),
+		)
+		return "", nil, err
+	}
+
+	var network *specbuild.Network
+
+	if networkJSON := labels["ua.network"]; networkJSON != "" {
+		if err := json.Unmarshal([]byte(networkJSON), &network); err != nil {
+			logger.Error("error decoding ua.network label",
+				zap.Error(err),
+			)
+			return "", nil, err
+		}
+	}
+
+	networkName, err := a.instanceNetwork(ctx, containerName, network)
+	if err != nil {
+		logger.Error("error preparing network",
+			zap.Error(err),
+		)
+		return "", nil, err
+	}
+
+	if networkName != "" {
+		hostConfig.NetworkMode = container.NetworkMode(networkName)
+	}
+
+	containerID, createErr := a.rt.Create(ctx, containerName, &containerConfig, &hostConfig)
 	if createErr != nil {
 		logger.Error("error creating container",
 			zap.Error(createErr),
 		)
+
+		if nerr := a.removeIsolatedNetwork(ctx, containerName); nerr != nil {
+			logger.Warn("failed to remove isolated network",
+				zap.Error(nerr),
+			)
+		}
+
 		return "", nil, createErr
 	}
-	containerID = c.ID
 
 	ctx, logger = ctxlog.FromContextWith(ctx,
 		zap.String("container_id", containerID),
@@ -101,18 +153,22 @@ func (a *App) specLegacyCreateContainer(ctx context.Context, imageID string, con
 			zap.Error(err),
 		)
 
-		cOpts := types.ContainerRemoveOptions{RemoveVolumes: true}
-
 		// Use another context just in case the old context was cancelled.
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		if rerr := a.cli.ContainerRemove(ctx, containerID, cOpts); rerr != nil {
+		if rerr := a.rt.Remove(ctx, containerID, false); rerr != nil {
 			logger.Warn("failed to remove container",
 				zap.Error(rerr),
 			)
 		}
 
+		if nerr := a.removeIsolatedNetwork(ctx, containerName); nerr != nil {
+			logger.Warn("failed to remove isolated network",
+				zap.Error(nerr),
+			)
+		}
+
 		return "", nil, err
 	}
 
@@ -120,7 +176,7 @@ func (a *App) specLegacyCreateContainer(ctx context.Context, imageID string, con
 }
 
 func (a *App) specLegacyCreateCmd(ctx context.Context, containerID string) (*models.InstanceCommand, error) {
-	info, err := a.cli.ContainerInspect(ctx, containerID)
+	info, err := a.rt.Inspect(ctx, containerID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,257 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/jakebailey/ua/app/specbuild"
+	"github.com/jakebailey/ua/models"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/runtime"
+	"go.uber.org/zap"
+)
+
+// specReset resets the spec's active instance to a fresh state, by closing
+// its terminal sessions and recreating its container from the assignment's
+// image. The instance keeps its ID (and its sidecars), so clients can simply
+// reconnect.
+func (a *App) specReset(w http.ResponseWriter, r *http.Request) {
+	specID := a.specProcessRequest(w, r)
+	if specID.IsEmpty() {
+		return
+	}
+
+	ctx, logger := ctxlog.FromContextWith(r.Context(),
+		zap.String("spec_id", specID.String()),
+	)
+
+	spec, err := a.repo.FindSpec(specID)
+	if err != nil {
+		logger.Error("error querying spec for build info",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	instance, err := a.findActiveInstance(ctx, specID)
+	if err != nil {
+		if err == models.ErrNotFound {
+			http.Error(w, "spec has no active instance", http.StatusNotFound)
+			return
+		}
+
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if a.forwardInstance(w, r, instance) {
+		return
+	}
+
+	ctx, logger = ctxlog.FromContextWith(ctx,
+		zap.String("assignment_name", spec.AssignmentName),
+		zap.String("instance_id", instance.ID.String()),
+	)
+
+	a.expireSessions(instance.ID.String())
+
+	locked, err := a.withClusterLock(ctx, instance, func(current *models.Instance) error {
+		// The instance may have been expired or cleaned since it was first
+		// looked up.
+		if !current.Active {
+			return models.ErrNotFound
+		}
+
+		return a.lockInstance(current, func() error {
+			return a.resetInstance(ctx, specID, spec, current)
+		})
+	})
+	if err != nil {
+		if err == models.ErrNotFound {
+			http.Error(w, "spec has no active instance", http.StatusNotFound)
+			return
+		}
+
+		logger.Error("error resetting instance",
+			zap.Error(err),
+		)
+		a.httpError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !locked {
+		http.Error(w, "instance is busy on another node", http.StatusConflict)
+		return
+	}
+
+	render.JSON(w, r, &specPostResponse{
+		InstanceID: instance.ID.String(),
+	})
+}
+
+// resetInstance replaces an instance's container with a new one created from
+// the assignment's image, running the post-build actions again. Instances
+// restored from a snapshot have their image rebuilt, so that they are reset
+// to a fresh state rather than to the snapshot. The old container is only
+// removed once the new one is ready; if the reset fails, the instance is left
+// as it was. The instance must be locked.
+func (a *App) resetInstance(ctx context.Context, specID models.ID, spec *models.Spec, instance *models.Instance) error {
+	logger := ctxlog.FromContext(ctx)
+
+	path := specbuild.AssignmentPath(a.config.AssignmentPath, spec.AssignmentName)
+	containerName := "ua-" + instance.ID.String()
+	oldContainerID := instance.ContainerID
+
+	// Move the old container out of the way, so the new container can take
+	// its name.
+	oldExists := true
+	if err := a.rt.Rename(ctx, oldContainerID, containerName+"-old"); err != nil {
+		if !runtime.IsNotFound(err) {
+			logger.Error("error renaming old container",
+				zap.Error(err),
+			)
+			return err
+		}
+		oldExists = false
+	}
+
+	before := time.Now()
+
+	actionLog := &specbuild.ActionLog{}
+
+	containerID, iCmd, err := a.resetCreateContainer(specbuild.WithActionLog(ctx, actionLog), path, spec.Data, containerName, instance.ImageID)
+	if err != specbuild.ErrNoJS {
+		a.insertBuildLog(ctx, specID, instance.ID, spec.AssignmentName, actionLog, err)
+		a.metrics.observeActions(actionLog)
+	}
+	if err == specbuild.ErrNoJS {
+		containerID, iCmd, err = a.specLegacyCreateContainer(ctx, instance.ImageID, containerName)
+	}
+	if err != nil {
+		if !oldExists {
+			a.resetMarkInactive(ctx, instance)
+			return err
+		}
+
+		if rerr := a.rt.Rename(ctx, oldContainerID, containerName); rerr != nil {
+			logger.Error("error restoring old container",
+				zap.Error(rerr),
+			)
+			a.resetMarkInactive(ctx, instance)
+		}
+
+		return err
+	}
+
+	if oldExists {
+		logger.Debug("removing old container",
+			zap.String("container_id", oldContainerID),
+		)
+
+		if err := a.rt.Remove(ctx, oldContainerID, true); err != nil && !runtime.IsNotFound(err) {
+			logger.Warn("error removing old container",
+				zap.Error(err),
+				zap.String("container_id", oldContainerID),
+			)
+		}
+	}
+
+	instance.ContainerID = containerID
+	instance.Command = *iCmd
+
+	if err := a.repo.SetContainer(instance); err != nil {
+		logger.Error("error updating instance container",
+			zap.Error(err),
+		)
+		a.resetMarkInactive(ctx, instance)
+		return err
+	}
+
+	logger.Info("spec instance reset",
+		zap.Duration("took", time.Since(before)),
+		zap.String("container_id", containerID),
+	)
+
+	return nil
+}
+
+// resetMarkInactive marks an instance inactive after a reset left it without
+// a usable container.
+func (a *App) resetMarkInactive(ctx context.Context, instance *models.Instance) {
+	if err := a.repo.MarkInactive(instance); err != nil {
+		ctxlog.FromContext(ctx).Error("error marking instance as inactive in database",
+			zap.Error(err),
+		)
+	}
+}
+
+// resetCreateContainer creates and sets up a new container for an instance
+// from its image, which is first rebuilt if it came from a snapshot. The
+// instance's network is reused, and its sidecars are left alone.
+func (a *App) resetCreateContainer(ctx context.Context, assignmentPath string, specData interface{}, containerName string, imageID string) (string, *models.InstanceCommand, error) {
+	logger := ctxlog.FromContext(ctx)
+
+	gen, err := specbuild.Generate(ctx, assignmentPath, specData)
+	if err != nil {
+		return "", nil, err
+	}
+
+	gen.Network, err = requestedNetwork(gen)
+	if err != nil {
+		return "", nil, err
+	}
+
+	labels, err := a.rt.ImageLabels(ctx, imageID)
+	if err != nil {
+		logger.Error("error reading image labels",
+			zap.Error(err),
+		)
+		return "", nil, err
+	}
+
+	if labels[snapshotLabel] != "" {
+		logger.Debug("rebuilding image restored from snapshot")
+
+		// The instance's image is tagged with the container's name.
+		imageID, err = a.specBuildImage(ctx, assignmentPath, gen, containerName)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	containerConfig, hostConfig, err := a.instanceContainerConfig(imageID, gen.Init, gen.Limits)
+	if err != nil {
+		return "", nil, err
+	}
+
+	containerID, err := a.rt.Create(ctx, containerName, containerConfig, hostConfig)
+	if err != nil {
+		logger.Error("error creating container",
+			zap.Error(err),
+		)
+		return "", nil, err
+	}
+
+	if err := a.specCreateContainerSetup(ctx, assignmentPath, containerName, containerID, gen, true); err != nil {
+		logger.Warn("setup failed, attempting to remove",
+			zap.Error(err),
+		)
+
+		// Use another context just in case the old context was cancelled.
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if rerr := a.rt.Remove(ctx, containerID, true); rerr != nil {
+			logger.Warn("failed to remove container",
+				zap.Error(rerr),
+			)
+		}
+
+		return "", nil, err
+	}
+
+	return containerID, instanceCommand(gen), nil
+}
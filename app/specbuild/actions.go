@@ -6,12 +6,13 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
-	"github.com/docker/docker/client"
 	"github.com/hashicorp/go-gatedio"
 	"github.com/jakebailey/ua/app/gobuild"
 	"github.com/jakebailey/ua/pkg/ctxlog"
 	"github.com/jakebailey/ua/pkg/docker/dexec"
+	"github.com/jakebailey/ua/pkg/runtime"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -41,7 +42,7 @@ type Action struct {
 	Subactions []Action
 }
 
-type actionFunc func(ctx context.Context, cli client.CommonAPIClient, containerID string, ac Action) error
+type actionFunc func(ctx context.Context, rt runtime.Runtime, containerID string, ac Action) error
 
 var actionFuncs = map[string]actionFunc{}
 
@@ -56,21 +57,60 @@ func init() {
 	actionFuncs["ordered"] = actionOrdered
 }
 
-func performAction(ctx context.Context, cli client.CommonAPIClient, containerID string, ac Action) error {
+func performAction(ctx context.Context, rt runtime.Runtime, containerID string, path []int, ac Action) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	ctx = context.WithValue(ctx, actionPathKey{}, path)
+
 	fn, ok := actionFuncs[ac.Action]
 	if !ok {
-		return fmt.Errorf("specbuild: unknown action %v", ac.Action)
+		err := &ActionError{
+			Path:     path,
+			Action:   ac.Action,
+			ExitCode: -1,
+			Err:      fmt.Errorf("specbuild: unknown action %v", ac.Action),
+		}
+		logFromContext(ctx).record(path, ac.Action, 0, err)
+		return err
 	}
-	return fn(ctx, cli, containerID, ac)
+
+	before := time.Now()
+	err := fn(ctx, rt, containerID, ac)
+	took := time.Since(before)
+
+	if err != nil {
+		ae, ok := err.(*ActionError)
+		if !ok {
+			ae = &ActionError{
+				Action:   ac.Action,
+				ExitCode: -1,
+				Err:      err,
+			}
+		}
+
+		// Errors from subactions already have their own path.
+		if ae.Path == nil {
+			ae.Path = path
+		}
+
+		err = ae
+	}
+
+	logFromContext(ctx).record(path, ac.Action, took, err)
+
+	return err
 }
 
 // PerformActions performs the given actions on the specified container.
-func PerformActions(ctx context.Context, cli client.CommonAPIClient, containerID string, actions []Action) error {
-	for _, ac := range actions {
-		if err := performAction(ctx, cli, containerID, ac); err != nil {
+// Failures are returned as an *ActionError.
+func PerformActions(ctx context.Context, rt runtime.Runtime, containerID string, actions []Action) error {
+	return performActions(ctx, rt, containerID, actionPath(ctx), actions)
+}
+
+func performActions(ctx context.Context, rt runtime.Runtime, containerID string, parent []int, actions []Action) error {
+	for i, ac := range actions {
+		if err := performAction(ctx, rt, containerID, subactionPath(parent, i), ac); err != nil {
 			return err
 		}
 	}
@@ -78,7 +118,39 @@ func PerformActions(ctx context.Context, cli client.CommonAPIClient, containerID
 	return nil
 }
 
-func actionExec(ctx context.Context, cli client.CommonAPIClient, containerID string, ac Action) error {
+type actionPathKey struct{}
+
+// actionPath returns the path of the action currently being performed, or
+// nil if called outside of an action.
+func actionPath(ctx context.Context) []int {
+	path, _ := ctx.Value(actionPathKey{}).([]int)
+	return path
+}
+
+func subactionPath(parent []int, i int) []int {
+	path := make([]int, len(parent)+1)
+	copy(path, parent)
+	path[len(parent)] = i
+	return path
+}
+
+// execActionError creates an ActionError for a failed execution.
+func execActionError(ac Action, err error, stdout, stderr fmt.Stringer) *ActionError {
+	exitCode := -1
+	if code, ok := err.(dexec.ExitCodeError); ok {
+		exitCode = int(code)
+	}
+
+	return &ActionError{
+		Action:   ac.Action,
+		ExitCode: exitCode,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Err:      err,
+	}
+}
+
+func actionExec(ctx context.Context, rt runtime.Runtime, containerID string, ac Action) error {
 	logger := ctxlog.FromContext(ctx)
 
 	logger.Debug("exec action",
@@ -104,20 +176,19 @@ func actionExec(ctx context.Context, cli client.CommonAPIClient, containerID str
 		ec.Stdin = strings.NewReader(*ac.Stdin)
 	}
 
-	// TODO: Properly return stdout/stderr in the error value.
-	if err := dexec.Exec(ctx, cli, containerID, ec); err != nil {
+	if err := dexec.Exec(ctx, rt, containerID, ec); err != nil {
 		logger.Warn("actionExec error",
 			zap.Error(err),
 			zap.String("stdout", stdout.String()),
 			zap.String("stderr", stderr.String()),
 		)
-		return err
+		return execActionError(ac, err, stdout, stderr)
 	}
 
 	return nil
 }
 
-func actionWriteAppend(ctx context.Context, cli client.CommonAPIClient, containerID string, ac Action) error {
+func actionWriteAppend(ctx context.Context, rt runtime.Runtime, containerID string, ac Action) error {
 	logger := ctxlog.FromContext(ctx)
 
 	logger.Debug(ac.Action+" action",
@@ -150,20 +221,19 @@ func actionWriteAppend(ctx context.Context, cli client.CommonAPIClient, containe
 		Stderr:     stderr,
 	}
 
-	// TODO: Properly return stdout/stderr in the error value.
-	if err := dexec.Exec(ctx, cli, containerID, ec); err != nil {
+	if err := dexec.Exec(ctx, rt, containerID, ec); err != nil {
 		logger.Warn("actionWriteAppend error",
 			zap.Error(err),
 			zap.String("stdout", stdout.String()),
 			zap.String("stderr", stderr.String()),
 		)
-		return err
+		return execActionError(ac, err, stdout, stderr)
 	}
 
 	return nil
 }
 
-func actionGobuild(ctx context.Context, cli client.CommonAPIClient, containerID string, ac Action) error {
+func actionGobuild(ctx context.Context, rt runtime.Runtime, containerID string, ac Action) error {
 	logger := ctxlog.FromContext(ctx)
 
 	logger.Debug("gobuild action",
@@ -178,7 +248,7 @@ func actionGobuild(ctx context.Context, cli client.CommonAPIClient, containerID
 		LDFlags:  ac.LDFlags,
 	}
 
-	r, err := gobuild.Build(ctx, cli, options)
+	r, err := gobuild.Build(ctx, rt, options)
 	if err != nil {
 		return err
 	}
@@ -195,39 +265,40 @@ func actionGobuild(ctx context.Context, cli client.CommonAPIClient, containerID
 		Stderr:     stderr,
 	}
 
-	// TODO: Properly return stdout/stderr in the error value.
-	if err := dexec.Exec(ctx, cli, containerID, ec); err != nil {
+	if err := dexec.Exec(ctx, rt, containerID, ec); err != nil {
 		logger.Warn("actionGobuild error",
 			zap.Error(err),
 			zap.String("stdout", stdout.String()),
 			zap.String("stderr", stderr.String()),
 		)
-		return err
+		return execActionError(ac, err, stdout, stderr)
 	}
 
 	return nil
 }
 
-func actionParallel(ctx context.Context, cli client.CommonAPIClient, containerID string, ac Action) error {
+func actionParallel(ctx context.Context, rt runtime.Runtime, containerID string, ac Action) error {
 	logger := ctxlog.FromContext(ctx)
 
 	logger.Debug("parallel action",
 		zap.Int("subactions", len(ac.Subactions)),
 	)
 
+	parent := actionPath(ctx)
+
 	g, ctx := errgroup.WithContext(ctx)
 
-	for _, ac := range ac.Subactions {
-		ac := ac
+	for i, ac := range ac.Subactions {
+		i, ac := i, ac
 
 		g.Go(func() error {
-			return performAction(ctx, cli, containerID, ac)
+			return performAction(ctx, rt, containerID, subactionPath(parent, i), ac)
 		})
 	}
 
 	return g.Wait()
 }
 
-func actionOrdered(ctx context.Context, cli client.CommonAPIClient, containerID string, ac Action) error {
-	return PerformActions(ctx, cli, containerID, ac.Subactions)
+func actionOrdered(ctx context.Context, rt runtime.Runtime, containerID string, ac Action) error {
+	return performActions(ctx, rt, containerID, actionPath(ctx), ac.Subactions)
 }
@@ -0,0 +1,39 @@
+package specbuild
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ActionError is returned when an action fails. For actions which run a
+// command in the container, the command's exit code and output are
+// included.
+type ActionError struct {
+	// Path is the index of the failed action in its list of actions. If the
+	// action is a subaction, the indexes of its parent actions come first.
+	Path []int
+
+	Action string
+
+	// ExitCode is the exit code of the action's command, or -1 if the
+	// command did not exit or the action doesn't run a command.
+	ExitCode int
+	Stdout   string
+	Stderr   string
+
+	Err error
+}
+
+func (e *ActionError) Error() string {
+	return fmt.Sprintf("specbuild: action %s (%s) failed: %v", FormatActionPath(e.Path), e.Action, e.Err)
+}
+
+// FormatActionPath formats an action path like "2.0.1".
+func FormatActionPath(path []int) string {
+	s := make([]string, len(path))
+	for i, v := range path {
+		s[i] = strconv.Itoa(v)
+	}
+	return strings.Join(s, ".")
+}
@@ -32,6 +32,30 @@ type GenerateOutput struct {
 	Cmd        []string
 	Env        []string
 	WorkingDir string
+
+	Limits  *Limits
+	Network *Network
+
+	// Services are sidecar services for the instance. Services require an
+	// isolated network, which is used even if not requested.
+	Services []Service
+
+	Grade *GradeCommand
+}
+
+// GradeCommand is a command run inside of an instance's container to grade
+// the work done in it.
+type GradeCommand struct {
+	User       string
+	Cmd        []string
+	Env        []string
+	WorkingDir string
+
+	// Timeout is the maximum time the command may run, in seconds.
+	Timeout int
+
+	// Structured indicates that the command writes a JSON result to stdout.
+	Structured bool
 }
 
 // Generate attempts to run the generate function of the assignment's module
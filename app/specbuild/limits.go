@@ -0,0 +1,118 @@
+package specbuild
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	units "github.com/docker/go-units"
+)
+
+// Limits are the resource limits requested for an instance's container.
+// Unset fields use the server's defaults. All limits are subject to the
+// maximums configured on the server.
+type Limits struct {
+	// Memory is the memory limit, in a human readable form like "256m".
+	Memory string
+	// CPUs is the number of CPUs the container may use, like 0.5.
+	CPUs float64
+	// Pids is the maximum number of processes in the container.
+	Pids int64
+	// DiskSize is the size of the container's filesystem, like "1g".
+	DiskSize string
+
+	Ulimits []Ulimit
+
+	// Tmpfs maps paths in the container to tmpfs mount options (as in
+	// "docker run --tmpfs"), like "size=64m". Tmpfs usage counts against
+	// the memory limit.
+	Tmpfs map[string]string
+}
+
+// TmpfsSize returns the size option of a tmpfs mount's options in bytes,
+// or 0 if unset.
+func TmpfsSize(options string) (int64, error) {
+	for _, option := range strings.Split(options, ",") {
+		if strings.HasPrefix(option, "size=") {
+			return units.RAMInBytes(strings.TrimPrefix(option, "size="))
+		}
+	}
+	return 0, nil
+}
+
+// Ulimit is a ulimit for processes in the container.
+type Ulimit struct {
+	Name string
+	Soft int64
+	Hard int64
+}
+
+// MemoryBytes returns the memory limit in bytes, or 0 if unset.
+func (l *Limits) MemoryBytes() (int64, error) {
+	if l.Memory == "" {
+		return 0, nil
+	}
+	return units.RAMInBytes(l.Memory)
+}
+
+// DiskSizeBytes returns the disk size in bytes, or 0 if unset.
+func (l *Limits) DiskSizeBytes() (int64, error) {
+	if l.DiskSize == "" {
+		return 0, nil
+	}
+	return units.RAMInBytes(l.DiskSize)
+}
+
+// Check returns an error describing any problems with the limits, like
+// negative values.
+func (l *Limits) Check() error {
+	if problems := l.validate(); len(problems) != 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (l *Limits) validate() []string {
+	var problems []string
+
+	if _, err := l.MemoryBytes(); err != nil {
+		problems = append(problems, fmt.Sprintf("invalid limits.memory: %v", err))
+	}
+
+	if _, err := l.DiskSizeBytes(); err != nil {
+		problems = append(problems, fmt.Sprintf("invalid limits.diskSize: %v", err))
+	}
+
+	if l.CPUs < 0 {
+		problems = append(problems, "limits.cpus must not be negative")
+	}
+
+	if l.Pids < 0 {
+		problems = append(problems, "limits.pids must not be negative")
+	}
+
+	for _, u := range l.Ulimits {
+		if u.Name == "" {
+			problems = append(problems, "limits.ulimits entries require a name")
+		}
+
+		if u.Soft < 0 || u.Hard < 0 {
+			problems = append(problems, fmt.Sprintf("ulimit %s must not be negative", u.Name))
+		} else if u.Soft > u.Hard {
+			problems = append(problems, fmt.Sprintf("ulimit %s has soft limit greater than its hard limit", u.Name))
+		}
+	}
+
+	for p, options := range l.Tmpfs {
+		if !path.IsAbs(p) {
+			problems = append(problems, fmt.Sprintf("tmpfs path %q is not absolute", p))
+		}
+
+		if _, err := TmpfsSize(options); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid size for tmpfs %s: %v", p, err))
+		}
+	}
+
+	return problems
+}
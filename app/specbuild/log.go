@@ -0,0 +1,73 @@
+package specbuild
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ActionLog records the result of every action performed with a context
+// given by WithActionLog.
+type ActionLog struct {
+	mu      sync.Mutex
+	entries []ActionLogEntry
+}
+
+// ActionLogEntry is the result of a single action. Output is only recorded
+// for actions which fail.
+type ActionLogEntry struct {
+	Path     []int         `json:"path"`
+	Action   string        `json:"action"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+	ExitCode int           `json:"exitCode,omitempty"`
+	Stdout   string        `json:"stdout,omitempty"`
+	Stderr   string        `json:"stderr,omitempty"`
+}
+
+type actionLogKey struct{}
+
+// WithActionLog returns a context which causes performed actions to be
+// recorded in the given log.
+func WithActionLog(ctx context.Context, log *ActionLog) context.Context {
+	return context.WithValue(ctx, actionLogKey{}, log)
+}
+
+func logFromContext(ctx context.Context) *ActionLog {
+	log, _ := ctx.Value(actionLogKey{}).(*ActionLog)
+	return log
+}
+
+// Entries returns the recorded entries, in the order the actions finished.
+func (l *ActionLog) Entries() []ActionLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]ActionLogEntry(nil), l.entries...)
+}
+
+func (l *ActionLog) record(path []int, action string, took time.Duration, err error) {
+	if l == nil {
+		return
+	}
+
+	entry := ActionLogEntry{
+		Path:     path,
+		Action:   action,
+		Duration: took,
+	}
+
+	if err != nil {
+		entry.Error = err.Error()
+
+		// Failed subactions are logged with their own output.
+		if ae, ok := err.(*ActionError); ok && FormatActionPath(ae.Path) == FormatActionPath(path) {
+			entry.ExitCode = ae.ExitCode
+			entry.Stdout = ae.Stdout
+			entry.Stderr = ae.Stderr
+		}
+	}
+
+	l.mu.Lock()
+	l.entries = append(l.entries, entry)
+	l.mu.Unlock()
+}
@@ -0,0 +1,14 @@
+package specbuild
+
+// Network configures the network an instance's container is attached to
+// once its post-build actions have run. By default, containers have no
+// network access. The server only allows networks in its allowlist.
+type Network struct {
+	// Name is the name of an existing Docker network to stay attached to.
+	Name string
+
+	// Isolated attaches the container to a new internal network of its own,
+	// which has no outside access, but is shared with the instance's sidecar
+	// services.
+	Isolated bool
+}
@@ -0,0 +1,61 @@
+package specbuild
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var serviceNameRegexp = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// Service is a sidecar service container, run alongside the instance's
+// container on its isolated network. The service can be reached from the
+// instance's container using its name as a hostname.
+type Service struct {
+	Name      string
+	ImageName string
+
+	// Cmd, Env, and User override the image's defaults, if set.
+	Cmd  []string
+	Env  []string
+	User string
+
+	Init *bool
+
+	// PostBuild are actions run on the service's container after it is
+	// created, while it still has outside network access.
+	PostBuild []Action
+
+	Limits *Limits
+}
+
+// CheckServiceNames returns an error if any service's name is missing, isn't
+// a valid hostname, or isn't unique. Names are used in container names,
+// image tags, and network aliases, so must be checked before any service is
+// created.
+func CheckServiceNames(services []Service) error {
+	seen := make(map[string]bool, len(services))
+
+	for i, svc := range services {
+		if problem := serviceNameProblem(svc.Name, seen); problem != "" {
+			return fmt.Errorf("service #%d: %s", i, problem)
+		}
+	}
+
+	return nil
+}
+
+// serviceNameProblem describes the problem with a service's name, if any,
+// given the names already seen, and marks the name as seen.
+func serviceNameProblem(name string, seen map[string]bool) string {
+	switch {
+	case name == "":
+		return "name must be set"
+	case !serviceNameRegexp.MatchString(name):
+		return "name must be a valid hostname"
+	case seen[name]:
+		return "name is not unique"
+	}
+
+	seen[name] = true
+	return ""
+}
@@ -0,0 +1,168 @@
+package specbuild
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ValidationError describes a problem with a GenerateOutput.
+type ValidationError struct {
+	// Path is the path of the action with the problem (see ActionError), or
+	// nil if the problem is not with an action.
+	Path    []int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == nil {
+		return e.Message
+	}
+	return "action " + FormatActionPath(e.Path) + ": " + e.Message
+}
+
+// Validate checks that a GenerateOutput describes a buildable instance,
+// returning all problems found.
+func Validate(out *GenerateOutput) []ValidationError {
+	var errs []ValidationError
+
+	report := func(path []int, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{
+			Path:    path,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	switch {
+	case out.ImageName == "" && out.Dockerfile == "":
+		report(nil, "one of imageName or dockerfile must be set")
+	case out.ImageName != "" && out.Dockerfile != "":
+		report(nil, "only one of imageName or dockerfile may be set")
+	}
+
+	if len(out.Cmd) == 0 {
+		report(nil, "cmd must be set")
+	}
+
+	validateActions(out.PostBuild, report)
+
+	if len(out.Services) != 0 && out.Network != nil && out.Network.Name != "" {
+		report(nil, "services cannot be used with network.name")
+	}
+
+	serviceNames := make(map[string]bool, len(out.Services))
+
+	for i, svc := range out.Services {
+		name := svc.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+
+		reportService := func(path []int, format string, args ...interface{}) {
+			msg := fmt.Sprintf(format, args...)
+			if path != nil {
+				msg = "action " + FormatActionPath(path) + ": " + msg
+			}
+			report(nil, "service %s: %s", name, msg)
+		}
+
+		if problem := serviceNameProblem(svc.Name, serviceNames); problem != "" {
+			reportService(nil, "%s", problem)
+		}
+
+		if svc.ImageName == "" {
+			reportService(nil, "imageName must be set")
+		}
+
+		validateActions(svc.PostBuild, reportService)
+
+		if svc.Limits != nil {
+			for _, problem := range svc.Limits.validate() {
+				reportService(nil, "%s", problem)
+			}
+		}
+	}
+
+	if out.Limits != nil {
+		for _, problem := range out.Limits.validate() {
+			report(nil, "%s", problem)
+		}
+	}
+
+	if out.Network != nil && out.Network.Name != "" && out.Network.Isolated {
+		report(nil, "only one of network.name or network.isolated may be set")
+	}
+
+	if out.Grade != nil {
+		if len(out.Grade.Cmd) == 0 {
+			report(nil, "grade.cmd must be set")
+		}
+
+		if out.Grade.Timeout < 0 {
+			report(nil, "grade.timeout must not be negative")
+		}
+	}
+
+	return errs
+}
+
+func validateActions(actions []Action, report func(path []int, format string, args ...interface{})) {
+	WalkActions(actions, func(path []int, ac *Action) {
+		if _, ok := actionFuncs[ac.Action]; !ok {
+			report(path, "unknown action %q", ac.Action)
+			return
+		}
+
+		switch ac.Action {
+		case "exec":
+			if len(ac.Cmd) == 0 {
+				report(path, "exec action requires cmd")
+			}
+
+		case "write", "append":
+			if ac.Filename == "" {
+				report(path, "%s action requires filename", ac.Action)
+			}
+
+			if ac.ContentsBase64 {
+				if _, err := base64.StdEncoding.DecodeString(ac.Contents); err != nil {
+					report(path, "contents is not valid base64: %v", err)
+				}
+			}
+
+		case "gobuild":
+			if len(ac.Packages) == 0 {
+				report(path, "gobuild action requires packages")
+			}
+
+		case "parallel", "ordered":
+			if len(ac.Subactions) == 0 {
+				report(path, "%s action requires subactions", ac.Action)
+			}
+		}
+	})
+}
+
+// WalkActions calls fn for each action and subaction, depth first, along with
+// its path (see ActionError).
+func WalkActions(actions []Action, fn func(path []int, ac *Action)) {
+	walkActions(nil, actions, fn)
+}
+
+func walkActions(parent []int, actions []Action, fn func(path []int, ac *Action)) {
+	for i := range actions {
+		path := subactionPath(parent, i)
+		fn(path, &actions[i])
+		walkActions(path, actions[i].Subactions, fn)
+	}
+}
+
+// AssignmentPath returns the path to an assignment's directory within the
+// assignments directory, where nested assignments are separated by periods
+// in the name.
+func AssignmentPath(assignmentsPath string, name string) string {
+	pathSlice := []string{assignmentsPath}
+	pathSlice = append(pathSlice, strings.Split(name, ".")...)
+	return filepath.Join(pathSlice...)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jakebailey/ua/pkg/simplecrypto"
+	"github.com/jessevdk/go-flags"
+)
+
+type genkeyArgs struct {
+	Size int    `long:"size" short:"s" default:"32" choice:"16" choice:"24" choice:"32" description:"Key size in bytes"`
+	ID   string `long:"id" description:"Key ID (defaults to the current date and a random suffix)"`
+	Raw  bool   `long:"raw" description:"Print only the base64 encoded key, without an ID (for UA_AES_KEY)"`
+}
+
+// genkeyMain runs the genkey subcommand, which prints a new random AES key
+// in the form accepted by --aes-keys, and returns the process's exit code.
+func genkeyMain(argv []string) int {
+	var args genkeyArgs
+
+	parser := flags.NewParser(&args, flags.Default)
+	parser.Name = filepath.Base(os.Args[0]) + " genkey"
+	parser.Usage = "[OPTIONS]"
+
+	if _, err := parser.ParseArgs(argv); err != nil {
+		return 2
+	}
+
+	key := make([]byte, args.Size)
+	if _, err := rand.Read(key); err != nil {
+		fmt.Fprintln(os.Stderr, "error generating key:", err)
+		return 1
+	}
+
+	if args.Raw {
+		fmt.Println(base64.StdEncoding.EncodeToString(key))
+		return 0
+	}
+
+	id := args.ID
+	if id == "" {
+		suffix := make([]byte, 3)
+		if _, err := rand.Read(suffix); err != nil {
+			fmt.Fprintln(os.Stderr, "error generating key ID:", err)
+			return 1
+		}
+
+		id = time.Now().Format("20060102") + "-" + hex.EncodeToString(suffix)
+	}
+
+	fmt.Println(simplecrypto.FormatKey(id, key))
+	return 0
+}
@@ -27,14 +27,18 @@ type flaggedConfig struct {
 	CertFile          string `long:"cert-file" env:"UA_CERT_FILE" description:"Path to HTTPS certificate file"`
 	KeyFile           string `long:"key-file" env:"UA_KEY_FILE" description:"Path to HTTPS key file"`
 
-	Database     string `long:"database" required:"true" env:"UA_DATABASE" description:"PostgreSQL database connection string"`
+	Database     string `long:"database" required:"true" env:"UA_DATABASE" description:"Database connection string (PostgreSQL, or sqlite://path for SQLite)"`
 	MigrateUp    bool   `long:"migrate-up" env:"UA_MIGRATE_UP" description:"Run migrations up after database connection"`
 	MigrateReset bool   `long:"migrate-reset" env:"UA_MIGRATE_RESET" description:"Reset database and run migrations up after database connection"`
 
 	AssignmentPath string `long:"assignment-path" env:"UA_ASSIGNMENT_PATH" description:"Path to assignments directory"`
 	StaticPath     string `long:"static-path" env:"UA_STATIC_PATH" description:"Path to static directory; if not provided embedded assets are used"`
 
-	AESKey string `long:"aes-key" required:"true" env:"UA_AES_KEY" description:"base64 encoded AES key"`
+	AESKey              string   `long:"aes-key" required:"true" env:"UA_AES_KEY" description:"base64 encoded AES key, used for messages without a key ID"`
+	AESKeys             []string `long:"aes-keys" env:"UA_AES_KEYS" env-delim:"," description:"Additional AES keys, as id:key with a base64 encoded key (may be repeated; see the genkey command)"`
+	AESActiveKey        string   `long:"aes-active-key" env:"UA_AES_ACTIVE_KEY" description:"ID of the key in aes-keys used to encrypt messages (aes-key if not set)"`
+	DisableLegacyCrypto bool     `long:"disable-legacy-crypto" env:"UA_DISABLE_LEGACY_CRYPTO" description:"Reject messages encrypted in the legacy AES-CFB format"`
+	ObserverKey         string   `long:"observer-key" env:"UA_OBSERVER_KEY" description:"base64 encoded key for signing observer tokens (observers disabled if not set)"`
 
 	CleanInactiveEvery time.Duration `long:"clean-inactive-every" env:"UA_CLEAN_INACTIVE_EVERY" description:"How often to clean up inactive instances"`
 	CheckExpiredEvery  time.Duration `long:"check-expired-every" env:"UA_CHECK_EXPIRED_EVERY" description:"How often to check for expired instances"`
@@ -42,7 +46,28 @@ type flaggedConfig struct {
 	InstanceExpire     time.Duration `long:"instance-expire" env:"UA_INSTANCE_EXPIRE" description:"Duration to expire instances after"`
 	ForceInactive      bool          `long:"force-inactive" env:"UA_FORCE_INACTIVE" description:"Force all instances to be inactive on startup/shutdown"`
 
-	DisableLimits bool `long:"disable-limits" env:"UA_DISABLE_LIMITS" description:"Disable container limits"`
+	NodeName string `long:"node-name" env:"UA_NODE_NAME" description:"Unique name of this node when running multiple nodes (hostname if not set)"`
+	NodeURL  string `long:"node-url" env:"UA_NODE_URL" description:"URL other nodes use to reach this node, for proxying terminal connections"`
+
+	RecordPath      string        `long:"record-path" env:"UA_RECORD_PATH" description:"Path to store terminal session recordings in (disabled if not set)"`
+	RecordRetention time.Duration `long:"record-retention" env:"UA_RECORD_RETENTION" description:"Duration to keep terminal session recordings"`
+
+	SnapshotInstances bool          `long:"snapshot-instances" env:"UA_SNAPSHOT_INSTANCES" description:"Snapshot expired instances (and those active at shutdown), restoring them in the spec's next instance"`
+	SnapshotRetention time.Duration `long:"snapshot-retention" env:"UA_SNAPSHOT_RETENTION" description:"Duration to keep instance snapshots"`
+
+	DisableLimits bool    `long:"disable-limits" env:"UA_DISABLE_LIMITS" description:"Disable container limits"`
+	MaxMemory     string  `long:"max-memory" env:"UA_MAX_MEMORY" description:"Maximum memory limit assignments may request"`
+	MaxCPUs       float64 `long:"max-cpus" env:"UA_MAX_CPUS" description:"Maximum CPUs assignments may use (unlimited if zero)"`
+	MaxPids       int64   `long:"max-pids" env:"UA_MAX_PIDS" description:"Maximum processes assignments may use (unlimited if zero)"`
+	MaxDiskSize   string  `long:"max-disk-size" env:"UA_MAX_DISK_SIZE" description:"Maximum disk size assignments may request"`
+	MaxTmpfsSize  string  `long:"max-tmpfs-size" env:"UA_MAX_TMPFS_SIZE" description:"Maximum size of each tmpfs mount assignments may request"`
+
+	MaxUlimits []string `long:"max-ulimit" env:"UA_MAX_ULIMITS" env-delim:"," description:"Maximum value of a ulimit assignments may request, as name:max (may be repeated)"`
+
+	AllowedNetworks []string `long:"allowed-network" env:"UA_ALLOWED_NETWORKS" env-delim:"," description:"Docker network assignments may stay attached to, or \"isolated\" to allow per-instance networks (may be repeated)"`
+
+	MaxUploadSize   string `long:"max-upload-size" env:"UA_MAX_UPLOAD_SIZE" description:"Maximum size of file uploads to instances"`
+	MaxDownloadSize string `long:"max-download-size" env:"UA_MAX_DOWNLOAD_SIZE" description:"Maximum size of file downloads from instances"`
 
 	DisableAutoPull bool          `long:"disable-auto-pull" env:"UA_AUTO_PULL" description:"Disable image autopull"`
 	AutoPullEvery   time.Duration `long:"auto-pull-every" env:"UA_AUTO_PULL_EVERY" description:"How often to auto-pull recently used images"`
@@ -50,6 +75,10 @@ type flaggedConfig struct {
 
 	PruneEvery time.Duration `long:"prune-every" env:"UA_PRUNE_EVERY" description:"How often to prune Docker"`
 
+	PoolSize   int           `long:"pool-size" env:"UA_POOL_SIZE" description:"Number of ready containers to keep per recently used image (pooling disabled if zero)"`
+	PoolEvery  time.Duration `long:"pool-every" env:"UA_POOL_EVERY" description:"How often to refill the container pool"`
+	PoolExpiry time.Duration `long:"pool-expiry" env:"UA_POOL_EXPIRY" description:"How recently an image must be used for its containers to be pooled"`
+
 	// TODO: Split this out into DebugRoutes and something like DebugLogging.
 	Debug      bool   `long:"debug" env:"UA_DEBUG" description:"Enables pretty logging and extra debug routes"`
 	PProfToken string `long:"pprof-token" env:"UA_PPROF_TOKEN" description:"Token/password for pprof debug endpoint (disabled if not set unless in debug mode)"`
@@ -70,6 +99,15 @@ func main() {
 		}
 	}
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(validateMain(os.Args[2:]))
+		case "genkey":
+			os.Exit(genkeyMain(os.Args[2:]))
+		}
+	}
+
 	if _, err := flags.Parse(&args); err != nil {
 		// Default flag parser prints messages, so just exit.
 		os.Exit(1)
@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE build_logs (
+	id uuid NOT NULL PRIMARY KEY,
+	created_at timestamptz NOT NULL,
+	spec_id uuid REFERENCES specs(id) ON DELETE CASCADE,
+	instance_id uuid NOT NULL,
+	assignment_name text NOT NULL,
+	success boolean NOT NULL,
+	error text NOT NULL,
+	actions jsonb NOT NULL
+);
+
+CREATE INDEX build_logs_spec_id_idx ON build_logs (spec_id);
+CREATE INDEX build_logs_instance_id_idx ON build_logs (instance_id);
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TABLE sidecars (
+	id uuid NOT NULL PRIMARY KEY,
+	created_at timestamptz NOT NULL,
+	instance_id uuid NOT NULL REFERENCES instances(id) ON DELETE CASCADE,
+	name text NOT NULL,
+	image_id text NOT NULL,
+	container_id text NOT NULL,
+	cleaned boolean NOT NULL
+);
+
+CREATE INDEX sidecars_instance_id_idx ON sidecars (instance_id);
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+CREATE TABLE snapshots (
+	id uuid NOT NULL PRIMARY KEY,
+	created_at timestamptz NOT NULL,
+	spec_id uuid NOT NULL REFERENCES specs(id) ON DELETE CASCADE,
+	instance_id uuid NOT NULL REFERENCES instances(id) ON DELETE CASCADE,
+	image_id text NOT NULL,
+	cleaned boolean NOT NULL
+);
+
+CREATE INDEX snapshots_spec_id_idx ON snapshots (spec_id);
+
+COMMIT;
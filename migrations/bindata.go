@@ -4,6 +4,14 @@
 // 1503788894_initial_schema.up.sql (490B)
 // 1518114782_instance_commands.down.sql (60B)
 // 1518114782_instance_commands.up.sql (74B)
+// 1792130000_build_logs.down.sql (50B)
+// 1792130000_build_logs.up.sql (429B)
+// 1792130100_sidecars.down.sql (48B)
+// 1792130100_sidecars.up.sql (347B)
+// 1792130200_snapshots.down.sql (49B)
+// 1792130200_snapshots.up.sql (355B)
+// 1792130300_instance_nodes.down.sql (148B)
+// 1792130300_instance_nodes.up.sql (220B)
 
 package migrations
 
@@ -72,7 +80,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var __1503788894_initial_schemaDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xc8\xcc\x2b\x2e\x49\xcc\x4b\x4e\x2d\x46\x15\x2e\x2e\x48\x4d\x06\x09\x39\xfb\xfb\xfa\x7a\x86\x58\x73\x01\x02\x00\x00\xff\xff\x91\xfd\x93\x23\x3a\x00\x00\x00")
+var __1503788894_initial_schemaDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x3a\x00\xc5\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x69\x6e\x73\x74\x61\x6e\x63\x65\x73\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x70\x65\x63\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x91\xfd\x93\x23\x3a\x00\x00\x00")
 
 func _1503788894_initial_schemaDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -87,12 +95,12 @@ func _1503788894_initial_schemaDownSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "1503788894_initial_schema.down.sql", size: 58, mode: os.FileMode(0755), modTime: time.Unix(1551032940, 0)}
+	info := bindataFileInfo{name: "1503788894_initial_schema.down.sql", size: 58, mode: os.FileMode(0775), modTime: time.Unix(1574319337, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x50, 0xb2, 0x75, 0x5f, 0xb2, 0xa6, 0x68, 0x88, 0xf3, 0xa1, 0x5d, 0xe6, 0x47, 0xac, 0xe3, 0x3d, 0x91, 0x77, 0x2, 0x70, 0xce, 0xf0, 0xb5, 0xb4, 0x3, 0x32, 0xa0, 0xd4, 0x51, 0x15, 0x12, 0xe}}
 	return a, nil
 }
 
-var __1503788894_initial_schemaUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x90\x41\x4b\xc3\x40\x10\x85\xcf\xd9\x5f\x31\xc7\x16\xf2\x0f\x72\x4a\xcb\x2a\xc1\x24\x95\x35\x1e\x7a\x0a\xd3\xdd\xa1\x8c\xb8\x93\x90\x9d\x48\xf1\xd7\x4b\x50\x8b\x46\xc1\x9b\xd7\xf7\x1e\xf3\xde\x7c\x3b\x7b\x5b\xb5\x85\x31\x7b\x67\xcb\xce\x42\x57\xee\x6a\x0b\x69\x24\x9f\x60\x63\x32\x0e\x30\xcf\x1c\xa0\x3d\x74\xd0\x3e\xd6\x35\xdc\xbb\xaa\x29\xdd\x11\xee\xec\x31\x37\x99\x9f\x08\x95\x42\x8f\x0a\xca\x91\x92\x62\x1c\xf5\xf5\x9a\xce\x4d\x36\x8f\xe1\x8f\x04\xa6\xc4\x67\x89\x24\xda\x0b\x46\x02\xa5\x8b\x7e\xf5\x03\x2a\xc2\x53\x1a\xe4\x74\x55\xcd\xb6\x30\xab\xc5\x2c\x49\x51\x3c\xfd\xd7\xea\x85\x50\xff\xd9\xe3\xec\x8d\x75\xb6\xdd\xdb\x87\x77\x72\x1b\x0e\xdb\xdc\x64\x1c\xf1\x4c\x4b\x68\xfd\x92\x1f\x44\x91\x85\xa6\xdf\x4c\xba\x8c\x3c\x51\x5a\x75\x2f\xa0\xbc\xf2\x0b\xc1\x69\x18\x9e\x09\xe5\xdb\xbd\x45\xa0\xf0\xc3\xfa\xe0\x74\x68\x9a\xaa\x2b\xcc\x5b\x00\x00\x00\xff\xff\x65\x0f\x35\xb8\xea\x01\x00\x00")
+var __1503788894_initial_schemaUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x90\x41\x4b\xc3\x40\x10\x85\xcf\xd9\x5f\x31\xc7\x16\xf2\x0f\x72\x4a\xcb\x2a\xc1\x24\x95\x35\x1e\x7a\x0a\xd3\xdd\xa1\x8c\xb8\x93\x90\x9d\x48\xf1\xd7\x4b\x50\x8b\x46\xc1\x9b\xd7\xf7\x1e\xf3\xde\x7c\x3b\x7b\x5b\xb5\x85\x31\x7b\x67\xcb\xce\x42\x57\xee\x6a\x0b\x69\x24\x9f\x60\x63\x32\x0e\x30\xcf\x1c\xa0\x3d\x74\xd0\x3e\xd6\x35\xdc\xbb\xaa\x29\xdd\x11\xee\xec\x31\x37\x99\x9f\x08\x95\x42\x8f\x0a\xca\x91\x92\x62\x1c\xf5\xf5\x9a\xce\x4d\x36\x8f\xe1\x8f\x04\xa6\xc4\x67\x89\x24\xda\x0b\x46\x02\xa5\x8b\x7e\xf5\x03\x2a\xc2\x53\x1a\xe4\x74\x55\xcd\xb6\x30\xab\xc5\x2c\x49\x51\x3c\xfd\xd7\xea\x85\x50\xff\xd9\xe3\xec\x8d\x75\xb6\xdd\xdb\x87\x77\x72\x1b\x0e\xdb\xdc\x64\x1c\xf1\x4c\x4b\x68\xfd\x92\x1f\x44\x91\x85\xa6\xdf\x4c\xba\x8c\x3c\x51\x5a\x75\x2f\xa0\xbc\xf2\x0b\xc1\x69\x18\x9e\x09\xe5\xdb\xbd\x45\xa0\xf0\xc3\xfa\xe0\x74\x68\x9a\xaa\x2b\xcc\xdb\x00\x65\x0f\x35\xb8\xea\x01\x00\x00")
 
 func _1503788894_initial_schemaUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -107,12 +115,12 @@ func _1503788894_initial_schemaUpSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "1503788894_initial_schema.up.sql", size: 490, mode: os.FileMode(0755), modTime: time.Unix(1551032940, 0)}
+	info := bindataFileInfo{name: "1503788894_initial_schema.up.sql", size: 490, mode: os.FileMode(0775), modTime: time.Unix(1574319337, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x1e, 0x91, 0x47, 0x36, 0xe4, 0xab, 0x4b, 0x9, 0x67, 0x1, 0xbb, 0x89, 0x8c, 0x17, 0x17, 0xa6, 0x81, 0x9c, 0x3c, 0x6e, 0xcc, 0xff, 0xba, 0x94, 0x94, 0x5c, 0x19, 0x8a, 0xb9, 0xc8, 0x36, 0x84}}
 	return a, nil
 }
 
-var __1518114782_instance_commandsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xc8\xcc\x2b\x2e\x49\xcc\x4b\x4e\x2d\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x48\xce\xcf\xcd\x4d\xcc\x4b\xb1\xe6\xe2\x72\xf6\xf7\xf5\xf5\x0c\xb1\xe6\x02\x04\x00\x00\xff\xff\x56\x4d\xe0\xff\x3c\x00\x00\x00")
+var __1518114782_instance_commandsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x3c\x00\xc3\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x69\x6e\x73\x74\x61\x6e\x63\x65\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x63\x6f\x6d\x6d\x61\x6e\x64\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x56\x4d\xe0\xff\x3c\x00\x00\x00")
 
 func _1518114782_instance_commandsDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -127,12 +135,12 @@ func _1518114782_instance_commandsDownSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "1518114782_instance_commands.down.sql", size: 60, mode: os.FileMode(0755), modTime: time.Unix(1551032940, 0)}
+	info := bindataFileInfo{name: "1518114782_instance_commands.down.sql", size: 60, mode: os.FileMode(0775), modTime: time.Unix(1574319337, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x6d, 0x3f, 0x1b, 0x21, 0xc7, 0xe7, 0xe, 0xbc, 0x91, 0xe8, 0x65, 0x46, 0x2d, 0xc6, 0x52, 0x97, 0x64, 0xe6, 0xdc, 0x9e, 0xcf, 0x3c, 0xbc, 0xb5, 0xc3, 0xbd, 0x5e, 0x1d, 0x51, 0xe0, 0x30, 0xbb}}
 	return a, nil
 }
 
-var __1518114782_instance_commandsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x04\xc0\x4b\x0a\x03\x21\x0c\x06\xe0\x7d\x4e\xf1\xdf\xc3\x95\x2f\x8a\x10\x23\x94\x78\x00\x6b\xbb\xe8\x80\x71\xe1\xdc\x9f\xf9\x42\x7e\x15\x71\x44\x9e\x35\xbf\xa1\x3e\x70\xc6\xdf\xce\x3d\x6c\xfe\x0e\x7c\x4a\x88\x8d\x7b\x15\xcc\xbd\xd6\xb0\x2f\xae\xb3\xed\x03\x69\x0a\xe9\xcc\x8e\x28\xb6\x5a\x8b\x3a\x7a\x02\x00\x00\xff\xff\x32\x0d\x19\x74\x4a\x00\x00\x00")
+var __1518114782_instance_commandsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4a\x00\xb5\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x69\x6e\x73\x74\x61\x6e\x63\x65\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x63\x6f\x6d\x6d\x61\x6e\x64\x20\x6a\x73\x6f\x6e\x62\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x32\x0d\x19\x74\x4a\x00\x00\x00")
 
 func _1518114782_instance_commandsUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -147,11 +155,171 @@ func _1518114782_instance_commandsUpSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "1518114782_instance_commands.up.sql", size: 74, mode: os.FileMode(0755), modTime: time.Unix(1551032940, 0)}
+	info := bindataFileInfo{name: "1518114782_instance_commands.up.sql", size: 74, mode: os.FileMode(0775), modTime: time.Unix(1574319337, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x80, 0x1d, 0xa4, 0x12, 0xa0, 0x29, 0x4, 0xee, 0x15, 0xa0, 0x2a, 0x9b, 0xb7, 0x22, 0xe3, 0xc, 0x2a, 0xa2, 0x7d, 0x81, 0x92, 0x43, 0xbb, 0xf0, 0xd4, 0xc5, 0xcb, 0x5e, 0x42, 0x47, 0x2d, 0x52}}
 	return a, nil
 }
 
+var __1792130000_build_logsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x32\x00\xcd\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x62\x75\x69\x6c\x64\x5f\x6c\x6f\x67\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xf6\xf2\xa6\xb0\x32\x00\x00\x00")
+
+func _1792130000_build_logsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130000_build_logsDownSql,
+		"1792130000_build_logs.down.sql",
+	)
+}
+
+func _1792130000_build_logsDownSql() (*asset, error) {
+	bytes, err := _1792130000_build_logsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130000_build_logs.down.sql", size: 50, mode: os.FileMode(0644), modTime: time.Unix(1792190508, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe5, 0x11, 0x21, 0xe3, 0x8f, 0x8c, 0xaf, 0xb1, 0x59, 0x49, 0xb8, 0xba, 0x59, 0x8a, 0xe6, 0x63, 0xe3, 0xd1, 0xee, 0x3, 0x12, 0x7c, 0x2c, 0x82, 0x1a, 0x63, 0x93, 0xc2, 0xb9, 0xb2, 0x7e, 0xd5}}
+	return a, nil
+}
+
+var __1792130000_build_logsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x74\x90\xcb\x6e\xab\x30\x10\x86\xd7\xf8\x29\x66\x19\x24\xde\x80\x15\x81\x39\x47\xa8\x60\x2a\x42\xa5\x66\x65\x19\x7b\x14\xb9\x02\x3b\x62\x8c\x14\xf5\xe9\xab\x54\x69\x4a\x6f\x5b\x7f\x9e\xff\xb6\xc7\xff\xb5\xcc\x85\x28\x7b\x2c\x06\x84\xa1\xd8\x37\x08\xe3\xea\x26\xab\xa6\x70\x62\xd8\x89\xc4\x59\x58\x57\x67\x41\x76\x03\xc8\xa7\xa6\x81\xc7\xbe\x6e\x8b\xfe\x08\x0f\x78\xcc\x44\x62\x16\xd2\x91\xac\xd2\x11\xa2\x9b\x89\xa3\x9e\xcf\xf1\xf5\xfe\x3b\x13\x09\x9f\xc9\xa8\x0f\x95\x1e\xff\x61\x8f\xb2\xc4\x03\x5c\xdf\x79\xe7\x6c\x0a\x9d\x84\x0a\x1b\x1c\x10\xca\xe2\x50\x16\x15\x66\x22\x71\x9e\xa3\xf6\x86\xd4\x77\xff\x4c\x24\x9a\xd9\x9d\xfc\x4c\x3e\x2a\xaf\x67\x82\x48\x97\xf8\xc5\x71\x35\x86\x98\x61\x0c\x61\x22\xed\xb7\x88\x96\x25\x2c\x3f\x0e\xb4\x89\x2e\x78\x86\x17\x0e\x7e\xbc\x03\x91\x7e\x2e\x53\xcb\x0a\x9f\x37\xcb\xa8\x5b\x2b\xe5\xec\xe5\x9a\x7f\xbb\xd9\x0d\xa5\xf9\x9f\xc7\x9b\x72\xbf\x09\x6c\xf0\x7b\x84\xae\x6d\xeb\x21\x17\x6f\x03\x00\x11\xaf\x3f\xf3\xad\x01\x00\x00")
+
+func _1792130000_build_logsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130000_build_logsUpSql,
+		"1792130000_build_logs.up.sql",
+	)
+}
+
+func _1792130000_build_logsUpSql() (*asset, error) {
+	bytes, err := _1792130000_build_logsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130000_build_logs.up.sql", size: 429, mode: os.FileMode(0644), modTime: time.Unix(1792190508, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xc8, 0x57, 0xc9, 0x21, 0x27, 0xad, 0xed, 0xe2, 0xa7, 0x80, 0xf7, 0xd7, 0xc, 0xd, 0xf, 0x55, 0x8c, 0x7, 0xf7, 0xd2, 0x62, 0x9c, 0x38, 0x46, 0x0, 0x78, 0x26, 0xd6, 0xa7, 0xe7, 0x71, 0xb7}}
+	return a, nil
+}
+
+var __1792130100_sidecarsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x30\x00\xcf\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x73\x69\x64\x65\x63\x61\x72\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xa6\x49\x20\x7a\x30\x00\x00\x00")
+
+func _1792130100_sidecarsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130100_sidecarsDownSql,
+		"1792130100_sidecars.down.sql",
+	)
+}
+
+func _1792130100_sidecarsDownSql() (*asset, error) {
+	bytes, err := _1792130100_sidecarsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130100_sidecars.down.sql", size: 48, mode: os.FileMode(0644), modTime: time.Unix(1792190904, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x39, 0x9d, 0xd0, 0x91, 0x10, 0xb7, 0xd4, 0x1b, 0xe5, 0x6, 0xc5, 0x75, 0xd, 0xa7, 0x45, 0x4b, 0x10, 0x35, 0x19, 0xe6, 0x7b, 0xd6, 0xc8, 0xe3, 0xbb, 0xee, 0x15, 0xac, 0xcb, 0x8f, 0x9c, 0xef}}
+	return a, nil
+}
+
+var __1792130100_sidecarsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x90\x41\x6a\xc3\x30\x10\x45\xd7\xd6\x29\x66\x99\x80\x6f\x90\x95\x62\x4f\x8b\xa9\x2d\x17\x45\x85\x66\x65\xa6\xd6\x50\x04\x95\x5c\xac\x09\x84\x9e\xbe\x24\x50\xc7\x6d\xb3\x13\xbc\xcf\x43\xf3\xf6\xf8\xd8\x98\x9d\x52\x95\x45\xed\x10\x9c\xde\xb7\x08\x39\x78\x1e\x69\xce\xb0\x51\x45\xf0\x70\x3a\x05\x0f\xa6\x77\x60\x5e\xda\x16\x9e\x6d\xd3\x69\x7b\x84\x27\x3c\x96\xaa\x18\x67\x26\x61\x3f\x90\x80\x84\xc8\x59\x28\x7e\xca\xd7\xb2\x2e\x55\x11\x52\x16\x4a\x23\x0f\xff\x4c\x16\x1f\xd0\xa2\xa9\xf0\x00\x3f\xa3\xbc\x09\x7e\x0b\xbd\x81\x1a\x5b\x74\x08\x95\x3e\x54\xba\xc6\x52\x15\x89\x22\x83\xf0\x59\x7e\xb9\x23\xbd\x5f\xc5\x7f\xc1\x38\x25\xa1\x90\x78\xbe\x0b\x3f\x98\x12\x7b\x78\x9b\xa6\xcb\x6b\x41\x6a\x7b\x0b\xd1\x98\x1a\x5f\x97\x10\xc3\xea\x88\x21\xf8\xf3\xe5\x87\xb7\x48\x2b\x78\x35\xf4\x5d\xd7\xb8\x9d\xfa\x1e\x00\x27\x85\xd8\x1c\x5b\x01\x00\x00")
+
+func _1792130100_sidecarsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130100_sidecarsUpSql,
+		"1792130100_sidecars.up.sql",
+	)
+}
+
+func _1792130100_sidecarsUpSql() (*asset, error) {
+	bytes, err := _1792130100_sidecarsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130100_sidecars.up.sql", size: 347, mode: os.FileMode(0644), modTime: time.Unix(1792190904, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x8, 0x93, 0x1d, 0x8a, 0x6f, 0x57, 0xef, 0xb7, 0xf6, 0x79, 0x2e, 0xd0, 0x73, 0x1c, 0x1f, 0x67, 0x68, 0x7e, 0x5a, 0xb2, 0x81, 0x59, 0x2a, 0xae, 0x10, 0xcb, 0x41, 0xf3, 0x23, 0x29, 0x16, 0xae}}
+	return a, nil
+}
+
+var __1792130200_snapshotsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x31\x00\xce\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x73\x6e\x61\x70\x73\x68\x6f\x74\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x01\x9a\xe2\xc3\x31\x00\x00\x00")
+
+func _1792130200_snapshotsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130200_snapshotsDownSql,
+		"1792130200_snapshots.down.sql",
+	)
+}
+
+func _1792130200_snapshotsDownSql() (*asset, error) {
+	bytes, err := _1792130200_snapshotsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130200_snapshots.down.sql", size: 49, mode: os.FileMode(0644), modTime: time.Unix(1792191225, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xc3, 0xac, 0x76, 0x40, 0x2a, 0xa0, 0xb, 0x17, 0x6e, 0xa9, 0xd2, 0xd0, 0x49, 0xd9, 0xd9, 0x17, 0xb3, 0x38, 0x49, 0x6b, 0xde, 0x4c, 0xaa, 0x3f, 0x7f, 0x1d, 0x54, 0x68, 0xb8, 0x6d, 0xa1, 0x4a}}
+	return a, nil
+}
+
+var __1792130200_snapshotsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x90\x41\x6a\xc3\x30\x10\x45\xd7\x9e\x53\xcc\x32\x01\xdf\x20\x2b\xc5\x9e\x16\x53\x5b\x2e\x8a\x0a\xcd\xca\xa8\xd6\xd0\x0a\x6a\xd9\x54\x13\x08\x3d\x7d\x51\x48\x9d\x42\x21\x3b\xc1\xff\x7a\xcc\x7f\x7b\x7a\x6c\xf4\x0e\xa0\x32\xa4\x2c\xa1\x55\xfb\x96\x30\x45\xb7\xa4\x8f\x59\x12\x6e\xa0\x08\x1e\x4f\xa7\xe0\x51\xf7\x16\xf5\x4b\xdb\xe2\xb3\x69\x3a\x65\x8e\xf8\x44\xc7\x12\x8a\xf1\x8b\x9d\xb0\x1f\x9c\xa0\x84\x89\x93\xb8\x69\x91\xef\xb5\x5d\x42\x91\x16\x1e\x87\x7f\x14\x43\x0f\x64\x48\x57\x74\xc0\x5c\x48\x9b\xe0\xb7\xd8\x6b\xac\xa9\x25\x4b\x58\xa9\x43\xa5\x6a\x2a\xa1\x08\x31\x89\x8b\x23\xdf\x45\xfc\x96\xee\x60\x26\xf7\x7e\x61\x08\x9f\x65\x65\xe4\x01\x9f\xec\x22\x7b\x7c\x9b\xe7\xfc\x5a\x23\xd8\xde\xb4\x34\xba\xa6\xd7\x9b\x96\xe1\x3a\x69\x08\xfe\x9c\x6f\xfe\xe3\xeb\x9a\x5c\xfe\xf6\x5d\xd7\xd8\x1d\xfc\x0c\x00\x9b\xd3\xa7\x21\x63\x01\x00\x00")
+
+func _1792130200_snapshotsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130200_snapshotsUpSql,
+		"1792130200_snapshots.up.sql",
+	)
+}
+
+func _1792130200_snapshotsUpSql() (*asset, error) {
+	bytes, err := _1792130200_snapshotsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130200_snapshots.up.sql", size: 355, mode: os.FileMode(0644), modTime: time.Unix(1792191225, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xc6, 0xeb, 0x15, 0xa3, 0x67, 0xfd, 0x70, 0x63, 0x62, 0x92, 0xa8, 0x6e, 0xb0, 0x2a, 0xb5, 0x7c, 0x19, 0x79, 0xc7, 0x5b, 0xd, 0xa9, 0xe5, 0xa5, 0x3e, 0xaa, 0xd2, 0x14, 0x5f, 0x45, 0x65, 0x79}}
+	return a, nil
+}
+
+var __1792130300_instance_nodesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xc8\xcc\x2b\x2e\x49\xcc\x4b\x4e\x2d\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x48\xc9\x4f\xce\x4e\x2d\x8a\xcf\xc8\x2f\x2e\xb1\x26\x42\x79\x5e\x7e\x4a\x6a\x7c\x69\x51\x0e\xb1\x6a\xad\xb9\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x03\x00\x4c\xb2\x18\x96\x94\x00\x00\x00")
+
+func _1792130300_instance_nodesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130300_instance_nodesDownSql,
+		"1792130300_instance_nodes.down.sql",
+	)
+}
+
+func _1792130300_instance_nodesDownSql() (*asset, error) {
+	bytes, err := _1792130300_instance_nodesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130300_instance_nodes.down.sql", size: 148, mode: os.FileMode(0644), modTime: time.Unix(1792193842, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x49, 0xd8, 0xd0, 0xcc, 0x2a, 0xec, 0x74, 0x5f, 0x8f, 0xa6, 0x6f, 0xa8, 0xd1, 0x81, 0xef, 0x13, 0xa7, 0x12, 0x4e, 0xc9, 0xa8, 0xc, 0x81, 0xc9, 0xa1, 0x6c, 0x92, 0xbc, 0x82, 0x1e, 0x2f, 0x3c}}
+	return a, nil
+}
+
+var __1792130300_instance_nodesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xc8\xcc\x2b\x2e\x49\xcc\x4b\x4e\x2d\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\xc8\xcb\x4f\x49\x55\x28\x49\xad\x28\x51\xf0\xf3\x0f\x51\xf0\x0b\xf5\xf1\x51\x70\x71\x75\x73\x0c\xf5\x09\x51\x50\x57\xb7\x26\x4e\x7f\x7c\x69\x51\x0e\x25\x66\xa4\xe4\x27\x67\xa7\x16\xc5\x67\xe4\x17\x97\xe0\x31\x86\xcb\xd9\xdf\xd7\xd7\x33\xc4\x9a\x0b\x30\x00\xf9\xf5\xc2\x45\xdc\x00\x00\x00")
+
+func _1792130300_instance_nodesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130300_instance_nodesUpSql,
+		"1792130300_instance_nodes.up.sql",
+	)
+}
+
+func _1792130300_instance_nodesUpSql() (*asset, error) {
+	bytes, err := _1792130300_instance_nodesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130300_instance_nodes.up.sql", size: 220, mode: os.FileMode(0644), modTime: time.Unix(1792193842, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x75, 0x28, 0x42, 0xd2, 0x53, 0x7c, 0xb1, 0xa0, 0xa9, 0xdf, 0x53, 0x7e, 0xf, 0xfc, 0xff, 0x98, 0xa4, 0x61, 0x83, 0x1c, 0xbd, 0x93, 0x77, 0x51, 0xcc, 0x3b, 0x8e, 0x53, 0x46, 0x51, 0x8e, 0xc2}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -247,6 +415,14 @@ var _bindata = map[string]func() (*asset, error){
 	"1503788894_initial_schema.up.sql":      _1503788894_initial_schemaUpSql,
 	"1518114782_instance_commands.down.sql": _1518114782_instance_commandsDownSql,
 	"1518114782_instance_commands.up.sql":   _1518114782_instance_commandsUpSql,
+	"1792130000_build_logs.down.sql":        _1792130000_build_logsDownSql,
+	"1792130000_build_logs.up.sql":          _1792130000_build_logsUpSql,
+	"1792130100_sidecars.down.sql":          _1792130100_sidecarsDownSql,
+	"1792130100_sidecars.up.sql":            _1792130100_sidecarsUpSql,
+	"1792130200_snapshots.down.sql":         _1792130200_snapshotsDownSql,
+	"1792130200_snapshots.up.sql":           _1792130200_snapshotsUpSql,
+	"1792130300_instance_nodes.down.sql":    _1792130300_instance_nodesDownSql,
+	"1792130300_instance_nodes.up.sql":      _1792130300_instance_nodesUpSql,
 }
 
 // AssetDir returns the file names below a certain
@@ -294,6 +470,14 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"1503788894_initial_schema.up.sql":      &bintree{_1503788894_initial_schemaUpSql, map[string]*bintree{}},
 	"1518114782_instance_commands.down.sql": &bintree{_1518114782_instance_commandsDownSql, map[string]*bintree{}},
 	"1518114782_instance_commands.up.sql":   &bintree{_1518114782_instance_commandsUpSql, map[string]*bintree{}},
+	"1792130000_build_logs.down.sql":        &bintree{_1792130000_build_logsDownSql, map[string]*bintree{}},
+	"1792130000_build_logs.up.sql":          &bintree{_1792130000_build_logsUpSql, map[string]*bintree{}},
+	"1792130100_sidecars.down.sql":          &bintree{_1792130100_sidecarsDownSql, map[string]*bintree{}},
+	"1792130100_sidecars.up.sql":            &bintree{_1792130100_sidecarsUpSql, map[string]*bintree{}},
+	"1792130200_snapshots.down.sql":         &bintree{_1792130200_snapshotsDownSql, map[string]*bintree{}},
+	"1792130200_snapshots.up.sql":           &bintree{_1792130200_snapshotsUpSql, map[string]*bintree{}},
+	"1792130300_instance_nodes.down.sql":    &bintree{_1792130300_instance_nodesDownSql, map[string]*bintree{}},
+	"1792130300_instance_nodes.up.sql":      &bintree{_1792130300_instance_nodesUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
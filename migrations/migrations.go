@@ -2,17 +2,22 @@ package migrations
 
 import (
 	"database/sql"
+	"fmt"
 
+	"github.com/jakebailey/ua/migrations/sqlite"
 	"github.com/mattes/migrate"
+	"github.com/mattes/migrate/database"
 	"github.com/mattes/migrate/database/postgres"
 	"github.com/mattes/migrate/source/go-bindata"
 )
 
 //go:generate go-bindata -pkg migrations -ignore=\.(go|json|md)$ .
 
-// Up brings the database up to date to the latest migration.
-func Up(db *sql.DB) error {
-	m, err := newMigrate(db)
+// Up brings the database up to date to the latest migration. The driver
+// is the name of the database/sql driver used to open the database, either
+// "postgres" or "sqlite3".
+func Up(db *sql.DB, driver string) error {
+	m, err := newMigrate(db, driver)
 	if err != nil {
 		return err
 	}
@@ -21,8 +26,8 @@ func Up(db *sql.DB) error {
 }
 
 // Down brings the database down by applying the down migrations.
-func Down(db *sql.DB) error {
-	m, err := newMigrate(db)
+func Down(db *sql.DB, driver string) error {
+	m, err := newMigrate(db, driver)
 	if err != nil {
 		return err
 	}
@@ -31,8 +36,8 @@ func Down(db *sql.DB) error {
 }
 
 // Reset resets the database by bringing the database down and up again.
-func Reset(db *sql.DB) error {
-	m, err := newMigrate(db)
+func Reset(db *sql.DB, driver string) error {
+	m, err := newMigrate(db, driver)
 	if err != nil {
 		return err
 	}
@@ -43,19 +48,32 @@ func Reset(db *sql.DB) error {
 	return ignoreNoChange(m.Up())
 }
 
-func newMigrate(db *sql.DB) (*migrate.Migrate, error) {
-	resource := bindata.Resource(AssetNames(), Asset)
-	source, err := bindata.WithInstance(resource)
+func newMigrate(db *sql.DB, driver string) (*migrate.Migrate, error) {
+	var resource *bindata.AssetSource
+	var dbDriver database.Driver
+	var err error
+
+	switch driver {
+	case "postgres":
+		resource = bindata.Resource(AssetNames(), Asset)
+		dbDriver, err = postgres.WithInstance(db, &postgres.Config{})
+	case "sqlite3":
+		resource = bindata.Resource(sqlite.AssetNames(), sqlite.Asset)
+		dbDriver, err = newSQLiteDriver(db)
+	default:
+		return nil, fmt.Errorf("migrations: no migrations for driver %q", driver)
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	source, err := bindata.WithInstance(resource)
 	if err != nil {
 		return nil, err
 	}
 
-	return migrate.NewWithInstance("go-bindata", source, "postgres", driver)
+	return migrate.NewWithInstance("go-bindata", source, driver, dbDriver)
 }
 
 func ignoreNoChange(err error) error {
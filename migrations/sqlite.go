@@ -0,0 +1,151 @@
+package migrations
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/mattes/migrate/database"
+)
+
+// sqliteMigrationsTable is the table migrate uses to track the version of a
+// SQLite database, named as in the PostgreSQL driver.
+const sqliteMigrationsTable = "schema_migrations"
+
+// sqliteDriver is a migrate database driver for SQLite, which migrate doesn't
+// provide (at least, in the version used here). It only supports existing
+// database connections.
+type sqliteDriver struct {
+	db *sql.DB
+}
+
+var _ database.Driver = (*sqliteDriver)(nil)
+
+func newSQLiteDriver(db *sql.DB) (*sqliteDriver, error) {
+	if err := db.Ping(); err != nil {
+		return nil, err
+	}
+
+	d := &sqliteDriver{db: db}
+
+	if err := d.ensureVersionTable(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (d *sqliteDriver) Open(url string) (database.Driver, error) {
+	return nil, errors.New("migrations: the sqlite driver can only be used with an existing database")
+}
+
+// Close does nothing, as the database belongs to the caller.
+func (d *sqliteDriver) Close() error {
+	return nil
+}
+
+// Lock does nothing. SQLite has no advisory locks, and SQLite databases are
+// only used by a single server.
+func (d *sqliteDriver) Lock() error {
+	return nil
+}
+
+func (d *sqliteDriver) Unlock() error {
+	return nil
+}
+
+func (d *sqliteDriver) Run(migration io.Reader) error {
+	query, err := ioutil.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+
+	if _, err := d.db.Exec(string(query)); err != nil {
+		return database.Error{OrigErr: err, Err: "migration failed", Query: query}
+	}
+
+	return nil
+}
+
+func (d *sqliteDriver) SetVersion(version int, dirty bool) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction start failed"}
+	}
+
+	query := `DELETE FROM ` + sqliteMigrationsTable
+	if _, err := tx.Exec(query); err != nil {
+		_ = tx.Rollback()
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+
+	if version >= 0 {
+		query = `INSERT INTO ` + sqliteMigrationsTable + ` (version, dirty) VALUES ($1, $2)`
+		if _, err := tx.Exec(query, version, dirty); err != nil {
+			_ = tx.Rollback()
+			return &database.Error{OrigErr: err, Query: []byte(query)}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return &database.Error{OrigErr: err, Err: "transaction commit failed"}
+	}
+
+	return nil
+}
+
+func (d *sqliteDriver) Version() (version int, dirty bool, err error) {
+	query := `SELECT version, dirty FROM ` + sqliteMigrationsTable + ` LIMIT 1`
+	err = d.db.QueryRow(query).Scan(&version, &dirty)
+	switch {
+	case err == sql.ErrNoRows:
+		return database.NilVersion, false, nil
+	case err != nil:
+		return 0, false, &database.Error{OrigErr: err, Query: []byte(query)}
+	default:
+		return version, dirty, nil
+	}
+}
+
+// Drop drops every table. Tables are dropped newest first, so that tables
+// are dropped before the tables they reference.
+func (d *sqliteDriver) Drop() error {
+	query := `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY rowid DESC`
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			rows.Close()
+			return err
+		}
+		tables = append(tables, table)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		query = `DROP TABLE IF EXISTS "` + table + `"`
+		if _, err := d.db.Exec(query); err != nil {
+			return &database.Error{OrigErr: err, Query: []byte(query)}
+		}
+	}
+
+	return d.ensureVersionTable()
+}
+
+func (d *sqliteDriver) ensureVersionTable() error {
+	query := `CREATE TABLE IF NOT EXISTS ` + sqliteMigrationsTable + ` (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)`
+	if _, err := d.db.Exec(query); err != nil {
+		return &database.Error{OrigErr: err, Query: []byte(query)}
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE instances;
+
+DROP TABLE specs;
+
+COMMIT;
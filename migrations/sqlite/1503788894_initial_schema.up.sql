@@ -0,0 +1,25 @@
+BEGIN;
+
+CREATE TABLE specs (
+	id text NOT NULL PRIMARY KEY,
+	created_at timestamp NOT NULL,
+	updated_at timestamp NOT NULL,
+	assignment_name text NOT NULL,
+	data text NOT NULL
+);
+
+
+CREATE TABLE instances (
+	id text NOT NULL PRIMARY KEY,
+	created_at timestamp NOT NULL,
+	updated_at timestamp NOT NULL,
+	spec_id text REFERENCES specs(id),
+	image_id text NOT NULL,
+	container_id text NOT NULL,
+	expires_at timestamp,
+	active boolean NOT NULL,
+	cleaned boolean NOT NULL
+);
+
+
+COMMIT;
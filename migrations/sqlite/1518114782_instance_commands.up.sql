@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE instances ADD COLUMN command text NOT NULL DEFAULT '{}';
+
+COMMIT;
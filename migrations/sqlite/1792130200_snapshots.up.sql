@@ -0,0 +1,14 @@
+BEGIN;
+
+CREATE TABLE snapshots (
+	id text NOT NULL PRIMARY KEY,
+	created_at timestamp NOT NULL,
+	spec_id text NOT NULL REFERENCES specs(id) ON DELETE CASCADE,
+	instance_id text NOT NULL REFERENCES instances(id) ON DELETE CASCADE,
+	image_id text NOT NULL,
+	cleaned boolean NOT NULL
+);
+
+CREATE INDEX snapshots_spec_id_idx ON snapshots (spec_id);
+
+COMMIT;
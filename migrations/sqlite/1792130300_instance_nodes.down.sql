@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE instances DROP COLUMN docker_host;
+ALTER TABLE instances DROP COLUMN node_url;
+ALTER TABLE instances DROP COLUMN node;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE instances ADD COLUMN node text NOT NULL DEFAULT '';
+ALTER TABLE instances ADD COLUMN node_url text NOT NULL DEFAULT '';
+ALTER TABLE instances ADD COLUMN docker_host text NOT NULL DEFAULT '';
+
+COMMIT;
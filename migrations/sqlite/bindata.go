@@ -0,0 +1,524 @@
+// Code generated by go-bindata. DO NOT EDIT.
+// sources:
+// 1503788894_initial_schema.down.sql (58B)
+// 1503788894_initial_schema.up.sql (479B)
+// 1518114782_instance_commands.down.sql (60B)
+// 1518114782_instance_commands.up.sql (86B)
+// 1792130000_build_logs.down.sql (50B)
+// 1792130000_build_logs.up.sql (426B)
+// 1792130100_sidecars.down.sql (48B)
+// 1792130100_sidecars.up.sql (345B)
+// 1792130200_snapshots.down.sql (49B)
+// 1792130200_snapshots.up.sql (353B)
+// 1792130300_instance_nodes.down.sql (148B)
+// 1792130300_instance_nodes.up.sql (220B)
+
+package sqlite
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func bindataRead(data []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewBuffer(data))
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, gz)
+	clErr := gz.Close()
+
+	if err != nil {
+		return nil, fmt.Errorf("read %q: %v", name, err)
+	}
+	if clErr != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type asset struct {
+	bytes  []byte
+	info   os.FileInfo
+	digest [sha256.Size]byte
+}
+
+type bindataFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (fi bindataFileInfo) Name() string {
+	return fi.name
+}
+func (fi bindataFileInfo) Size() int64 {
+	return fi.size
+}
+func (fi bindataFileInfo) Mode() os.FileMode {
+	return fi.mode
+}
+func (fi bindataFileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+func (fi bindataFileInfo) IsDir() bool {
+	return false
+}
+func (fi bindataFileInfo) Sys() interface{} {
+	return nil
+}
+
+var __1503788894_initial_schemaDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x3a\x00\xc5\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x69\x6e\x73\x74\x61\x6e\x63\x65\x73\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x70\x65\x63\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x91\xfd\x93\x23\x3a\x00\x00\x00")
+
+func _1503788894_initial_schemaDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1503788894_initial_schemaDownSql,
+		"1503788894_initial_schema.down.sql",
+	)
+}
+
+func _1503788894_initial_schemaDownSql() (*asset, error) {
+	bytes, err := _1503788894_initial_schemaDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1503788894_initial_schema.down.sql", size: 58, mode: os.FileMode(0644), modTime: time.Unix(1792193289, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x50, 0xb2, 0x75, 0x5f, 0xb2, 0xa6, 0x68, 0x88, 0xf3, 0xa1, 0x5d, 0xe6, 0x47, 0xac, 0xe3, 0x3d, 0x91, 0x77, 0x2, 0x70, 0xce, 0xf0, 0xb5, 0xb4, 0x3, 0x32, 0xa0, 0xd4, 0x51, 0x15, 0x12, 0xe}}
+	return a, nil
+}
+
+var __1503788894_initial_schemaUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xbc\x90\xcf\x4a\xc3\x40\x10\xc6\xcf\x3b\x4f\x31\xc7\x16\xf2\x06\x39\xa5\x65\x95\x60\x92\xca\x1a\x0f\x3d\x85\x71\x77\x28\x03\xee\x26\x64\x47\xe9\xe3\x4b\x50\x4b\xa3\xe2\xb1\xd7\xef\x0f\xdf\xc7\x6f\x67\xef\xeb\xae\x04\xd8\x3b\x5b\xf5\x16\xfb\x6a\xd7\x58\xcc\x13\xfb\x8c\x1b\x30\x12\x50\xf9\xac\xd8\x1d\x7a\xec\x9e\x9b\x06\x1f\x5d\xdd\x56\xee\x88\x0f\xf6\x58\x80\xf1\x33\x93\x72\x18\x48\x51\x25\x72\x56\x8a\xd3\x25\x5b\x80\x79\x9b\xc2\xbf\x3e\xe5\x2c\xa7\x14\x39\xe9\x90\x28\xf2\x7a\xab\x00\x13\x48\x69\x2d\xc2\xb6\x84\x1f\x67\x25\x65\xa5\xe4\xf9\x16\x87\x17\x30\xc3\xf7\x86\xb3\x77\xd6\xd9\x6e\x6f\x9f\x3e\x81\x6d\x24\x6c\x0b\x30\x12\xe9\xc4\x97\xd0\x55\xd9\x8f\x49\x49\x12\xcf\x7f\x99\x7c\x9e\x64\xe6\xbc\x5a\x5e\x08\x79\x95\x77\xc6\x97\x71\x7c\x65\x4a\xd7\x05\xbf\x08\x1c\x7e\x59\x5f\x84\x0e\x6d\x5b\xf7\x25\x7c\x0c\x00\xbb\xb2\x7e\xb9\xdf\x01\x00\x00")
+
+func _1503788894_initial_schemaUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1503788894_initial_schemaUpSql,
+		"1503788894_initial_schema.up.sql",
+	)
+}
+
+func _1503788894_initial_schemaUpSql() (*asset, error) {
+	bytes, err := _1503788894_initial_schemaUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1503788894_initial_schema.up.sql", size: 479, mode: os.FileMode(0644), modTime: time.Unix(1792193286, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x1f, 0xb5, 0xef, 0x83, 0x54, 0xe0, 0xcd, 0x57, 0xe6, 0x5b, 0xc2, 0x4b, 0x7, 0x93, 0x99, 0x18, 0x1, 0xe2, 0x9f, 0x84, 0x4c, 0x2f, 0xb, 0xde, 0x68, 0xfc, 0x17, 0xe6, 0xe1, 0x7b, 0x24, 0x2f}}
+	return a, nil
+}
+
+var __1518114782_instance_commandsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x3c\x00\xc3\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x69\x6e\x73\x74\x61\x6e\x63\x65\x73\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x63\x6f\x6d\x6d\x61\x6e\x64\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x56\x4d\xe0\xff\x3c\x00\x00\x00")
+
+func _1518114782_instance_commandsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1518114782_instance_commandsDownSql,
+		"1518114782_instance_commands.down.sql",
+	)
+}
+
+func _1518114782_instance_commandsDownSql() (*asset, error) {
+	bytes, err := _1518114782_instance_commandsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1518114782_instance_commands.down.sql", size: 60, mode: os.FileMode(0644), modTime: time.Unix(1792193286, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x6d, 0x3f, 0x1b, 0x21, 0xc7, 0xe7, 0xe, 0xbc, 0x91, 0xe8, 0x65, 0x46, 0x2d, 0xc6, 0x52, 0x97, 0x64, 0xe6, 0xdc, 0x9e, 0xcf, 0x3c, 0xbc, 0xb5, 0xc3, 0xbd, 0x5e, 0x1d, 0x51, 0xe0, 0x30, 0xbb}}
+	return a, nil
+}
+
+var __1518114782_instance_commandsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x56\x00\xa9\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x69\x6e\x73\x74\x61\x6e\x63\x65\x73\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x63\x6f\x6d\x6d\x61\x6e\x64\x20\x74\x65\x78\x74\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x27\x7b\x7d\x27\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xe5\x3a\xf8\xb1\x56\x00\x00\x00")
+
+func _1518114782_instance_commandsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1518114782_instance_commandsUpSql,
+		"1518114782_instance_commands.up.sql",
+	)
+}
+
+func _1518114782_instance_commandsUpSql() (*asset, error) {
+	bytes, err := _1518114782_instance_commandsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1518114782_instance_commands.up.sql", size: 86, mode: os.FileMode(0644), modTime: time.Unix(1792193286, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe0, 0xc, 0xee, 0x9a, 0x3d, 0xa6, 0x2f, 0xb4, 0xc5, 0x7e, 0x5a, 0x4e, 0xc8, 0x6b, 0x23, 0x7a, 0x32, 0x6e, 0x88, 0x2a, 0xaa, 0xf1, 0xcf, 0xe4, 0x8c, 0x25, 0x2c, 0x53, 0xaa, 0xf, 0x10, 0x9a}}
+	return a, nil
+}
+
+var __1792130000_build_logsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x32\x00\xcd\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x62\x75\x69\x6c\x64\x5f\x6c\x6f\x67\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xf6\xf2\xa6\xb0\x32\x00\x00\x00")
+
+func _1792130000_build_logsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130000_build_logsDownSql,
+		"1792130000_build_logs.down.sql",
+	)
+}
+
+func _1792130000_build_logsDownSql() (*asset, error) {
+	bytes, err := _1792130000_build_logsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130000_build_logs.down.sql", size: 50, mode: os.FileMode(0644), modTime: time.Unix(1792193289, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe5, 0x11, 0x21, 0xe3, 0x8f, 0x8c, 0xaf, 0xb1, 0x59, 0x49, 0xb8, 0xba, 0x59, 0x8a, 0xe6, 0x63, 0xe3, 0xd1, 0xee, 0x3, 0x12, 0x7c, 0x2c, 0x82, 0x1a, 0x63, 0x93, 0xc2, 0xb9, 0xb2, 0x7e, 0xd5}}
+	return a, nil
+}
+
+var __1792130000_build_logsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x74\x90\xc1\x6a\xc3\x30\x10\x44\xcf\xd6\x57\xec\x31\x06\xff\x81\x4f\x8e\xbd\x2d\xa6\xb6\x5c\x1c\x17\x9a\x93\x50\xa4\x25\x08\x6c\x29\x78\x15\xc8\xe7\x97\x94\xb4\x55\x9b\xe6\x3a\x4f\x9a\x9d\x99\x2d\x3e\xb7\xb2\x14\xa2\x1e\xb1\x9a\x10\xa6\x6a\xdb\x21\x1c\xce\x6e\xb6\x6a\x0e\x47\x86\x8d\xc8\x9c\x85\x48\x97\x08\x72\x98\x40\xbe\x75\x1d\xbc\x8e\x6d\x5f\x8d\x7b\x78\xc1\x7d\x21\x32\xb3\x92\x8e\x64\x95\x8e\x10\xdd\x42\x1c\xf5\x72\xfa\x7e\x5b\x88\x8c\x4f\x64\xd4\x97\xc7\x88\x4f\x38\xa2\xac\x71\x07\x57\x9d\x37\xce\xe6\x30\x48\x68\xb0\xc3\x09\xa1\xae\x76\x75\xd5\x60\x21\x32\xe7\x39\x6a\x6f\x48\xfd\xbd\x5e\x88\x4c\x33\xbb\xa3\x5f\xc8\x47\xe5\xf5\x42\x77\x9c\xcf\xc6\x10\x33\x1c\x42\x98\x49\xfb\x14\xd1\xba\x86\xf5\xde\xd0\x44\x17\x3c\xff\xd6\x45\xfe\x33\x4b\x2b\x1b\x7c\x4f\x66\x51\xb7\x52\xca\xd9\xcb\x35\x7e\x3a\xd8\x0d\xe5\xe5\xc3\xcf\x49\xb7\xff\x0c\x12\xfc\x19\x61\xe8\xfb\x76\x2a\xc5\xc7\x00\x48\x07\x74\x0a\xaa\x01\x00\x00")
+
+func _1792130000_build_logsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130000_build_logsUpSql,
+		"1792130000_build_logs.up.sql",
+	)
+}
+
+func _1792130000_build_logsUpSql() (*asset, error) {
+	bytes, err := _1792130000_build_logsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130000_build_logs.up.sql", size: 426, mode: os.FileMode(0644), modTime: time.Unix(1792193286, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xc2, 0xc6, 0x31, 0xbd, 0xf0, 0x26, 0xeb, 0xa3, 0x9a, 0xed, 0x3b, 0x81, 0xad, 0x59, 0x87, 0xa6, 0xdd, 0xdc, 0x3, 0xfb, 0x2f, 0xc3, 0xa6, 0xd2, 0xdf, 0x4a, 0x31, 0x4e, 0xb7, 0x5a, 0x78, 0x7}}
+	return a, nil
+}
+
+var __1792130100_sidecarsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x30\x00\xcf\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x73\x69\x64\x65\x63\x61\x72\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xa6\x49\x20\x7a\x30\x00\x00\x00")
+
+func _1792130100_sidecarsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130100_sidecarsDownSql,
+		"1792130100_sidecars.down.sql",
+	)
+}
+
+func _1792130100_sidecarsDownSql() (*asset, error) {
+	bytes, err := _1792130100_sidecarsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130100_sidecars.down.sql", size: 48, mode: os.FileMode(0644), modTime: time.Unix(1792193289, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x39, 0x9d, 0xd0, 0x91, 0x10, 0xb7, 0xd4, 0x1b, 0xe5, 0x6, 0xc5, 0x75, 0xd, 0xa7, 0x45, 0x4b, 0x10, 0x35, 0x19, 0xe6, 0x7b, 0xd6, 0xc8, 0xe3, 0xbb, 0xee, 0x15, 0xac, 0xcb, 0x8f, 0x9c, 0xef}}
+	return a, nil
+}
+
+var __1792130100_sidecarsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x90\x41\x6a\xc3\x30\x10\x45\xd7\x9e\x53\xcc\x32\x01\xdf\x20\x2b\xc5\x9e\x16\x53\x5b\x2e\x8a\x0a\xcd\xca\x4c\xad\xa1\x08\x6a\xb9\x58\xb3\xc8\xf1\x8b\x0b\x75\x52\x92\x9d\xe0\x7f\x3d\xfe\xbc\x23\x3d\x37\xf6\x00\x50\x39\x32\x9e\xd0\x9b\x63\x4b\x98\x63\x90\x91\x97\x8c\x3b\x28\x62\x40\x95\x8b\xa2\xed\x3d\xda\xb7\xb6\xc5\x57\xd7\x74\xc6\x9d\xf1\x85\xce\x25\x14\xe3\x22\xac\x12\x06\x56\xd4\x38\x49\x56\x9e\xbe\xb7\x6e\x09\x45\x4c\x59\x39\x8d\x32\xdc\x71\x1c\x3d\x91\x23\x5b\xd1\x09\xff\x4a\x79\x17\xc3\x1e\x7b\x8b\x35\xb5\xe4\x09\x2b\x73\xaa\x4c\x4d\x25\x14\x89\x27\xf9\xff\x7f\x65\x4f\xfc\x79\x0f\x5e\x47\xcd\x49\x39\x26\x59\x1e\x86\x5f\xc2\x49\x02\x7e\xcc\xf3\xfa\xda\x22\xd8\x5f\x35\x34\xb6\xa6\xf7\x4d\xc3\x70\x73\xc4\x10\xc3\x65\x5d\x78\x55\x74\x13\xfe\x12\xfa\xae\x6b\xfc\x01\x7e\x06\x00\x11\x02\xe0\x96\x59\x01\x00\x00")
+
+func _1792130100_sidecarsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130100_sidecarsUpSql,
+		"1792130100_sidecars.up.sql",
+	)
+}
+
+func _1792130100_sidecarsUpSql() (*asset, error) {
+	bytes, err := _1792130100_sidecarsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130100_sidecars.up.sql", size: 345, mode: os.FileMode(0644), modTime: time.Unix(1792193286, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xaa, 0x5e, 0x96, 0x25, 0x63, 0xa9, 0x6c, 0x20, 0xba, 0x6d, 0x8b, 0xa1, 0xfb, 0x91, 0x19, 0x8, 0x94, 0x77, 0x8d, 0xd, 0x38, 0xbb, 0x83, 0x89, 0x79, 0x52, 0xee, 0xa6, 0xd9, 0x47, 0x73, 0x1c}}
+	return a, nil
+}
+
+var __1792130200_snapshotsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x31\x00\xce\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x73\x6e\x61\x70\x73\x68\x6f\x74\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x01\x9a\xe2\xc3\x31\x00\x00\x00")
+
+func _1792130200_snapshotsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130200_snapshotsDownSql,
+		"1792130200_snapshots.down.sql",
+	)
+}
+
+func _1792130200_snapshotsDownSql() (*asset, error) {
+	bytes, err := _1792130200_snapshotsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130200_snapshots.down.sql", size: 49, mode: os.FileMode(0644), modTime: time.Unix(1792193289, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xc3, 0xac, 0x76, 0x40, 0x2a, 0xa0, 0xb, 0x17, 0x6e, 0xa9, 0xd2, 0xd0, 0x49, 0xd9, 0xd9, 0x17, 0xb3, 0x38, 0x49, 0x6b, 0xde, 0x4c, 0xaa, 0x3f, 0x7f, 0x1d, 0x54, 0x68, 0xb8, 0x6d, 0xa1, 0x4a}}
+	return a, nil
+}
+
+var __1792130200_snapshotsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x7c\x90\x41\x6a\xc3\x30\x10\x45\xd7\x9e\x53\xcc\x32\x01\xdf\x20\x2b\xc5\x9e\x16\x53\x5b\x2e\x8a\x0a\xcd\x4a\xa8\xd6\xd0\x0a\x6a\xd9\x54\xb3\xc8\xf1\x8b\x4a\x9a\x04\x02\xd9\x09\xfe\xd7\xe3\xcf\xdb\xd3\x73\xa7\x77\x00\x8d\x21\x65\x09\xad\xda\xf7\x84\x39\xf9\x35\x7f\x2d\x92\x71\x03\x55\x0c\x28\x7c\x12\xd4\xa3\x45\xfd\xd6\xf7\xf8\x6a\xba\x41\x99\x23\xbe\xd0\xb1\x86\x6a\xfa\x61\x2f\x1c\x9c\x17\x94\x38\x73\x16\x3f\xaf\x97\x6e\x0d\x55\x5e\x79\x72\x77\x0c\x43\x4f\x64\x48\x37\x74\xc0\x52\xc8\x9b\x18\xb6\x38\x6a\x6c\xa9\x27\x4b\xd8\xa8\x43\xa3\x5a\xaa\xa1\x8a\x29\x8b\x4f\x13\x3f\x44\xfc\x97\x1e\x60\x66\xff\x79\xcf\x28\xf3\xbf\xd9\x27\x0e\xf8\xb1\x2c\xe5\x75\x89\x60\x7b\x95\xd2\xe9\x96\xde\xaf\x52\xdc\xf9\x24\x17\xc3\xa9\x6c\xbe\xb1\x75\x4e\xfe\xfe\x8e\xc3\xd0\xd9\x1d\xfc\x0e\x00\x44\xe4\x1c\x0b\x61\x01\x00\x00")
+
+func _1792130200_snapshotsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130200_snapshotsUpSql,
+		"1792130200_snapshots.up.sql",
+	)
+}
+
+func _1792130200_snapshotsUpSql() (*asset, error) {
+	bytes, err := _1792130200_snapshotsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130200_snapshots.up.sql", size: 353, mode: os.FileMode(0644), modTime: time.Unix(1792193286, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb6, 0x9b, 0xc6, 0x69, 0xe4, 0x66, 0x35, 0x76, 0xe8, 0x60, 0x60, 0x89, 0xb0, 0xa9, 0xa8, 0x48, 0x32, 0xc7, 0x65, 0x43, 0x67, 0x4d, 0xff, 0xe8, 0x21, 0xe4, 0x88, 0x96, 0x3b, 0x68, 0x96, 0xb4}}
+	return a, nil
+}
+
+var __1792130300_instance_nodesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xc8\xcc\x2b\x2e\x49\xcc\x4b\x4e\x2d\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x48\xc9\x4f\xce\x4e\x2d\x8a\xcf\xc8\x2f\x2e\xb1\x26\x42\x79\x5e\x7e\x4a\x6a\x7c\x69\x51\x0e\xb1\x6a\xad\xb9\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x03\x00\x4c\xb2\x18\x96\x94\x00\x00\x00")
+
+func _1792130300_instance_nodesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130300_instance_nodesDownSql,
+		"1792130300_instance_nodes.down.sql",
+	)
+}
+
+func _1792130300_instance_nodesDownSql() (*asset, error) {
+	bytes, err := _1792130300_instance_nodesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130300_instance_nodes.down.sql", size: 148, mode: os.FileMode(0644), modTime: time.Unix(1792193842, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x49, 0xd8, 0xd0, 0xcc, 0x2a, 0xec, 0x74, 0x5f, 0x8f, 0xa6, 0x6f, 0xa8, 0xd1, 0x81, 0xef, 0x13, 0xa7, 0x12, 0x4e, 0xc9, 0xa8, 0xc, 0x81, 0xc9, 0xa1, 0x6c, 0x92, 0xbc, 0x82, 0x1e, 0x2f, 0x3c}}
+	return a, nil
+}
+
+var __1792130300_instance_nodesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xc8\xcc\x2b\x2e\x49\xcc\x4b\x4e\x2d\x56\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\xc8\xcb\x4f\x49\x55\x28\x49\xad\x28\x51\xf0\xf3\x0f\x51\xf0\x0b\xf5\xf1\x51\x70\x71\x75\x73\x0c\xf5\x09\x51\x50\x57\xb7\x26\x4e\x7f\x7c\x69\x51\x0e\x25\x66\xa4\xe4\x27\x67\xa7\x16\xc5\x67\xe4\x17\x97\xe0\x31\x86\xcb\xd9\xdf\xd7\xd7\x33\xc4\x9a\x0b\x30\x00\xf9\xf5\xc2\x45\xdc\x00\x00\x00")
+
+func _1792130300_instance_nodesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1792130300_instance_nodesUpSql,
+		"1792130300_instance_nodes.up.sql",
+	)
+}
+
+func _1792130300_instance_nodesUpSql() (*asset, error) {
+	bytes, err := _1792130300_instance_nodesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1792130300_instance_nodes.up.sql", size: 220, mode: os.FileMode(0644), modTime: time.Unix(1792193842, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x75, 0x28, 0x42, 0xd2, 0x53, 0x7c, 0xb1, 0xa0, 0xa9, 0xdf, 0x53, 0x7e, 0xf, 0xfc, 0xff, 0x98, 0xa4, 0x61, 0x83, 0x1c, 0xbd, 0x93, 0x77, 0x51, 0xcc, 0x3b, 0x8e, 0x53, 0x46, 0x51, 0x8e, 0xc2}}
+	return a, nil
+}
+
+// Asset loads and returns the asset for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func Asset(name string) ([]byte, error) {
+	canonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[canonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("Asset %s can't read by error: %v", name, err)
+		}
+		return a.bytes, nil
+	}
+	return nil, fmt.Errorf("Asset %s not found", name)
+}
+
+// AssetString returns the asset contents as a string (instead of a []byte).
+func AssetString(name string) (string, error) {
+	data, err := Asset(name)
+	return string(data), err
+}
+
+// MustAsset is like Asset but panics when Asset would return an error.
+// It simplifies safe initialization of global variables.
+func MustAsset(name string) []byte {
+	a, err := Asset(name)
+	if err != nil {
+		panic("asset: Asset(" + name + "): " + err.Error())
+	}
+
+	return a
+}
+
+// MustAssetString is like AssetString but panics when Asset would return an
+// error. It simplifies safe initialization of global variables.
+func MustAssetString(name string) string {
+	return string(MustAsset(name))
+}
+
+// AssetInfo loads and returns the asset info for the given name.
+// It returns an error if the asset could not be found or
+// could not be loaded.
+func AssetInfo(name string) (os.FileInfo, error) {
+	canonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[canonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return nil, fmt.Errorf("AssetInfo %s can't read by error: %v", name, err)
+		}
+		return a.info, nil
+	}
+	return nil, fmt.Errorf("AssetInfo %s not found", name)
+}
+
+// AssetDigest returns the digest of the file with the given name. It returns an
+// error if the asset could not be found or the digest could not be loaded.
+func AssetDigest(name string) ([sha256.Size]byte, error) {
+	canonicalName := strings.Replace(name, "\\", "/", -1)
+	if f, ok := _bindata[canonicalName]; ok {
+		a, err := f()
+		if err != nil {
+			return [sha256.Size]byte{}, fmt.Errorf("AssetDigest %s can't read by error: %v", name, err)
+		}
+		return a.digest, nil
+	}
+	return [sha256.Size]byte{}, fmt.Errorf("AssetDigest %s not found", name)
+}
+
+// Digests returns a map of all known files and their checksums.
+func Digests() (map[string][sha256.Size]byte, error) {
+	mp := make(map[string][sha256.Size]byte, len(_bindata))
+	for name := range _bindata {
+		a, err := _bindata[name]()
+		if err != nil {
+			return nil, err
+		}
+		mp[name] = a.digest
+	}
+	return mp, nil
+}
+
+// AssetNames returns the names of the assets.
+func AssetNames() []string {
+	names := make([]string, 0, len(_bindata))
+	for name := range _bindata {
+		names = append(names, name)
+	}
+	return names
+}
+
+// _bindata is a table, holding each asset generator, mapped to its name.
+var _bindata = map[string]func() (*asset, error){
+	"1503788894_initial_schema.down.sql":    _1503788894_initial_schemaDownSql,
+	"1503788894_initial_schema.up.sql":      _1503788894_initial_schemaUpSql,
+	"1518114782_instance_commands.down.sql": _1518114782_instance_commandsDownSql,
+	"1518114782_instance_commands.up.sql":   _1518114782_instance_commandsUpSql,
+	"1792130000_build_logs.down.sql":        _1792130000_build_logsDownSql,
+	"1792130000_build_logs.up.sql":          _1792130000_build_logsUpSql,
+	"1792130100_sidecars.down.sql":          _1792130100_sidecarsDownSql,
+	"1792130100_sidecars.up.sql":            _1792130100_sidecarsUpSql,
+	"1792130200_snapshots.down.sql":         _1792130200_snapshotsDownSql,
+	"1792130200_snapshots.up.sql":           _1792130200_snapshotsUpSql,
+	"1792130300_instance_nodes.down.sql":    _1792130300_instance_nodesDownSql,
+	"1792130300_instance_nodes.up.sql":      _1792130300_instance_nodesUpSql,
+}
+
+// AssetDir returns the file names below a certain
+// directory embedded in the file by go-bindata.
+// For example if you run go-bindata on data/... and data contains the
+// following hierarchy:
+//     data/
+//       foo.txt
+//       img/
+//         a.png
+//         b.png
+// then AssetDir("data") would return []string{"foo.txt", "img"},
+// AssetDir("data/img") would return []string{"a.png", "b.png"},
+// AssetDir("foo.txt") and AssetDir("notexist") would return an error, and
+// AssetDir("") will return []string{"data"}.
+func AssetDir(name string) ([]string, error) {
+	node := _bintree
+	if len(name) != 0 {
+		canonicalName := strings.Replace(name, "\\", "/", -1)
+		pathList := strings.Split(canonicalName, "/")
+		for _, p := range pathList {
+			node = node.Children[p]
+			if node == nil {
+				return nil, fmt.Errorf("Asset %s not found", name)
+			}
+		}
+	}
+	if node.Func != nil {
+		return nil, fmt.Errorf("Asset %s not found", name)
+	}
+	rv := make([]string, 0, len(node.Children))
+	for childName := range node.Children {
+		rv = append(rv, childName)
+	}
+	return rv, nil
+}
+
+type bintree struct {
+	Func     func() (*asset, error)
+	Children map[string]*bintree
+}
+
+var _bintree = &bintree{nil, map[string]*bintree{
+	"1503788894_initial_schema.down.sql":    &bintree{_1503788894_initial_schemaDownSql, map[string]*bintree{}},
+	"1503788894_initial_schema.up.sql":      &bintree{_1503788894_initial_schemaUpSql, map[string]*bintree{}},
+	"1518114782_instance_commands.down.sql": &bintree{_1518114782_instance_commandsDownSql, map[string]*bintree{}},
+	"1518114782_instance_commands.up.sql":   &bintree{_1518114782_instance_commandsUpSql, map[string]*bintree{}},
+	"1792130000_build_logs.down.sql":        &bintree{_1792130000_build_logsDownSql, map[string]*bintree{}},
+	"1792130000_build_logs.up.sql":          &bintree{_1792130000_build_logsUpSql, map[string]*bintree{}},
+	"1792130100_sidecars.down.sql":          &bintree{_1792130100_sidecarsDownSql, map[string]*bintree{}},
+	"1792130100_sidecars.up.sql":            &bintree{_1792130100_sidecarsUpSql, map[string]*bintree{}},
+	"1792130200_snapshots.down.sql":         &bintree{_1792130200_snapshotsDownSql, map[string]*bintree{}},
+	"1792130200_snapshots.up.sql":           &bintree{_1792130200_snapshotsUpSql, map[string]*bintree{}},
+	"1792130300_instance_nodes.down.sql":    &bintree{_1792130300_instance_nodesDownSql, map[string]*bintree{}},
+	"1792130300_instance_nodes.up.sql":      &bintree{_1792130300_instance_nodesUpSql, map[string]*bintree{}},
+}}
+
+// RestoreAsset restores an asset under the given directory.
+func RestoreAsset(dir, name string) error {
+	data, err := Asset(name)
+	if err != nil {
+		return err
+	}
+	info, err := AssetInfo(name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(_filePath(dir, filepath.Dir(name)), os.FileMode(0755))
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(_filePath(dir, name), data, info.Mode())
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(_filePath(dir, name), info.ModTime(), info.ModTime())
+}
+
+// RestoreAssets restores an asset under the given directory recursively.
+func RestoreAssets(dir, name string) error {
+	children, err := AssetDir(name)
+	// File
+	if err != nil {
+		return RestoreAsset(dir, name)
+	}
+	// Dir
+	for _, child := range children {
+		err = RestoreAssets(dir, filepath.Join(name, child))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func _filePath(dir, name string) string {
+	canonicalName := strings.Replace(name, "\\", "/", -1)
+	return filepath.Join(append([]string{dir}, strings.Split(canonicalName, "/")...)...)
+}
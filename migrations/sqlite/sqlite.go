@@ -0,0 +1,5 @@
+// Package sqlite contains the database migrations for SQLite. These mirror
+// the PostgreSQL migrations in the parent package, version for version.
+package sqlite
+
+//go:generate go-bindata -pkg sqlite -ignore=\.(go|json|md)$ .
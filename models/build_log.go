@@ -0,0 +1,153 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// BuildLog records the result of building an instance, including the result
+// of each of its post-build actions. Build logs are kept even if the build
+// fails (and the instance is never created), so that assignment authors can
+// find out why.
+type BuildLog struct {
+	ID             ID              `json:"id"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	SpecID         ID              `json:"specID"`
+	InstanceID     ID              `json:"instanceID"`
+	AssignmentName string          `json:"assignmentName"`
+	Success        bool            `json:"success"`
+	Error          string          `json:"error,omitempty"`
+	Actions        json.RawMessage `json:"actions"`
+}
+
+// NewBuildLog creates a new BuildLog with a new ID.
+func NewBuildLog() *BuildLog {
+	return &BuildLog{
+		ID: NewID(),
+	}
+}
+
+// BuildLogStore stores BuildLogs. Build logs are never updated.
+type BuildLogStore struct {
+	db *sql.DB
+}
+
+// NewBuildLogStore creates a new BuildLogStore.
+func NewBuildLogStore(db *sql.DB) *BuildLogStore {
+	return &BuildLogStore{db: db}
+}
+
+const buildLogColumns = "id, created_at, spec_id, instance_id, assignment_name, success, error, actions"
+
+// Insert inserts a new build log. If its creation time is unset, it is set
+// to the current time.
+func (s *BuildLogStore) Insert(l *BuildLog) error {
+	if l.CreatedAt.IsZero() {
+		l.CreatedAt = time.Now()
+	}
+
+	actions := l.Actions
+	if actions == nil {
+		actions = json.RawMessage("[]")
+	}
+
+	_, err := s.db.Exec(
+		"INSERT INTO build_logs ("+buildLogColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		l.ID, l.CreatedAt, l.SpecID, l.InstanceID, l.AssignmentName, l.Success, l.Error, []byte(actions),
+	)
+	return err
+}
+
+// FindByInstance returns the build log for an instance, or ErrNotFound.
+func (s *BuildLogStore) FindByInstance(instanceID ID) (*BuildLog, error) {
+	row := s.db.QueryRow(
+		"SELECT "+buildLogColumns+" FROM build_logs WHERE instance_id = $1 ORDER BY created_at DESC LIMIT 1",
+		instanceID,
+	)
+
+	l, err := scanBuildLog(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return l, err
+}
+
+// FindRecent returns up to limit of the most recent build logs, optionally
+// filtered by spec ID and assignment name (ignored if empty).
+func (s *BuildLogStore) FindRecent(specID ID, assignmentName string, limit int) ([]*BuildLog, error) {
+	query := "SELECT " + buildLogColumns + " FROM build_logs WHERE true"
+	var args []interface{}
+
+	if !specID.IsEmpty() {
+		args = append(args, specID)
+		query += " AND spec_id = $1"
+	}
+
+	if assignmentName != "" {
+		args = append(args, assignmentName)
+		query += " AND assignment_name = $" + strconv.Itoa(len(args))
+	}
+
+	args = append(args, limit)
+	query += " ORDER BY created_at DESC LIMIT $" + strconv.Itoa(len(args))
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []*BuildLog
+
+	for rows.Next() {
+		l, err := scanBuildLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, l)
+	}
+
+	return logs, rows.Err()
+}
+
+// FindFailures returns up to limit of the most recent failed build logs.
+func (s *BuildLogStore) FindFailures(limit int) ([]*BuildLog, error) {
+	rows, err := s.db.Query(
+		"SELECT "+buildLogColumns+" FROM build_logs WHERE NOT success ORDER BY created_at DESC LIMIT $1",
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []*BuildLog
+
+	for rows.Next() {
+		l, err := scanBuildLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, l)
+	}
+
+	return logs, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanBuildLog(row rowScanner) (*BuildLog, error) {
+	var l BuildLog
+	var actions []byte
+
+	if err := row.Scan(&l.ID, &l.CreatedAt, &l.SpecID, &l.InstanceID, &l.AssignmentName, &l.Success, &l.Error, &actions); err != nil {
+		return nil, err
+	}
+
+	l.Actions = json.RawMessage(actions)
+	return &l, nil
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid"
+)
+
+// ErrNotFound is returned when a record can't be found.
+var ErrNotFound = errors.New("models: not found")
+
+// ID is a lexically sortable identifier, a ULID (https://github.com/oklog/ulid)
+// stored and formatted as a UUID. It is compatible with the IDs previously
+// generated by kallax, so existing rows and URLs remain valid.
+type ID [16]byte
+
+var randPool = &sync.Pool{
+	New: func() interface{} {
+		seed := time.Now().UnixNano() + rand.Int63()
+		return rand.NewSource(seed)
+	},
+}
+
+// NewID returns a new ID.
+func NewID() ID {
+	entropy := randPool.Get().(rand.Source)
+	id := ID(ulid.MustNew(ulid.Timestamp(time.Now()), rand.New(entropy)))
+	randPool.Put(entropy)
+
+	return id
+}
+
+// ParseID parses an ID from its string representation.
+func ParseID(s string) (ID, error) {
+	var id ID
+	err := id.UnmarshalText([]byte(s))
+	return id, err
+}
+
+// String returns the UUID representation of the ID.
+func (id ID) String() string {
+	buf := make([]byte, 36)
+
+	hex.Encode(buf[0:8], id[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], id[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], id[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], id[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], id[10:])
+
+	return string(buf)
+}
+
+// IsEmpty returns true if the ID has not been set.
+func (id ID) IsEmpty() bool {
+	return id == ID{}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (id ID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+var (
+	urnPrefix  = []byte("urn:uuid:")
+	byteGroups = []int{8, 4, 4, 4, 12}
+)
+
+// UnmarshalText implements encoding.TextUnmarshaler. IDs may be formatted
+// as a plain UUID, in braces, or as a URN, like:
+//
+//	"6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+//	"{6ba7b810-9dad-11d1-80b4-00c04fd430c8}"
+//	"urn:uuid:6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+//
+// Unlike UUIDs, the version is not checked.
+func (id *ID) UnmarshalText(text []byte) error {
+	if len(text) < 32 {
+		return fmt.Errorf("models: id string too short: %s", text)
+	}
+
+	t := text
+	braced := false
+
+	if bytes.HasPrefix(t, urnPrefix) {
+		t = t[len(urnPrefix):]
+	} else if t[0] == '{' {
+		braced = true
+		t = t[1:]
+	}
+
+	var parsed ID
+	b := parsed[:]
+
+	for i, byteGroup := range byteGroups {
+		if i > 0 {
+			if len(t) == 0 || t[0] != '-' {
+				return errors.New("models: invalid id string format")
+			}
+			t = t[1:]
+		}
+
+		if len(t) < byteGroup {
+			return fmt.Errorf("models: id string too short: %s", text)
+		}
+
+		if i == 4 && len(t) > byteGroup && (!braced || t[byteGroup] != '}' || len(t[byteGroup:]) > 1) {
+			return fmt.Errorf("models: id string too long: %s", text)
+		}
+
+		if _, err := hex.Decode(b[:byteGroup/2], t[:byteGroup]); err != nil {
+			return err
+		}
+
+		t = t[byteGroup:]
+		b = b[byteGroup/2:]
+	}
+
+	*id = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner. IDs are stored as text (or a PostgreSQL
+// uuid), but raw 16 byte values are accepted as well.
+func (id *ID) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		if len(src) == len(id) {
+			copy(id[:], src)
+			return nil
+		}
+		return id.UnmarshalText(src)
+	case string:
+		return id.UnmarshalText([]byte(src))
+	default:
+		return fmt.Errorf("models: cannot scan %T into ID", src)
+	}
+}
+
+// Value implements driver.Valuer.
+func (id ID) Value() (driver.Value, error) {
+	return id.String(), nil
+}
+
+// nullID scans an ID which may be NULL, leaving it empty.
+type nullID struct {
+	id *ID
+}
+
+func (n nullID) Scan(src interface{}) error {
+	if src == nil {
+		*n.id = ID{}
+		return nil
+	}
+	return n.id.Scan(src)
+}
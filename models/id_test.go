@@ -0,0 +1,74 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseID(t *testing.T) {
+	const s = "0169a1f2-8d6e-7f0e-3c3b-26f1a2f6c0de"
+
+	for _, text := range []string{
+		s,
+		"{" + s + "}",
+		"urn:uuid:" + s,
+	} {
+		id, err := ParseID(text)
+		if err != nil {
+			t.Errorf("expected nil error parsing %q, got %v", text, err)
+			continue
+		}
+
+		if id.String() != s {
+			t.Errorf("expected %q to parse as %s, got %s", text, s, id)
+		}
+	}
+
+	for _, text := range []string{
+		"",
+		s[:35],
+		s + "0",
+		s + "}",
+		"0169a1f2x8d6e-7f0e-3c3b-26f1a2f6c0de",
+		"0169a1f2-8d6e-7f0e-3c3b-26f1a2f6c0dz",
+	} {
+		if _, err := ParseID(text); err == nil {
+			t.Errorf("expected error parsing %q", text)
+		}
+	}
+}
+
+func TestIDRoundTrip(t *testing.T) {
+	id := NewID()
+	if id.IsEmpty() {
+		t.Fatal("expected new ID to be non-empty")
+	}
+
+	b, err := json.Marshal(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded ID
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != id {
+		t.Errorf("expected %s after JSON round trip, got %s", id, decoded)
+	}
+
+	v, err := id.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var scanned ID
+	if err := scanned.Scan(v); err != nil {
+		t.Fatal(err)
+	}
+
+	if scanned != id {
+		t.Errorf("expected %s after database round trip, got %s", id, scanned)
+	}
+}
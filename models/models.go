@@ -2,26 +2,23 @@ package models
 
 import (
 	"time"
-
-	"gopkg.in/src-d/go-kallax.v1"
 )
 
 // Spec specifies how to build an assignment's image from scratch.
 // This includes the assignment's name (same as the subdirectory),
-// data and seed to pass to the template, and any instances associated
-// with the spec.
+// and data and seed to pass to the template.
 type Spec struct {
-	kallax.Model `table:"specs"`
-	kallax.Timestamps
-	ID             kallax.ULID `pk:""`
+	ID             ID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 	AssignmentName string
 	Data           interface{}
-	Instances      []*Instance
 }
 
-func newSpec() *Spec {
+// NewSpec creates a new Spec with a new ID.
+func NewSpec() *Spec {
 	return &Spec{
-		ID: kallax.NewULID(),
+		ID: NewID(),
 	}
 }
 
@@ -29,22 +26,31 @@ func newSpec() *Spec {
 // the ID of the Docker image, the ID of the Docker container,
 // when the instance should expire (a new instance must be created),
 // and its status.
+//
+// When multiple nodes share a database, each instance is owned by the node
+// which created it, and its container lives on that node's Docker host.
+// Instances created before nodes were recorded have an empty Node, and are
+// treated as belonging to every node.
 type Instance struct {
-	kallax.Model `table:"instances"`
-	kallax.Timestamps
-	ID          kallax.ULID `pk:""`
-	Spec        *Spec       `fk:",inverse"`
+	ID          ID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Spec        *Spec
 	ImageID     string
 	ContainerID string
 	ExpiresAt   *time.Time
 	Active      bool
 	Cleaned     bool
 	Command     InstanceCommand
+	Node        string
+	NodeURL     string
+	DockerHost  string
 }
 
-func newInstance() *Instance {
+// NewInstance creates a new Instance with a new ID.
+func NewInstance() *Instance {
 	return &Instance{
-		ID: kallax.NewULID(),
+		ID: NewID(),
 	}
 }
 
@@ -56,5 +62,3 @@ type InstanceCommand struct {
 	Env        []string
 	WorkingDir string
 }
-
-//go:generate sh -c "if [[ $(go env GOMOD) ]]; then echo WARNING: kallax gen cannot be run outside of GOPATH; else kallax gen; fi"
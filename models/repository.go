@@ -0,0 +1,428 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Repository stores specs and their instances. Instances are always returned
+// with their Spec loaded (if they have one).
+type Repository interface {
+	// FindSpec returns the spec with the given ID, or ErrNotFound.
+	FindSpec(id ID) (*Spec, error)
+	// InsertSpec inserts a new spec.
+	InsertSpec(spec *Spec) error
+	// ListSpecs returns the specs matching the filter, newest first.
+	ListSpecs(filter SpecFilter) ([]*Spec, error)
+
+	// FindInstance returns the instance with the given ID, or ErrNotFound.
+	FindInstance(id ID) (*Instance, error)
+	// FindActiveInstance is FindInstance, but only finds active instances.
+	FindActiveInstance(id ID) (*Instance, error)
+	// FindActiveBySpec returns the active instances of a spec, newest
+	// first. There should only ever be one.
+	FindActiveBySpec(specID ID) ([]*Instance, error)
+	// InsertInstance inserts a new instance of its Spec.
+	InsertInstance(instance *Instance) error
+	// ListInstances returns the instances matching the filter, newest first.
+	ListInstances(filter InstanceFilter) ([]*Instance, error)
+
+	// ListExpired returns the active instances which expired before the
+	// given time.
+	ListExpired(before time.Time) ([]*Instance, error)
+	// ListActive returns all active instances.
+	ListActive() ([]*Instance, error)
+	// ListInactiveUncleaned returns the inactive instances which have yet
+	// to be cleaned.
+	ListInactiveUncleaned() ([]*Instance, error)
+	// ListActiveOrUncleaned returns the instances which are either active
+	// or have yet to be cleaned.
+	ListActiveOrUncleaned() ([]*Instance, error)
+	// ListUncleanedBySpec returns the instances of a spec which have yet to
+	// be cleaned.
+	ListUncleanedBySpec(specID ID) ([]*Instance, error)
+
+	// MarkInactive marks an instance as inactive.
+	MarkInactive(instance *Instance) error
+	// MarkCleaned marks an instance as inactive and cleaned.
+	MarkCleaned(instance *Instance) error
+	// Expire sets an instance's expiry time and marks it as inactive.
+	Expire(instance *Instance, at time.Time) error
+	// SetExpiresAt saves an instance's ExpiresAt.
+	SetExpiresAt(instance *Instance) error
+	// SetContainer saves an instance's ContainerID and Command.
+	SetContainer(instance *Instance) error
+}
+
+// SpecFilter filters the specs returned by ListSpecs. Zero values match
+// all specs. Offset is only used along with Limit.
+type SpecFilter struct {
+	AssignmentName string
+	Limit          uint64
+	Offset         uint64
+}
+
+// InstanceFilter filters the instances returned by ListInstances. Zero
+// values match all instances. Offset is only used along with Limit.
+type InstanceFilter struct {
+	SpecID         ID
+	AssignmentName string
+	Active         *bool
+	Cleaned        *bool
+	ExpiresBefore  *time.Time
+	ExpiresAfter   *time.Time
+	Limit          uint64
+	Offset         uint64
+}
+
+// SQLRepository is a Repository written by hand on top of database/sql.
+// Like the other stores, its queries work on both PostgreSQL and SQLite.
+type SQLRepository struct {
+	db *sql.DB
+}
+
+var _ Repository = (*SQLRepository)(nil)
+
+// NewSQLRepository creates a new SQLRepository.
+func NewSQLRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{db: db}
+}
+
+const specColumns = "id, created_at, updated_at, assignment_name, data"
+
+// FindSpec implements Repository.
+func (r *SQLRepository) FindSpec(id ID) (*Spec, error) {
+	specs, err := r.querySpecs("SELECT "+specColumns+" FROM specs WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(specs) == 0 {
+		return nil, ErrNotFound
+	}
+	return specs[0], nil
+}
+
+// InsertSpec implements Repository. Its creation and update times are set to
+// the current time.
+func (r *SQLRepository) InsertSpec(spec *Spec) error {
+	data, err := json.Marshal(spec.Data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	spec.CreatedAt = now
+	spec.UpdatedAt = now
+
+	_, err = r.db.Exec(
+		"INSERT INTO specs ("+specColumns+") VALUES ($1, $2, $3, $4, $5)",
+		spec.ID, spec.CreatedAt, spec.UpdatedAt, spec.AssignmentName, string(data),
+	)
+	return err
+}
+
+// ListSpecs implements Repository.
+func (r *SQLRepository) ListSpecs(filter SpecFilter) ([]*Spec, error) {
+	var q listQuery
+
+	if filter.AssignmentName != "" {
+		q.where("assignment_name = ", filter.AssignmentName)
+	}
+
+	return r.querySpecs("SELECT "+specColumns+" FROM specs"+q.String("created_at", filter.Limit, filter.Offset), q.args...)
+}
+
+func (r *SQLRepository) querySpecs(query string, args ...interface{}) ([]*Spec, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var specs []*Spec
+
+	for rows.Next() {
+		var spec Spec
+		var data []byte
+
+		if err := rows.Scan(&spec.ID, &spec.CreatedAt, &spec.UpdatedAt, &spec.AssignmentName, &data); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(data, &spec.Data); err != nil {
+			return nil, err
+		}
+
+		specs = append(specs, &spec)
+	}
+
+	return specs, rows.Err()
+}
+
+const instanceSelect = "SELECT i.id, i.created_at, i.updated_at, i.image_id, i.container_id, i.expires_at, i.active, i.cleaned, i.command, " +
+	"i.node, i.node_url, i.docker_host, " +
+	"s.id, s.created_at, s.updated_at, s.assignment_name, s.data " +
+	"FROM instances i LEFT JOIN specs s ON s.id = i.spec_id"
+
+// FindInstance implements Repository.
+func (r *SQLRepository) FindInstance(id ID) (*Instance, error) {
+	return r.findInstance(instanceSelect+" WHERE i.id = $1", id)
+}
+
+// FindActiveInstance implements Repository.
+func (r *SQLRepository) FindActiveInstance(id ID) (*Instance, error) {
+	return r.findInstance(instanceSelect+" WHERE i.id = $1 AND i.active = $2", id, true)
+}
+
+// FindActiveBySpec implements Repository.
+func (r *SQLRepository) FindActiveBySpec(specID ID) ([]*Instance, error) {
+	return r.queryInstances(instanceSelect+" WHERE i.spec_id = $1 AND i.active = $2 ORDER BY i.created_at DESC", specID, true)
+}
+
+// InsertInstance implements Repository. Its creation and update times are
+// set to the current time.
+func (r *SQLRepository) InsertInstance(instance *Instance) error {
+	command, err := json.Marshal(&instance.Command)
+	if err != nil {
+		return err
+	}
+
+	var specID interface{}
+	if instance.Spec != nil {
+		specID = instance.Spec.ID
+	}
+
+	now := time.Now()
+	instance.CreatedAt = now
+	instance.UpdatedAt = now
+
+	_, err = r.db.Exec(
+		"INSERT INTO instances (id, created_at, updated_at, spec_id, image_id, container_id, expires_at, active, cleaned, command, node, node_url, docker_host) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+		instance.ID, instance.CreatedAt, instance.UpdatedAt, specID, instance.ImageID, instance.ContainerID,
+		instance.ExpiresAt, instance.Active, instance.Cleaned, string(command),
+		instance.Node, instance.NodeURL, instance.DockerHost,
+	)
+	return err
+}
+
+// ListInstances implements Repository.
+func (r *SQLRepository) ListInstances(filter InstanceFilter) ([]*Instance, error) {
+	var q listQuery
+
+	if !filter.SpecID.IsEmpty() {
+		q.where("i.spec_id = ", filter.SpecID)
+	}
+
+	if filter.AssignmentName != "" {
+		q.where("s.assignment_name = ", filter.AssignmentName)
+	}
+
+	if filter.Active != nil {
+		q.where("i.active = ", *filter.Active)
+	}
+
+	if filter.Cleaned != nil {
+		q.where("i.cleaned = ", *filter.Cleaned)
+	}
+
+	if filter.ExpiresBefore != nil {
+		q.where("i.expires_at < ", *filter.ExpiresBefore)
+	}
+
+	if filter.ExpiresAfter != nil {
+		q.where("i.expires_at > ", *filter.ExpiresAfter)
+	}
+
+	return r.queryInstances(instanceSelect+q.String("i.created_at", filter.Limit, filter.Offset), q.args...)
+}
+
+// ListExpired implements Repository.
+func (r *SQLRepository) ListExpired(before time.Time) ([]*Instance, error) {
+	return r.queryInstances(instanceSelect+" WHERE i.active = $1 AND i.expires_at < $2", true, before)
+}
+
+// ListActive implements Repository.
+func (r *SQLRepository) ListActive() ([]*Instance, error) {
+	return r.queryInstances(instanceSelect+" WHERE i.active = $1", true)
+}
+
+// ListInactiveUncleaned implements Repository.
+func (r *SQLRepository) ListInactiveUncleaned() ([]*Instance, error) {
+	return r.queryInstances(instanceSelect+" WHERE i.active = $1 AND i.cleaned = $1", false)
+}
+
+// ListActiveOrUncleaned implements Repository.
+func (r *SQLRepository) ListActiveOrUncleaned() ([]*Instance, error) {
+	return r.queryInstances(instanceSelect+" WHERE i.active = $1 OR i.cleaned = $2", true, false)
+}
+
+// ListUncleanedBySpec implements Repository.
+func (r *SQLRepository) ListUncleanedBySpec(specID ID) ([]*Instance, error) {
+	return r.queryInstances(instanceSelect+" WHERE i.spec_id = $1 AND i.cleaned = $2", specID, false)
+}
+
+// MarkInactive implements Repository.
+func (r *SQLRepository) MarkInactive(instance *Instance) error {
+	instance.Active = false
+	return r.update(instance, "active = $1", instance.Active)
+}
+
+// MarkCleaned implements Repository.
+func (r *SQLRepository) MarkCleaned(instance *Instance) error {
+	instance.Active = false
+	instance.Cleaned = true
+	return r.update(instance, "active = $1, cleaned = $2", instance.Active, instance.Cleaned)
+}
+
+// Expire implements Repository.
+func (r *SQLRepository) Expire(instance *Instance, at time.Time) error {
+	instance.ExpiresAt = &at
+	instance.Active = false
+	return r.update(instance, "expires_at = $1, active = $2", instance.ExpiresAt, instance.Active)
+}
+
+// SetExpiresAt implements Repository.
+func (r *SQLRepository) SetExpiresAt(instance *Instance) error {
+	return r.update(instance, "expires_at = $1", instance.ExpiresAt)
+}
+
+// SetContainer implements Repository.
+func (r *SQLRepository) SetContainer(instance *Instance) error {
+	command, err := json.Marshal(&instance.Command)
+	if err != nil {
+		return err
+	}
+
+	return r.update(instance, "container_id = $1, command = $2", instance.ContainerID, string(command))
+}
+
+// update sets columns of an instance, along with its update time. The last
+// two placeholders are used for the update time and ID.
+func (r *SQLRepository) update(instance *Instance, set string, args ...interface{}) error {
+	instance.UpdatedAt = time.Now()
+
+	n := len(args)
+	args = append(args, instance.UpdatedAt, instance.ID)
+
+	_, err := r.db.Exec(
+		"UPDATE instances SET "+set+", updated_at = $"+strconv.Itoa(n+1)+" WHERE id = $"+strconv.Itoa(n+2),
+		args...,
+	)
+	return err
+}
+
+func (r *SQLRepository) findInstance(query string, args ...interface{}) (*Instance, error) {
+	instances, err := r.queryInstances(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(instances) == 0 {
+		return nil, ErrNotFound
+	}
+	return instances[0], nil
+}
+
+func (r *SQLRepository) queryInstances(query string, args ...interface{}) ([]*Instance, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var instances []*Instance
+
+	for rows.Next() {
+		instance, err := scanInstance(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		instances = append(instances, instance)
+	}
+
+	return instances, rows.Err()
+}
+
+func scanInstance(row rowScanner) (*Instance, error) {
+	var (
+		instance Instance
+		command  []byte
+
+		specID         ID
+		specCreatedAt  *time.Time
+		specUpdatedAt  *time.Time
+		assignmentName sql.NullString
+		data           []byte
+	)
+
+	if err := row.Scan(
+		&instance.ID, &instance.CreatedAt, &instance.UpdatedAt, &instance.ImageID, &instance.ContainerID,
+		&instance.ExpiresAt, &instance.Active, &instance.Cleaned, &command,
+		&instance.Node, &instance.NodeURL, &instance.DockerHost,
+		nullID{&specID}, &specCreatedAt, &specUpdatedAt, &assignmentName, &data,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(command, &instance.Command); err != nil {
+		return nil, err
+	}
+
+	if !specID.IsEmpty() {
+		instance.Spec = &Spec{
+			ID:             specID,
+			CreatedAt:      *specCreatedAt,
+			UpdatedAt:      *specUpdatedAt,
+			AssignmentName: assignmentName.String,
+		}
+
+		if err := json.Unmarshal(data, &instance.Spec.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	return &instance, nil
+}
+
+// listQuery builds the WHERE, ORDER BY, LIMIT, and OFFSET clauses of a list
+// query, numbering its placeholders.
+type listQuery struct {
+	conds []string
+	args  []interface{}
+}
+
+// where adds a condition comparing against arg, like where("active = ", true).
+func (q *listQuery) where(cond string, arg interface{}) {
+	q.args = append(q.args, arg)
+	q.conds = append(q.conds, cond+"$"+strconv.Itoa(len(q.args)))
+}
+
+// String returns the clauses, ordering by the given column (newest first).
+// A zero limit is ignored, along with the offset, as SQLite doesn't allow an
+// offset without a limit.
+func (q *listQuery) String(orderBy string, limit, offset uint64) string {
+	var sb strings.Builder
+
+	if len(q.conds) != 0 {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(strings.Join(q.conds, " AND "))
+	}
+
+	sb.WriteString(" ORDER BY " + orderBy + " DESC")
+
+	if limit != 0 {
+		sb.WriteString(" LIMIT " + strconv.FormatUint(limit, 10))
+
+		if offset != 0 {
+			sb.WriteString(" OFFSET " + strconv.FormatUint(offset, 10))
+		}
+	}
+
+	return sb.String()
+}
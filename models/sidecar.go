@@ -0,0 +1,85 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Sidecar is a service container run alongside an instance's container,
+// sharing the instance's isolated network. Each sidecar has its own image,
+// tagged from the service's image.
+type Sidecar struct {
+	ID          ID
+	CreatedAt   time.Time
+	InstanceID  ID
+	Name        string
+	ImageID     string
+	ContainerID string
+	Cleaned     bool
+}
+
+// NewSidecar creates a new Sidecar with a new ID.
+func NewSidecar() *Sidecar {
+	return &Sidecar{
+		ID: NewID(),
+	}
+}
+
+// SidecarStore stores Sidecars.
+type SidecarStore struct {
+	db *sql.DB
+}
+
+// NewSidecarStore creates a new SidecarStore.
+func NewSidecarStore(db *sql.DB) *SidecarStore {
+	return &SidecarStore{db: db}
+}
+
+const sidecarColumns = "id, created_at, instance_id, name, image_id, container_id, cleaned"
+
+// Insert inserts a new sidecar. If its creation time is unset, it is set to
+// the current time.
+func (s *SidecarStore) Insert(sc *Sidecar) error {
+	if sc.CreatedAt.IsZero() {
+		sc.CreatedAt = time.Now()
+	}
+
+	_, err := s.db.Exec(
+		"INSERT INTO sidecars ("+sidecarColumns+") VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		sc.ID, sc.CreatedAt, sc.InstanceID, sc.Name, sc.ImageID, sc.ContainerID, sc.Cleaned,
+	)
+	return err
+}
+
+// FindByInstance returns the uncleaned sidecars of an instance.
+func (s *SidecarStore) FindByInstance(instanceID ID) ([]*Sidecar, error) {
+	rows, err := s.db.Query(
+		"SELECT "+sidecarColumns+" FROM sidecars WHERE instance_id = $1 AND NOT cleaned ORDER BY name",
+		instanceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sidecars []*Sidecar
+
+	for rows.Next() {
+		var sc Sidecar
+
+		if err := rows.Scan(&sc.ID, &sc.CreatedAt, &sc.InstanceID, &sc.Name, &sc.ImageID, &sc.ContainerID, &sc.Cleaned); err != nil {
+			return nil, err
+		}
+
+		sidecars = append(sidecars, &sc)
+	}
+
+	return sidecars, rows.Err()
+}
+
+// MarkCleaned marks a sidecar as cleaned.
+func (s *SidecarStore) MarkCleaned(sc *Sidecar) error {
+	sc.Cleaned = true
+	_, err := s.db.Exec("UPDATE sidecars SET cleaned = true WHERE id = $1", sc.ID)
+	return err
+}
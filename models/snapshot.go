@@ -0,0 +1,122 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Snapshot is an image committed from an expired instance's container, used
+// to restore the spec's work in its next instance.
+type Snapshot struct {
+	ID         ID
+	CreatedAt  time.Time
+	SpecID     ID
+	InstanceID ID
+	ImageID    string
+	Cleaned    bool
+}
+
+// NewSnapshot creates a new Snapshot with a new ID.
+func NewSnapshot() *Snapshot {
+	return &Snapshot{
+		ID: NewID(),
+	}
+}
+
+// SnapshotStore stores Snapshots.
+type SnapshotStore struct {
+	db *sql.DB
+}
+
+// NewSnapshotStore creates a new SnapshotStore.
+func NewSnapshotStore(db *sql.DB) *SnapshotStore {
+	return &SnapshotStore{db: db}
+}
+
+const snapshotColumns = "id, created_at, spec_id, instance_id, image_id, cleaned"
+
+// Insert inserts a new snapshot. If its creation time is unset, it is set to
+// the current time.
+func (s *SnapshotStore) Insert(snapshot *Snapshot) error {
+	if snapshot.CreatedAt.IsZero() {
+		snapshot.CreatedAt = time.Now()
+	}
+
+	_, err := s.db.Exec(
+		"INSERT INTO snapshots ("+snapshotColumns+") VALUES ($1, $2, $3, $4, $5, $6)",
+		snapshot.ID, snapshot.CreatedAt, snapshot.SpecID, snapshot.InstanceID, snapshot.ImageID, snapshot.Cleaned,
+	)
+	return err
+}
+
+// FindLatest returns the most recent uncleaned snapshot of a spec, or
+// ErrNotFound if there is none.
+func (s *SnapshotStore) FindLatest(specID ID) (*Snapshot, error) {
+	row := s.db.QueryRow(
+		"SELECT "+snapshotColumns+" FROM snapshots WHERE spec_id = $1 AND NOT cleaned ORDER BY created_at DESC LIMIT 1",
+		specID,
+	)
+
+	snapshot, err := scanSnapshot(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return snapshot, err
+}
+
+// FindBySpec returns the uncleaned snapshots of a spec.
+func (s *SnapshotStore) FindBySpec(specID ID) ([]*Snapshot, error) {
+	return s.query(
+		"SELECT "+snapshotColumns+" FROM snapshots WHERE spec_id = $1 AND NOT cleaned",
+		specID,
+	)
+}
+
+// FindExpired returns the uncleaned snapshots which were created before the
+// given time, or which have been superseded by a newer snapshot of the same
+// spec.
+func (s *SnapshotStore) FindExpired(before time.Time) ([]*Snapshot, error) {
+	return s.query(
+		"SELECT "+snapshotColumns+" FROM snapshots s WHERE NOT cleaned AND (created_at < $1 OR EXISTS ("+
+			"SELECT 1 FROM snapshots n WHERE n.spec_id = s.spec_id AND NOT n.cleaned AND n.created_at > s.created_at))",
+		before,
+	)
+}
+
+// MarkCleaned marks a snapshot as cleaned.
+func (s *SnapshotStore) MarkCleaned(snapshot *Snapshot) error {
+	snapshot.Cleaned = true
+	_, err := s.db.Exec("UPDATE snapshots SET cleaned = true WHERE id = $1", snapshot.ID)
+	return err
+}
+
+func (s *SnapshotStore) query(query string, args ...interface{}) ([]*Snapshot, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snapshots []*Snapshot
+
+	for rows.Next() {
+		snapshot, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
+
+func scanSnapshot(row rowScanner) (*Snapshot, error) {
+	var snapshot Snapshot
+
+	if err := row.Scan(&snapshot.ID, &snapshot.CreatedAt, &snapshot.SpecID, &snapshot.InstanceID, &snapshot.ImageID, &snapshot.Cleaned); err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
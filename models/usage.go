@@ -0,0 +1,109 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// AssignmentUsage summarizes how an assignment has been used.
+type AssignmentUsage struct {
+	AssignmentName  string
+	Specs           int
+	Instances       int
+	ActiveInstances int
+	// LastCreated is the creation time of the assignment's newest instance,
+	// or nil if it has none.
+	LastCreated *time.Time
+}
+
+// UsageStore queries usage statistics across specs and instances. Kallax
+// can't express aggregate queries, so it is written by hand.
+type UsageStore struct {
+	db *sql.DB
+}
+
+// NewUsageStore creates a new UsageStore.
+func NewUsageStore(db *sql.DB) *UsageStore {
+	return &UsageStore{db: db}
+}
+
+// Assignments returns the usage of every assignment which has a spec,
+// ordered by assignment name.
+func (s *UsageStore) Assignments() ([]*AssignmentUsage, error) {
+	rows, err := s.db.Query(`SELECT
+		s.assignment_name,
+		COUNT(DISTINCT s.id),
+		COUNT(i.id),
+		COALESCE(SUM(CASE WHEN i.active THEN 1 ELSE 0 END), 0),
+		MAX(i.created_at)
+	FROM specs s
+	LEFT JOIN instances i ON i.spec_id = s.id
+	GROUP BY s.assignment_name
+	ORDER BY s.assignment_name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usages []*AssignmentUsage
+
+	for rows.Next() {
+		var u AssignmentUsage
+		if err := rows.Scan(&u.AssignmentName, &u.Specs, &u.Instances, &u.ActiveInstances, nullTime{&u.LastCreated}); err != nil {
+			return nil, err
+		}
+		usages = append(usages, &u)
+	}
+
+	return usages, rows.Err()
+}
+
+// sqliteTimeFormats are the formats SQLite times may be stored in, as listed
+// by go-sqlite3 (which writes the first).
+var sqliteTimeFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// nullTime scans a nullable time computed by a query, like MAX(created_at).
+// PostgreSQL returns these as times, but SQLite only knows that a column is
+// a time when it is read directly, and returns anything else as text.
+type nullTime struct {
+	t **time.Time
+}
+
+func (n nullTime) Scan(src interface{}) error {
+	var s string
+
+	switch src := src.(type) {
+	case nil:
+		*n.t = nil
+		return nil
+	case time.Time:
+		*n.t = &src
+		return nil
+	case string:
+		s = src
+	case []byte:
+		s = string(src)
+	default:
+		return fmt.Errorf("models: cannot scan %T into time", src)
+	}
+
+	for _, format := range sqliteTimeFormats {
+		if t, err := time.Parse(format, s); err == nil {
+			*n.t = &t
+			return nil
+		}
+	}
+
+	return fmt.Errorf("models: cannot parse %q as time", s)
+}
@@ -0,0 +1,99 @@
+// Package asciicast implements a writer for the asciicast v2 terminal
+// recording format, as used by asciinema.
+//
+// See https://github.com/asciinema/asciinema/blob/develop/doc/asciicast-v2.md.
+package asciicast
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Event types, as defined by the asciicast v2 format.
+const (
+	EventOutput = "o"
+	EventInput  = "i"
+	EventResize = "r"
+)
+
+// Header is the first line of an asciicast v2 file.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     uint              `json:"width"`
+	Height    uint              `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Writer writes asciicast v2 events to an underlying writer. Event times are
+// relative to the time the Writer was created.
+//
+// Writer is safe for concurrent use.
+type Writer struct {
+	mu    sync.Mutex
+	w     io.Writer
+	enc   *json.Encoder
+	start time.Time
+	err   error
+}
+
+// NewWriter creates a new Writer, writing the header immediately. The
+// header's version and timestamp are set by NewWriter.
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	start := time.Now()
+
+	header.Version = 2
+	header.Timestamp = start.Unix()
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(header); err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		w:     w,
+		enc:   enc,
+		start: start,
+	}, nil
+}
+
+// Output records data written to the terminal.
+func (w *Writer) Output(data string) {
+	w.write(EventOutput, data)
+}
+
+// Input records data typed into the terminal.
+func (w *Writer) Input(data string) {
+	w.write(EventInput, data)
+}
+
+// Resize records a change in terminal size.
+func (w *Writer) Resize(height, width uint) {
+	w.write(EventResize, strconv.FormatUint(uint64(width), 10)+"x"+strconv.FormatUint(uint64(height), 10))
+}
+
+// Err returns the first error encountered while writing events, if any.
+// Once an error occurs, all further events are dropped.
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (w *Writer) write(typ string, data string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.err != nil {
+		return
+	}
+
+	t := time.Since(w.start).Seconds()
+	w.err = w.enc.Encode([]interface{}{t, typ, data})
+}
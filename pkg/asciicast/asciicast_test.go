@@ -0,0 +1,73 @@
+package asciicast
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := NewWriter(&buf, Header{Width: 80, Height: 24})
+	if err != nil {
+		t.Fatalf("expected nil error on NewWriter, got %s", err.Error())
+	}
+
+	w.Output("hello <world>\r\n")
+	w.Input("ls\r")
+	w.Resize(40, 120)
+
+	if err := w.Err(); err != nil {
+		t.Fatalf("expected nil error from Err, got %s", err.Error())
+	}
+
+	s := bufio.NewScanner(&buf)
+
+	if !s.Scan() {
+		t.Fatal("missing header line")
+	}
+
+	var header Header
+	if err := json.Unmarshal(s.Bytes(), &header); err != nil {
+		t.Fatalf("error decoding header: %s", err.Error())
+	}
+
+	if header.Version != 2 || header.Width != 80 || header.Height != 24 || header.Timestamp == 0 {
+		t.Fatalf("unexpected header: %+v", header)
+	}
+
+	expected := [][2]string{
+		{EventOutput, "hello <world>\r\n"},
+		{EventInput, "ls\r"},
+		{EventResize, "120x40"},
+	}
+
+	for i, e := range expected {
+		if !s.Scan() {
+			t.Fatalf("missing event %d", i)
+		}
+
+		var event []interface{}
+		if err := json.Unmarshal(s.Bytes(), &event); err != nil {
+			t.Fatalf("error decoding event %d: %s", i, err.Error())
+		}
+
+		if len(event) != 3 {
+			t.Fatalf("event %d has %d elements, expected 3", i, len(event))
+		}
+
+		if _, ok := event[0].(float64); !ok {
+			t.Fatalf("event %d has non-numeric time %v", i, event[0])
+		}
+
+		if event[1] != e[0] || event[2] != e[1] {
+			t.Fatalf("event %d is %v, expected %v", i, event, e)
+		}
+	}
+
+	if s.Scan() {
+		t.Fatalf("unexpected extra line: %s", s.Text())
+	}
+}
@@ -4,11 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"time"
 
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
 	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/runtime"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -36,10 +34,10 @@ func (e ExitCodeError) Error() string {
 //
 // Exec will wait for the program to exit before returning. Cancel the context
 // via a timeout/deadline/cancel to cause Exec to stop waiting and return.
-func Exec(ctx context.Context, cli client.CommonAPIClient, containerID string, config Config) error {
+func Exec(ctx context.Context, rt runtime.Runtime, containerID string, config Config) error {
 	logger := ctxlog.FromContext(ctx)
 
-	execConfig := types.ExecConfig{
+	execConfig := runtime.ExecConfig{
 		User:         config.User,
 		Cmd:          config.Cmd,
 		Env:          config.Env,
@@ -61,40 +59,47 @@ func Exec(ctx context.Context, cli client.CommonAPIClient, containerID string, c
 		zap.Bool("tty", execConfig.Tty),
 	)
 
-	execResp, err := cli.ContainerExecCreate(ctx, containerID, execConfig)
+	proc, err := rt.Exec(ctx, containerID, execConfig)
 	if err != nil {
 		return err
 	}
-	execID := execResp.ID
+	defer proc.Close()
 
-	hj, err := cli.ContainerExecAttach(ctx, execID, types.ExecStartCheck{Tty: config.Tty})
-	if err != nil {
-		return err
-	}
-	defer hj.Close()
+	// The copies below only end with the streams, so close them once the
+	// context is done. This doesn't stop the process itself.
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			proc.Close()
+		case <-stop:
+		}
+	}()
 
 	var g errgroup.Group
 
 	if execConfig.AttachStdin {
 		g.Go(func() error {
 			defer func() {
-				if cerr := hj.CloseWrite(); cerr != nil {
-					logger.Warn("dexec hj.CloseWrite error",
+				if cerr := proc.CloseStdin(); cerr != nil {
+					logger.Warn("dexec CloseStdin error",
 						zap.Error(cerr),
 					)
 				}
 			}()
 
-			return copyFunc(hj.Conn, config.Stdin)()
+			return copyFunc(proc.Stdin(), config.Stdin)()
 		}) // Exits when stdin or the connection closes.
 	}
 
 	if execConfig.AttachStdout {
-		g.Go(copyFunc(config.Stdout, hj.Conn)) // Exits when stdout or the connection closes.
+		g.Go(copyFunc(config.Stdout, proc.Stdout())) // Exits when stdout or the connection closes.
 	}
 
 	if execConfig.AttachStderr {
-		g.Go(copyFunc(config.Stderr, hj.Reader)) // Exits when stderr or the connection closes.
+		g.Go(copyFunc(config.Stderr, proc.Stderr())) // Exits when stderr or the connection closes.
 	}
 
 	if werr := g.Wait(); werr != nil {
@@ -103,7 +108,16 @@ func Exec(ctx context.Context, cli client.CommonAPIClient, containerID string, c
 		)
 	}
 
-	return waitForExit(ctx, cli, execID)
+	exitCode, err := proc.Wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	if exitCode != 0 {
+		return ExitCodeError(exitCode)
+	}
+
+	return nil
 }
 
 func copyFunc(w io.Writer, r io.Reader) func() error {
@@ -112,36 +126,3 @@ func copyFunc(w io.Writer, r io.Reader) func() error {
 		return err
 	}
 }
-
-func waitForExit(ctx context.Context, cli client.CommonAPIClient, execID string) error {
-	for i := 0; ; i++ {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		resp, err := cli.ContainerExecInspect(ctx, execID)
-		if err != nil {
-			return err
-		}
-
-		if resp.Running {
-			time.Sleep(10 * time.Millisecond) // Arbitrary.
-			continue
-		}
-
-		if i != 0 {
-			logger := ctxlog.FromContext(ctx)
-			logger.Debug("dexec waitForExit",
-				zap.Int("retries", i),
-			)
-		}
-
-		if resp.ExitCode != 0 {
-			return ExitCodeError(resp.ExitCode)
-		}
-
-		return nil
-	}
-}
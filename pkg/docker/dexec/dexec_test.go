@@ -0,0 +1,108 @@
+package dexec
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/jakebailey/ua/pkg/runtime"
+	"github.com/jakebailey/ua/pkg/runtime/runtimetest"
+)
+
+func startFake(t *testing.T, fn runtimetest.ExecFunc) (runtime.Runtime, string) {
+	ctx := context.Background()
+
+	rt := runtimetest.New()
+	rt.Daemon.ExecFunc = fn
+	rt.Daemon.AddImage("alpine")
+
+	containerID, err := rt.Create(ctx, "", &container.Config{Image: "alpine"}, nil)
+	if err != nil {
+		t.Fatalf("expected nil error on Create, got %s", err.Error())
+	}
+
+	if err := rt.Start(ctx, containerID); err != nil {
+		t.Fatalf("expected nil error on Start, got %s", err.Error())
+	}
+
+	return rt, containerID
+}
+
+func TestExec(t *testing.T) {
+	rt, containerID := startFake(t, func(_ string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		if _, err := io.Copy(stdout, stdin); err != nil {
+			return 1
+		}
+		_, _ = io.WriteString(stderr, strings.Join(cmd, " "))
+		return 0
+	})
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	config := Config{
+		Cmd:    []string{"cat", "-"},
+		Stdin:  strings.NewReader("Hello, World!"),
+		Stdout: stdout,
+		Stderr: stderr,
+	}
+
+	if err := Exec(context.Background(), rt, containerID, config); err != nil {
+		t.Fatalf("expected nil error on Exec, got %s", err.Error())
+	}
+
+	if stdout.String() != "Hello, World!" {
+		t.Fatalf("expected stdin to be copied to stdout, got %q", stdout.String())
+	}
+
+	if stderr.String() != "cat -" {
+		t.Fatalf("expected command on stderr, got %q", stderr.String())
+	}
+}
+
+func TestExecExitCode(t *testing.T) {
+	rt, containerID := startFake(t, func(string, []string, io.Reader, io.Writer, io.Writer) int {
+		return 3
+	})
+
+	err := Exec(context.Background(), rt, containerID, Config{Cmd: []string{"false"}})
+	if code, ok := err.(ExitCodeError); !ok || code != 3 {
+		t.Fatalf("expected ExitCodeError(3), got %v", err)
+	}
+}
+
+func TestExecNotFound(t *testing.T) {
+	rt := runtimetest.New()
+
+	err := Exec(context.Background(), rt, "missing", Config{Cmd: []string{"true"}})
+	if !runtime.IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	rt, containerID := startFake(t, func(string, []string, io.Reader, io.Writer, io.Writer) int {
+		<-done
+		return 0
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	config := Config{
+		Cmd:    []string{"sleep", "60"},
+		Stdout: &bytes.Buffer{},
+		Stderr: &bytes.Buffer{},
+	}
+
+	if err := Exec(ctx, rt, containerID, config); err != context.DeadlineExceeded {
+		t.Fatalf("expected Exec to stop waiting at the deadline, got %v", err)
+	}
+}
@@ -0,0 +1,404 @@
+package dockertest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+)
+
+// Container describes a container in the fake daemon.
+type Container struct {
+	ID         string
+	Name       string
+	ImageID    string
+	Config     *container.Config
+	HostConfig *container.HostConfig
+	Running    bool
+	Starts     int
+	Networks   []string
+}
+
+type fakeContainer struct {
+	id         string
+	name       string
+	imageID    string
+	config     *container.Config
+	hostConfig *container.HostConfig
+	created    time.Time
+
+	running  bool
+	starts   int
+	exitCode int
+
+	startedAt  time.Time
+	finishedAt time.Time
+}
+
+// Container returns a container by ID or name.
+func (c *Client) Container(ref string) (Container, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ctr, err := c.findContainer(ref)
+	if err != nil {
+		return Container{}, false
+	}
+
+	return c.describe(ctr), true
+}
+
+// Containers returns all containers, ordered by creation.
+func (c *Client) Containers() []Container {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	containers := make([]Container, 0, len(c.containers))
+	for _, ctr := range c.sortedContainers() {
+		containers = append(containers, c.describe(ctr))
+	}
+
+	return containers
+}
+
+// ContainerCreate creates a container.
+func (c *Client) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, name string) (container.ContainerCreateCreatedBody, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	img, ok := c.findImage(config.Image)
+	if !ok {
+		return container.ContainerCreateCreatedBody{}, noSuchImage(config.Image)
+	}
+
+	if name != "" {
+		if other, err := c.findContainer(name); err == nil {
+			return container.ContainerCreateCreatedBody{}, errdefs.Conflict(fmt.Errorf(`Conflict. The container name "/%s" is already in use by container "%s". You have to remove (or rename) that container to be able to reuse that name.`, name, other.id))
+		}
+	}
+
+	if hostConfig == nil {
+		hostConfig = &container.HostConfig{}
+	}
+
+	networkName := string(hostConfig.NetworkMode)
+	if networkName == "" || networkName == "default" {
+		networkName = "bridge"
+	}
+
+	net, ok := c.networks[networkName]
+	if !ok {
+		return container.ContainerCreateCreatedBody{}, noSuchNetwork(networkName)
+	}
+
+	ctr := &fakeContainer{
+		id:         c.newID(),
+		name:       name,
+		imageID:    img.id,
+		config:     config,
+		hostConfig: hostConfig,
+		created:    time.Now(),
+	}
+
+	if ctr.name == "" {
+		ctr.name = "fake_" + shortID(ctr.id)
+	}
+
+	c.containers[ctr.id] = ctr
+	net.containers[ctr.id] = true
+
+	return container.ContainerCreateCreatedBody{ID: ctr.id}, nil
+}
+
+// ContainerStart starts a container. Starting a running container does
+// nothing.
+func (c *Client) ContainerStart(ctx context.Context, ref string, options types.ContainerStartOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ctr, err := c.findContainer(ref)
+	if err != nil {
+		return err
+	}
+
+	if ctr.running {
+		return nil
+	}
+
+	ctr.running = true
+	ctr.starts++
+	ctr.exitCode = 0
+	ctr.startedAt = time.Now()
+
+	return nil
+}
+
+// ContainerStop stops a container, along with any processes exec'd in it.
+// Stopping a stopped container does nothing.
+func (c *Client) ContainerStop(ctx context.Context, ref string, timeout *time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ctr, err := c.findContainer(ref)
+	if err != nil {
+		return err
+	}
+
+	if ctr.running {
+		c.stop(ctr, 143)
+	}
+
+	return nil
+}
+
+// ContainerKill kills a running container, along with any processes exec'd
+// in it.
+func (c *Client) ContainerKill(ctx context.Context, ref, signal string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ctr, err := c.findContainer(ref)
+	if err != nil {
+		return err
+	}
+
+	if !ctr.running {
+		return errdefs.Conflict(fmt.Errorf("Cannot kill container: %s: Container %s is not running", ref, ctr.id))
+	}
+
+	c.stop(ctr, 137)
+	return nil
+}
+
+// ContainerRemove removes a container. Running containers can only be
+// removed if forced.
+func (c *Client) ContainerRemove(ctx context.Context, ref string, options types.ContainerRemoveOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ctr, err := c.findContainer(ref)
+	if err != nil {
+		return err
+	}
+
+	if ctr.running {
+		if !options.Force {
+			return errdefs.Conflict(fmt.Errorf("You cannot remove a running container %s. Stop the container before attempting removal or force remove", ctr.id))
+		}
+
+		c.stop(ctr, 137)
+	}
+
+	for _, net := range c.networks {
+		delete(net.containers, ctr.id)
+	}
+
+	delete(c.containers, ctr.id)
+	return nil
+}
+
+// ContainerRename renames a container. The new name must not be in use.
+func (c *Client) ContainerRename(ctx context.Context, ref string, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ctr, err := c.findContainer(ref)
+	if err != nil {
+		return err
+	}
+
+	if other, err := c.findContainer(name); err == nil && other != ctr {
+		return errdefs.Conflict(fmt.Errorf(`Error when allocating new name: Conflict. The container name "/%s" is already in use by container "%s". You have to remove (or rename) that container to be able to reuse that name.`, name, other.id))
+	}
+
+	ctr.name = name
+	return nil
+}
+
+// ContainerInspect inspects a container.
+func (c *Client) ContainerInspect(ctx context.Context, ref string) (types.ContainerJSON, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ctr, err := c.findContainer(ref)
+	if err != nil {
+		return types.ContainerJSON{}, err
+	}
+
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:         ctr.id,
+			Created:    ctr.created.Format(time.RFC3339Nano),
+			Name:       "/" + ctr.name,
+			Image:      ctr.imageID,
+			HostConfig: ctr.hostConfig,
+			State: &types.ContainerState{
+				Status:     ctr.status(),
+				Running:    ctr.running,
+				ExitCode:   ctr.exitCode,
+				StartedAt:  formatTime(ctr.startedAt),
+				FinishedAt: formatTime(ctr.finishedAt),
+			},
+		},
+		Config: ctr.config,
+	}, nil
+}
+
+// ContainerList lists containers. Only the "label" filter is supported.
+func (c *Client) ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var containers []types.Container
+
+	for _, ctr := range c.sortedContainers() {
+		if !ctr.running && !options.All {
+			continue
+		}
+
+		if !options.Filters.MatchKVList("label", ctr.config.Labels) {
+			continue
+		}
+
+		containers = append(containers, types.Container{
+			ID:      ctr.id,
+			Names:   []string{"/" + ctr.name},
+			Image:   ctr.config.Image,
+			ImageID: ctr.imageID,
+			Created: ctr.created.Unix(),
+			Labels:  ctr.config.Labels,
+			State:   ctr.status(),
+		})
+	}
+
+	return containers, nil
+}
+
+// ContainerCommit creates an image from a container. The image has the
+// labels of the container's image and configuration, along with any labels
+// in the options' configuration.
+func (c *Client) ContainerCommit(ctx context.Context, ref string, options types.ContainerCommitOptions) (types.IDResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ctr, err := c.findContainer(ref)
+	if err != nil {
+		return types.IDResponse{}, err
+	}
+
+	var tag string
+	if options.Reference != "" {
+		tag, err = normalize(options.Reference)
+		if err != nil {
+			return types.IDResponse{}, errdefs.InvalidParameter(err)
+		}
+	}
+
+	labels := make(map[string]string)
+	if img, ok := c.images[ctr.imageID]; ok {
+		for k, v := range img.labels {
+			labels[k] = v
+		}
+	}
+	for k, v := range ctr.config.Labels {
+		labels[k] = v
+	}
+	if options.Config != nil {
+		for k, v := range options.Config.Labels {
+			labels[k] = v
+		}
+	}
+
+	id := "sha256:" + c.newID()
+	c.images[id] = &image{id: id, labels: labels}
+
+	if tag != "" {
+		c.tags[tag] = id
+	}
+
+	return types.IDResponse{ID: id}, nil
+}
+
+func (c *Client) findContainer(ref string) (*fakeContainer, error) {
+	if ctr, ok := c.containers[ref]; ok {
+		return ctr, nil
+	}
+
+	for _, ctr := range c.containers {
+		if ctr.name == ref {
+			return ctr, nil
+		}
+	}
+
+	return nil, errdefs.NotFound(fmt.Errorf("Error: No such container: %s", ref))
+}
+
+func (c *Client) sortedContainers() []*fakeContainer {
+	containers := make([]*fakeContainer, 0, len(c.containers))
+	for _, ctr := range c.containers {
+		containers = append(containers, ctr)
+	}
+
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].id < containers[j].id
+	})
+
+	return containers
+}
+
+func (c *Client) describe(ctr *fakeContainer) Container {
+	var networks []string
+	for name, net := range c.networks {
+		if net.containers[ctr.id] {
+			networks = append(networks, name)
+		}
+	}
+	sort.Strings(networks)
+
+	return Container{
+		ID:         ctr.id,
+		Name:       ctr.name,
+		ImageID:    ctr.imageID,
+		Config:     ctr.config,
+		HostConfig: ctr.hostConfig,
+		Running:    ctr.running,
+		Starts:     ctr.starts,
+		Networks:   networks,
+	}
+}
+
+// stop stops a container and kills its execs.
+func (c *Client) stop(ctr *fakeContainer, exitCode int) {
+	ctr.running = false
+	ctr.exitCode = exitCode
+	ctr.finishedAt = time.Now()
+
+	for _, e := range c.execs {
+		if e.containerID == ctr.id {
+			e.kill()
+		}
+	}
+}
+
+func (ctr *fakeContainer) status() string {
+	switch {
+	case ctr.running:
+		return "running"
+	case ctr.starts == 0:
+		return "created"
+	default:
+		return "exited"
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "0001-01-01T00:00:00Z"
+	}
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,162 @@
+// Package dockertest provides an in-memory fake Docker client, so that code
+// which manages images and containers can be tested without a Docker daemon.
+// Code which uses the container runtime can be tested by wrapping the fake
+// with runtime.NewDocker.
+//
+// The fake tracks images, containers, execs, and networks, and returns the
+// same kinds of errors (and error messages) as the Docker daemon for the
+// common failure cases, like removing a running container. No processes are
+// actually run; exec'd processes are run by calling the Client's ExecFunc.
+//
+// Only the parts of client.CommonAPIClient used by ua are implemented.
+// Calling any other method panics.
+package dockertest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/docker/docker/api"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+// ExecFunc runs a process in the fake daemon. It returns the process's exit
+// code.
+type ExecFunc func(containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) int
+
+// Client is a fake Docker client.
+type Client struct {
+	// Unimplemented methods are left to the nil embedded interface, and
+	// will panic if called.
+	client.CommonAPIClient
+
+	// ExecFunc runs exec'd processes. If nil, processes read all of their
+	// input, then exit with code 0.
+	ExecFunc ExecFunc
+
+	mu         sync.Mutex
+	nextID     int
+	down       bool
+	host       string
+	images     map[string]*image
+	tags       map[string]string
+	containers map[string]*fakeContainer
+	execs      map[string]*fakeExec
+	networks   map[string]*fakeNetwork
+}
+
+var _ client.CommonAPIClient = (*Client)(nil)
+
+// New creates a new fake Docker client, with no images or containers, and
+// the default "bridge", "host", and "none" networks.
+func New() *Client {
+	c := &Client{
+		host:       client.DefaultDockerHost,
+		images:     make(map[string]*image),
+		tags:       make(map[string]string),
+		containers: make(map[string]*fakeContainer),
+		execs:      make(map[string]*fakeExec),
+		networks:   make(map[string]*fakeNetwork),
+	}
+
+	for _, name := range []string{"bridge", "host", "none"} {
+		c.networks[name] = &fakeNetwork{
+			id:         c.newID(),
+			name:       name,
+			builtin:    true,
+			containers: make(map[string]bool),
+		}
+	}
+
+	return c
+}
+
+// SetDown makes the fake daemon unreachable (or reachable again). While down,
+// Ping returns an error.
+func (c *Client) SetDown(down bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.down = down
+}
+
+// SetDaemonHost sets the host returned by DaemonHost. By default, it is the
+// default local Docker host.
+func (c *Client) SetDaemonHost(host string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.host = host
+}
+
+// DaemonHost returns the host of the fake daemon.
+func (c *Client) DaemonHost() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.host
+}
+
+// ClientVersion returns the API version of the fake daemon.
+func (c *Client) ClientVersion() string {
+	return api.DefaultVersion
+}
+
+// NegotiateAPIVersionPing does nothing, as the fake only has one version.
+func (c *Client) NegotiateAPIVersionPing(types.Ping) {}
+
+// Ping pings the fake daemon.
+func (c *Client) Ping(ctx context.Context) (types.Ping, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.down {
+		return types.Ping{}, fmt.Errorf("Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?")
+	}
+
+	return types.Ping{APIVersion: api.DefaultVersion, OSType: "linux"}, nil
+}
+
+// DiskUsage reports the fake daemon's images and containers, which take up
+// no space.
+func (c *Client) DiskUsage(ctx context.Context) (types.DiskUsage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var du types.DiskUsage
+
+	for id := range c.images {
+		du.Images = append(du.Images, &types.ImageSummary{ID: id})
+	}
+
+	for _, ctr := range c.sortedContainers() {
+		du.Containers = append(du.Containers, &types.Container{ID: ctr.id})
+	}
+
+	return du, nil
+}
+
+// Close does nothing.
+func (c *Client) Close() error {
+	return nil
+}
+
+func (c *Client) newID() string {
+	c.nextID++
+	return fmt.Sprintf("%064x", c.nextID)
+}
+
+func (c *Client) execFunc() ExecFunc {
+	if c.ExecFunc != nil {
+		return c.ExecFunc
+	}
+
+	return func(containerID string, cmd []string, stdin io.Reader, stdout, stderr io.Writer) int {
+		_, _ = io.Copy(ioutil.Discard, stdin)
+		return 0
+	}
+}
@@ -0,0 +1,259 @@
+package dockertest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// Exec describes a process exec'd in a container.
+type Exec struct {
+	ID          string
+	ContainerID string
+	Config      types.ExecConfig
+	Running     bool
+	ExitCode    int
+	Height      uint
+	Width       uint
+}
+
+type fakeExec struct {
+	id          string
+	containerID string
+	config      types.ExecConfig
+
+	started  bool
+	running  bool
+	killed   bool
+	exitCode int
+
+	height uint
+	width  uint
+
+	conn net.Conn
+}
+
+// Execs returns all exec'd processes, in the order they were created.
+func (c *Client) Execs() []Exec {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	execs := make([]*fakeExec, 0, len(c.execs))
+	for _, e := range c.execs {
+		execs = append(execs, e)
+	}
+
+	sort.Slice(execs, func(i, j int) bool {
+		return execs[i].id < execs[j].id
+	})
+
+	described := make([]Exec, 0, len(execs))
+	for _, e := range execs {
+		described = append(described, Exec{
+			ID:          e.id,
+			ContainerID: e.containerID,
+			Config:      e.config,
+			Running:     e.running,
+			ExitCode:    e.exitCode,
+			Height:      e.height,
+			Width:       e.width,
+		})
+	}
+
+	return described
+}
+
+// ContainerExecCreate creates an exec in a running container.
+func (c *Client) ContainerExecCreate(ctx context.Context, ref string, config types.ExecConfig) (types.IDResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ctr, err := c.findContainer(ref)
+	if err != nil {
+		return types.IDResponse{}, err
+	}
+
+	if !ctr.running {
+		return types.IDResponse{}, errdefs.Conflict(fmt.Errorf("Container %s is not running", ctr.id))
+	}
+
+	e := &fakeExec{
+		id:          c.newID(),
+		containerID: ctr.id,
+		config:      config,
+	}
+	c.execs[e.id] = e
+
+	return types.IDResponse{ID: e.id}, nil
+}
+
+// ContainerExecAttach starts an exec, and attaches to it. As with the
+// Docker daemon, output is multiplexed (see stdcopy) unless the exec has a
+// TTY. The returned connection supports CloseWrite.
+func (c *Client) ContainerExecAttach(ctx context.Context, execID string, config types.ExecStartCheck) (types.HijackedResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.execs[execID]
+	if !ok {
+		return types.HijackedResponse{}, noSuchExec(execID)
+	}
+
+	if e.started {
+		return types.HijackedResponse{}, errdefs.Conflict(fmt.Errorf("Error: Exec command %s is already running", execID))
+	}
+
+	if ctr, ok := c.containers[e.containerID]; !ok || !ctr.running {
+		return types.HijackedResponse{}, errdefs.Conflict(fmt.Errorf("Container %s is not running", e.containerID))
+	}
+
+	clientConn, serverConn, err := connPair()
+	if err != nil {
+		return types.HijackedResponse{}, err
+	}
+
+	e.started = true
+	e.running = true
+	e.conn = serverConn
+
+	go c.run(e, c.execFunc()) // Exits when ExecFunc returns.
+
+	return types.HijackedResponse{
+		Conn:   clientConn,
+		Reader: bufio.NewReader(clientConn),
+	}, nil
+}
+
+// ContainerExecInspect inspects an exec.
+func (c *Client) ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.execs[execID]
+	if !ok {
+		return types.ContainerExecInspect{}, noSuchExec(execID)
+	}
+
+	return types.ContainerExecInspect{
+		ExecID:      e.id,
+		ContainerID: e.containerID,
+		Running:     e.running,
+		ExitCode:    e.exitCode,
+	}, nil
+}
+
+// ContainerExecResize resizes an exec's TTY.
+func (c *Client) ContainerExecResize(ctx context.Context, execID string, options types.ResizeOptions) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.execs[execID]
+	if !ok {
+		return noSuchExec(execID)
+	}
+
+	if !e.running {
+		return errdefs.Conflict(fmt.Errorf("Exec %s is not running", execID))
+	}
+
+	e.height = options.Height
+	e.width = options.Width
+	return nil
+}
+
+func (c *Client) run(e *fakeExec, fn ExecFunc) {
+	var stdin io.Reader = strings.NewReader("")
+	if e.config.AttachStdin {
+		stdin = e.conn
+	}
+
+	var stdout, stderr io.Writer = ioutil.Discard, ioutil.Discard
+
+	switch {
+	case e.config.Tty:
+		if e.config.AttachStdout || e.config.AttachStderr {
+			stdout = e.conn
+			stderr = e.conn
+		}
+	default:
+		if e.config.AttachStdout {
+			stdout = stdcopy.NewStdWriter(e.conn, stdcopy.Stdout)
+		}
+		if e.config.AttachStderr {
+			stderr = stdcopy.NewStdWriter(e.conn, stdcopy.Stderr)
+		}
+	}
+
+	exitCode := fn(e.containerID, e.config.Cmd, stdin, stdout, stderr)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e.running = false
+	e.exitCode = exitCode
+	if e.killed {
+		e.exitCode = 137
+	}
+
+	e.conn.Close()
+}
+
+// kill kills a running exec, closing its connection. The exec's ExecFunc
+// will see its input end, and its output fail.
+func (e *fakeExec) kill() {
+	if !e.running {
+		return
+	}
+
+	e.killed = true
+	e.conn.Close()
+}
+
+// connPair returns a connected pair of TCP connections over the loopback
+// interface. Unlike net.Pipe, these support CloseWrite, as the connections
+// hijacked from the Docker daemon do.
+func connPair() (client net.Conn, server net.Conn, err error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer l.Close()
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+
+	accepted := make(chan result, 1)
+
+	go func() {
+		conn, err := l.Accept()
+		accepted <- result{conn, err}
+	}() // Exits when a connection is accepted, or the listener closes.
+
+	client, err = net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r := <-accepted
+	if r.err != nil {
+		client.Close()
+		return nil, nil, r.err
+	}
+
+	return client, r.conn, nil
+}
+
+func noSuchExec(execID string) error {
+	return errdefs.NotFound(fmt.Errorf("No such exec instance: %s", execID))
+}
@@ -0,0 +1,301 @@
+package dockertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+type image struct {
+	id     string
+	labels map[string]string
+}
+
+// AddImage adds an image, as though it had been pulled or built, and returns
+// its ID.
+func (c *Client) AddImage(ref string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.addImage(ref)
+}
+
+// HasImage reports whether an image exists, by ID or reference.
+func (c *Client) HasImage(ref string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.findImage(ref)
+	return ok
+}
+
+// Tags returns all image tags, in their normalized forms (like
+// "alpine:latest"), sorted.
+func (c *Client) Tags() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tags := make([]string, 0, len(c.tags))
+	for tag := range c.tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	return tags
+}
+
+// ImageInspectWithRaw inspects an image.
+func (c *Client) ImageInspectWithRaw(ctx context.Context, ref string) (types.ImageInspect, []byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	img, ok := c.findImage(ref)
+	if !ok {
+		return types.ImageInspect{}, nil, noSuchImage(ref)
+	}
+
+	inspect := types.ImageInspect{
+		ID:       img.id,
+		RepoTags: c.imageTags(img.id),
+		Config: &container.Config{
+			Labels: img.labels,
+		},
+	}
+
+	raw, err := json.Marshal(inspect)
+	if err != nil {
+		return types.ImageInspect{}, nil, err
+	}
+
+	return inspect, raw, nil
+}
+
+// ImagePull "pulls" an image. Any well-formed reference can be pulled.
+func (c *Client) ImagePull(ctx context.Context, ref string, options types.ImagePullOptions) (io.ReadCloser, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := reference.ParseNormalizedNamed(ref); err != nil {
+		return nil, errdefs.InvalidParameter(err)
+	}
+
+	c.addImage(ref)
+
+	return ioutil.NopCloser(strings.NewReader(`{"status":"Downloaded newer image for ` + ref + `"}` + "\n")), nil
+}
+
+// ImageTag tags an image.
+func (c *Client) ImageTag(ctx context.Context, source, target string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	img, ok := c.findImage(source)
+	if !ok {
+		return noSuchImage(source)
+	}
+
+	tag, err := normalize(target)
+	if err != nil {
+		return errdefs.InvalidParameter(err)
+	}
+
+	c.tags[tag] = img.id
+	return nil
+}
+
+// ImageBuild "builds" an image. The build context is read and discarded,
+// and the resulting image is tagged with the given tags.
+func (c *Client) ImageBuild(ctx context.Context, buildContext io.Reader, options types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+	if _, err := io.Copy(ioutil.Discard, buildContext); err != nil {
+		return types.ImageBuildResponse{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := "sha256:" + c.newID()
+	c.images[id] = &image{id: id}
+
+	for _, t := range options.Tags {
+		tag, err := normalize(t)
+		if err != nil {
+			return types.ImageBuildResponse{}, errdefs.InvalidParameter(err)
+		}
+		c.tags[tag] = id
+	}
+
+	aux, err := json.Marshal(types.BuildResult{ID: id})
+	if err != nil {
+		return types.ImageBuildResponse{}, err
+	}
+	auxRaw := json.RawMessage(aux)
+
+	body := &bytes.Buffer{}
+	enc := json.NewEncoder(body)
+
+	for _, jm := range []jsonmessage.JSONMessage{
+		{Stream: "Step 1/1 : FROM scratch\n"},
+		{Aux: &auxRaw},
+		{Stream: "Successfully built " + id + "\n"},
+	} {
+		if err := enc.Encode(jm); err != nil {
+			return types.ImageBuildResponse{}, err
+		}
+	}
+
+	return types.ImageBuildResponse{
+		Body:   ioutil.NopCloser(body),
+		OSType: "linux",
+	}, nil
+}
+
+// ImageRemove removes an image. If ref is a tag, only that tag is removed,
+// unless it was the image's last tag. Images used by containers cannot be
+// removed.
+func (c *Client) ImageRemove(ctx context.Context, ref string, options types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	img, ok := c.findImage(ref)
+	if !ok {
+		return nil, noSuchImage(ref)
+	}
+
+	var deleted []types.ImageDeleteResponseItem
+
+	if _, isID := c.images[ref]; !isID {
+		tag, _ := normalize(ref)
+
+		if len(c.imageTags(img.id)) > 1 {
+			delete(c.tags, tag)
+			return []types.ImageDeleteResponseItem{{Untagged: tag}}, nil
+		}
+
+		if !options.Force {
+			if err := c.checkImageUnused(ref, img.id, false); err != nil {
+				return nil, err
+			}
+		}
+
+		delete(c.tags, tag)
+		deleted = append(deleted, types.ImageDeleteResponseItem{Untagged: tag})
+	} else {
+		if !options.Force {
+			if len(c.imageTags(img.id)) > 1 {
+				return nil, errdefs.Conflict(fmt.Errorf("conflict: unable to delete %s (must be forced) - image is referenced in multiple repositories", shortID(img.id)))
+			}
+
+			if err := c.checkImageUnused(ref, img.id, true); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, tag := range c.imageTags(img.id) {
+			delete(c.tags, tag)
+			deleted = append(deleted, types.ImageDeleteResponseItem{Untagged: tag})
+		}
+	}
+
+	delete(c.images, img.id)
+	deleted = append(deleted, types.ImageDeleteResponseItem{Deleted: img.id})
+
+	return deleted, nil
+}
+
+func (c *Client) addImage(ref string) string {
+	if img, ok := c.findImage(ref); ok {
+		return img.id
+	}
+
+	id := "sha256:" + c.newID()
+	c.images[id] = &image{id: id}
+
+	if tag, err := normalize(ref); err == nil {
+		c.tags[tag] = id
+	}
+
+	return id
+}
+
+func (c *Client) findImage(ref string) (*image, bool) {
+	if img, ok := c.images[ref]; ok {
+		return img, true
+	}
+
+	tag, err := normalize(ref)
+	if err != nil {
+		return nil, false
+	}
+
+	id, ok := c.tags[tag]
+	if !ok {
+		return nil, false
+	}
+
+	return c.images[id], true
+}
+
+func (c *Client) imageTags(id string) []string {
+	var tags []string
+	for tag, other := range c.tags {
+		if other == id {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+func (c *Client) checkImageUnused(ref string, id string, byID bool) error {
+	for _, ctr := range c.containers {
+		if ctr.imageID != id {
+			continue
+		}
+
+		if !byID {
+			return errdefs.Conflict(fmt.Errorf("conflict: unable to remove repository reference %q (must force) - container %s is using its referenced image %s", ref, shortID(ctr.id), shortID(id)))
+		}
+
+		if ctr.running {
+			return errdefs.Conflict(fmt.Errorf("conflict: unable to delete %s (cannot be forced) - image is being used by running container %s", shortID(id), shortID(ctr.id)))
+		}
+
+		return errdefs.Conflict(fmt.Errorf("conflict: unable to delete %s (must be forced) - image is being used by stopped container %s", shortID(id), shortID(ctr.id)))
+	}
+
+	return nil
+}
+
+// normalize converts an image reference to its familiar form, with a tag,
+// as the Docker daemon does when matching references.
+func normalize(ref string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", err
+	}
+
+	return reference.FamiliarString(reference.TagNameOnly(named)), nil
+}
+
+func noSuchImage(ref string) error {
+	return errdefs.NotFound(fmt.Errorf("Error: No such image: %s", ref))
+}
+
+func shortID(id string) string {
+	id = strings.TrimPrefix(id, "sha256:")
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,147 @@
+package dockertest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+)
+
+type fakeNetwork struct {
+	id       string
+	name     string
+	builtin  bool
+	internal bool
+	labels   map[string]string
+
+	containers map[string]bool
+}
+
+// HasNetwork reports whether a network exists.
+func (c *Client) HasNetwork(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, ok := c.networks[name]
+	return ok
+}
+
+// NetworkInspect inspects a network.
+func (c *Client) NetworkInspect(ctx context.Context, name string, options types.NetworkInspectOptions) (types.NetworkResource, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	net, ok := c.networks[name]
+	if !ok {
+		return types.NetworkResource{}, noSuchNetwork(name)
+	}
+
+	containers := make(map[string]types.EndpointResource, len(net.containers))
+	for id := range net.containers {
+		containers[id] = types.EndpointResource{Name: c.containers[id].name}
+	}
+
+	return types.NetworkResource{
+		Name:       net.name,
+		ID:         net.id,
+		Internal:   net.internal,
+		Labels:     net.labels,
+		Containers: containers,
+	}, nil
+}
+
+// NetworkCreate creates a network.
+func (c *Client) NetworkCreate(ctx context.Context, name string, options types.NetworkCreate) (types.NetworkCreateResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.networks[name]; ok {
+		return types.NetworkCreateResponse{}, errdefs.Conflict(fmt.Errorf("network with name %s already exists", name))
+	}
+
+	net := &fakeNetwork{
+		id:         c.newID(),
+		name:       name,
+		internal:   options.Internal,
+		labels:     options.Labels,
+		containers: make(map[string]bool),
+	}
+	c.networks[name] = net
+
+	return types.NetworkCreateResponse{ID: net.id}, nil
+}
+
+// NetworkRemove removes a network. Networks with containers attached cannot
+// be removed.
+func (c *Client) NetworkRemove(ctx context.Context, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	net, ok := c.networks[name]
+	if !ok {
+		return noSuchNetwork(name)
+	}
+
+	if net.builtin {
+		return errdefs.Forbidden(fmt.Errorf("%s is a pre-defined network and cannot be removed", name))
+	}
+
+	if len(net.containers) != 0 {
+		return errdefs.Forbidden(fmt.Errorf("error while removing network: network %s id %s has active endpoints", name, net.id))
+	}
+
+	delete(c.networks, name)
+	return nil
+}
+
+// NetworkConnect connects a container to a network.
+func (c *Client) NetworkConnect(ctx context.Context, name, ref string, config *network.EndpointSettings) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	net, ok := c.networks[name]
+	if !ok {
+		return noSuchNetwork(name)
+	}
+
+	ctr, err := c.findContainer(ref)
+	if err != nil {
+		return err
+	}
+
+	if net.containers[ctr.id] {
+		return errdefs.Forbidden(fmt.Errorf("endpoint with name %s already exists in network %s", ctr.name, name))
+	}
+
+	net.containers[ctr.id] = true
+	return nil
+}
+
+// NetworkDisconnect disconnects a container from a network.
+func (c *Client) NetworkDisconnect(ctx context.Context, name, ref string, force bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	net, ok := c.networks[name]
+	if !ok {
+		return noSuchNetwork(name)
+	}
+
+	ctr, err := c.findContainer(ref)
+	if err != nil {
+		return err
+	}
+
+	if !net.containers[ctr.id] {
+		return errdefs.Forbidden(fmt.Errorf("container %s is not connected to network %s", ctr.id, name))
+	}
+
+	delete(net.containers, ctr.id)
+	return nil
+}
+
+func noSuchNetwork(name string) error {
+	return errdefs.NotFound(fmt.Errorf("Error: No such network: %s", name))
+}
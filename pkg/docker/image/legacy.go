@@ -3,13 +3,10 @@ package image
 import (
 	"bytes"
 	"compress/gzip"
-	"context"
 	"encoding/base64"
 	"encoding/json"
 	"path/filepath"
 	"text/template"
-
-	"github.com/docker/docker/client"
 )
 
 const (
@@ -57,29 +54,28 @@ var legacyFuncs = template.FuncMap{
 	},
 }
 
-// BuildLegacy builds a docker image on the given docker client. The process used
-// differs from the "normal" docker build process in that the Dockerfile is
-// a template, and exists outside of the normal build directory. A typical
-// layout looks like:
+// RenderLegacy executes a legacy assignment's Dockerfile template, returning
+// the Dockerfile and the path of the build context to build it with. Unlike
+// the "normal" docker build process, the Dockerfile is a template, and exists
+// outside of the build context. A typical layout looks like:
 //
 //     test
 //     +-- context
-//     |   +-- helloworld.txt
+//     |   +-- helloworld.txt
 //     +-- Dockerfile.tmpl
-func BuildLegacy(ctx context.Context, cli client.CommonAPIClient, path string, tag string, tmplData interface{}) (string, error) {
+func RenderLegacy(path string, tmplData interface{}) (dockerfile string, contextPath string, err error) {
 	tmplPath := filepath.Join(path, LegacyTemplateName)
-	contextPath := filepath.Join(path, LegacyContextSubdir)
+	contextPath = filepath.Join(path, LegacyContextSubdir)
 
 	tmpl, err := template.New(LegacyTemplateName).Funcs(legacyFuncs).Option("missingkey=error").ParseFiles(tmplPath)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	tmplBuf := &bytes.Buffer{}
 	if err := tmpl.Execute(tmplBuf, tmplData); err != nil {
-		return "", err
+		return "", "", err
 	}
-	dockerfile := tmplBuf.String()
 
-	return Build(ctx, cli, tag, dockerfile, contextPath)
+	return tmplBuf.String(), contextPath, nil
 }
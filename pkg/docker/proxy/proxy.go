@@ -6,9 +6,8 @@ import (
 	"io"
 	"time"
 
-	"github.com/docker/docker/api/types"
-	"github.com/docker/docker/client"
 	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/runtime"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -21,6 +20,33 @@ type Conn interface {
 	IsClose(error) bool
 }
 
+// RawConn is a Conn which can send and receive terminal data as raw bytes,
+// skipping JSON encoding. Other messages are still sent as JSON.
+type RawConn interface {
+	Conn
+
+	// Raw reports whether raw framing is in use. If false, the other RawConn
+	// methods should not be used.
+	Raw() bool
+
+	// ReadRaw reads the next message. If the message is terminal data,
+	// it is returned with isData set to true. Otherwise, the message is
+	// decoded as JSON into v.
+	ReadRaw(v interface{}) (data []byte, isData bool, err error)
+
+	// WriteRaw writes terminal data. Implementations must not retain p.
+	WriteRaw(p []byte) error
+}
+
+// AsRaw returns conn as a RawConn if it implements RawConn and raw framing
+// is in use, otherwise nil.
+func AsRaw(conn Conn) RawConn {
+	if raw, ok := conn.(RawConn); ok && raw.Raw() {
+		return raw
+	}
+	return nil
+}
+
 // Command is the configuration for a proxy'd command.
 type Command struct {
 	User       string
@@ -29,15 +55,24 @@ type Command struct {
 	WorkingDir string
 }
 
-// Proxy attaches to a docker container and proxies its stdin/out/err
-// over a websocket using the terminado protocol.
-func Proxy(ctx context.Context, id string, conn Conn, cli client.CommonAPIClient, command Command) error {
+// Recorder records the terminal data that passes through a proxy.
+// Implementations must be safe for concurrent use.
+type Recorder interface {
+	Input(data string)
+	Output(data string)
+	Resize(height, width uint)
+}
+
+// Proxy runs a command in a container and proxies its stdin/out/err
+// over a websocket using the terminado protocol. If rec is not nil, then
+// all stdin, stdout, and resize messages are recorded to it.
+func Proxy(ctx context.Context, id string, conn Conn, rt runtime.Runtime, command Command, rec Recorder) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	logger := ctxlog.FromContext(ctx)
 
-	execConfig := types.ExecConfig{
+	execConfig := runtime.ExecConfig{
 		User:         command.User,
 		Cmd:          command.Cmd,
 		Env:          command.Env,
@@ -52,37 +87,25 @@ func Proxy(ctx context.Context, id string, conn Conn, cli client.CommonAPIClient
 		zap.Any("exec_config", execConfig),
 	)
 
-	execResp, err := cli.ContainerExecCreate(ctx, id, execConfig)
+	proc, err := rt.Exec(ctx, id, execConfig)
 	if err != nil {
 		return err
 	}
-	execID := execResp.ID
-
-	ctx, logger = ctxlog.FromContextWith(ctx,
-		zap.String("exec_id", execID),
-	)
-
-	logger.Debug("attaching to exec")
-
-	hj, err := cli.ContainerExecAttach(ctx, execID, types.ExecStartCheck{Tty: true})
-	if err != nil {
-		return err
-	}
-	defer hj.Close()
+	defer proc.Close()
 
 	logger.Info("proxying")
 
 	g, ctx := errgroup.WithContext(ctx)
 
-	// These exit when the context is cancelled, or the hijacked connection or
+	// These exit when the context is cancelled, or the process streams or
 	// proxy connection close.
-	g.Go(proxyInputFunc(ctx, execID, conn, cli, hj.Conn))
-	g.Go(proxyOutputFunc(ctx, conn, hj.Conn, "stdout"))
-	g.Go(proxyOutputFunc(ctx, conn, hj.Reader, "stderr"))
+	g.Go(proxyInputFunc(ctx, conn, proc, rec))
+	g.Go(proxyOutputFunc(ctx, conn, proc.Stdout(), "stdout", rec))
+	g.Go(proxyOutputFunc(ctx, conn, proc.Stderr(), "stderr", rec))
 
 	g.Go(func() error {
 		<-ctx.Done()
-		hj.Close()
+		proc.Close()
 		return conn.Close()
 	}) // Exits when the context is cancelled.
 
@@ -93,7 +116,7 @@ func Proxy(ctx context.Context, id string, conn Conn, cli client.CommonAPIClient
 	return nil
 }
 
-func proxyInputFunc(ctx context.Context, id string, conn Conn, cli client.CommonAPIClient, writer io.Writer) func() error {
+func proxyInputFunc(ctx context.Context, conn Conn, proc runtime.Process, rec Recorder) func() error {
 	return func() error {
 		ctx, logger := ctxlog.FromContextWith(ctx,
 			zap.String("pipe", "stdin"),
@@ -102,16 +125,50 @@ func proxyInputFunc(ctx context.Context, id string, conn Conn, cli client.Common
 		defer logger.Debug("proxy stopping")
 		logger.Debug("proxy starting")
 
+		writer := proc.Stdin()
+		raw := AsRaw(conn)
+
 		var buf []interface{}
 		for {
-			err := conn.ReadJSON(&buf)
-			if err != nil {
+			if raw != nil {
+				data, isData, err := raw.ReadRaw(&buf)
+				if err != nil {
+					return err
+				}
+
+				if isData {
+					if rec != nil {
+						rec.Input(string(data))
+					}
+
+					if _, err := writer.Write(data); err != nil {
+						return err
+					}
+					continue
+				}
+			} else if err := conn.ReadJSON(&buf); err != nil {
 				return err
 			}
 
+			if len(buf) == 0 {
+				continue
+			}
+
 			switch buf[0] {
 			case "stdin":
-				if _, err := writer.Write([]byte(buf[1].(string))); err != nil {
+				data, ok := buf[1].(string)
+				if !ok {
+					logger.Warn("invalid stdin",
+						zap.Any("bad_stdin", buf[1]),
+					)
+					continue
+				}
+
+				if rec != nil {
+					rec.Input(data)
+				}
+
+				if _, err := writer.Write([]byte(data)); err != nil {
 					return err
 				}
 			case "set_size":
@@ -138,16 +195,15 @@ func proxyInputFunc(ctx context.Context, id string, conn Conn, cli client.Common
 					continue
 				}
 
-				resizeOptions := types.ResizeOptions{
-					Height: height,
-					Width:  width,
+				if rec != nil {
+					rec.Resize(height, width)
 				}
 
 				var i int
 				var resizeErr error
 
 				for i = 0; i < 5; i++ {
-					resizeErr = cli.ContainerExecResize(ctx, id, resizeOptions)
+					resizeErr = proc.Resize(ctx, height, width)
 					if resizeErr == nil {
 						break
 					}
@@ -177,7 +233,7 @@ func proxyInputFunc(ctx context.Context, id string, conn Conn, cli client.Common
 	}
 }
 
-func proxyOutputFunc(ctx context.Context, conn Conn, reader io.Reader, name string) func() error {
+func proxyOutputFunc(ctx context.Context, conn Conn, reader io.Reader, name string, rec Recorder) func() error {
 	return func() error {
 		logger := ctxlog.FromContext(ctx).With(
 			zap.String("pipe", name),
@@ -190,6 +246,10 @@ func proxyOutputFunc(ctx context.Context, conn Conn, reader io.Reader, name stri
 			return err
 		}
 
+		if raw := AsRaw(conn); raw != nil {
+			return proxyOutputRaw(raw, reader, rec)
+		}
+
 		s := bufio.NewScanner(reader)
 		s.Split(ScanRunesGreedy)
 
@@ -198,7 +258,13 @@ func proxyOutputFunc(ctx context.Context, conn Conn, reader io.Reader, name stri
 				return err
 			}
 
-			if err := conn.WriteJSON([]string{"stdout", s.Text()}); err != nil {
+			text := s.Text()
+
+			if rec != nil {
+				rec.Output(text)
+			}
+
+			if err := conn.WriteJSON([]string{"stdout", text}); err != nil {
 				return err
 			}
 		}
@@ -206,3 +272,27 @@ func proxyOutputFunc(ctx context.Context, conn Conn, reader io.Reader, name stri
 		return io.EOF
 	}
 }
+
+// proxyOutputRaw copies output directly to a RawConn, avoiding the rune
+// scanning and JSON encoding needed for the default protocol.
+func proxyOutputRaw(conn RawConn, reader io.Reader, rec Recorder) error {
+	var carry runeCarry
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if rec != nil {
+				rec.Output(carry.text(buf[:n]))
+			}
+
+			if werr := conn.WriteRaw(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"io"
+	"unicode/utf8"
 )
 
 // RuneCopy is like io.Copy, but only writes valid runes, waiting for more input if invalid.
@@ -50,3 +51,40 @@ func ScanRunesGreedy(data []byte, atEOF bool) (advance int, token []byte, err er
 
 	return advance, buf.Bytes(), err
 }
+
+// runeCarry holds incomplete trailing UTF-8 sequences between chunks of
+// data, so that data can be converted to text on rune boundaries.
+type runeCarry struct {
+	pending []byte
+}
+
+// text returns as much of the pending data plus p as can be converted
+// without splitting a rune, holding onto the remainder.
+func (c *runeCarry) text(p []byte) string {
+	if len(c.pending) != 0 {
+		p = append(c.pending, p...)
+		c.pending = nil
+	}
+
+	n := fullRunesLen(p)
+	if n < len(p) {
+		c.pending = append([]byte(nil), p[n:]...)
+	}
+
+	return string(p[:n])
+}
+
+// fullRunesLen returns the length of the longest prefix of p which does not
+// end with an incomplete UTF-8 sequence.
+func fullRunesLen(p []byte) int {
+	for i := len(p) - 1; i >= 0 && i > len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if utf8.FullRune(p[i:]) {
+				return len(p)
+			}
+			return i
+		}
+	}
+
+	return len(p)
+}
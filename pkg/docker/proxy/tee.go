@@ -0,0 +1,175 @@
+package proxy
+
+import (
+	"sync"
+)
+
+// observerBuffer is the number of messages which can be queued for an
+// observer before it is considered too slow and detached.
+const observerBuffer = 256
+
+// TeeConn wraps a Conn, copying every message written to it to a set of
+// attached observer Conns. Only the wrapped Conn is ever read from, so
+// observers cannot send input.
+//
+// Writes to observers are queued and sent asynchronously, so that a slow
+// observer cannot block the wrapped Conn. Observers which fall too far
+// behind are detached and closed.
+type TeeConn struct {
+	Conn
+
+	mu        sync.Mutex
+	observers map[Conn]chan interface{}
+	closed    bool
+}
+
+var _ RawConn = (*TeeConn)(nil)
+
+// rawMessage is terminal data queued for an observer.
+type rawMessage []byte
+
+// NewTeeConn creates a new TeeConn wrapping conn.
+func NewTeeConn(conn Conn) *TeeConn {
+	return &TeeConn{
+		Conn:      conn,
+		observers: make(map[Conn]chan interface{}),
+	}
+}
+
+// WriteJSON writes to the wrapped Conn, then queues the message for all
+// observers. The returned error is that of the wrapped Conn.
+func (t *TeeConn) WriteJSON(v interface{}) error {
+	err := t.Conn.WriteJSON(v)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for conn, ch := range t.observers {
+		select {
+		case ch <- v:
+		default:
+			t.detach(conn)
+		}
+	}
+
+	return err
+}
+
+// Raw reports whether the wrapped Conn uses raw framing.
+func (t *TeeConn) Raw() bool {
+	return AsRaw(t.Conn) != nil
+}
+
+// ReadRaw reads from the wrapped Conn. It must only be called if Raw
+// returns true.
+func (t *TeeConn) ReadRaw(v interface{}) (data []byte, isData bool, err error) {
+	return t.Conn.(RawConn).ReadRaw(v)
+}
+
+// WriteRaw writes terminal data to the wrapped Conn, then queues a copy
+// for all observers. It must only be called if Raw returns true. Observers
+// which don't use raw framing are sent the data as JSON.
+func (t *TeeConn) WriteRaw(p []byte) error {
+	err := t.Conn.(RawConn).WriteRaw(p)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.observers) == 0 {
+		return err
+	}
+
+	msg := rawMessage(append([]byte(nil), p...))
+
+	for conn, ch := range t.observers {
+		select {
+		case ch <- msg:
+		default:
+			t.detach(conn)
+		}
+	}
+
+	return err
+}
+
+// Attach attaches an observer. Attach returns false if the TeeConn has
+// already been closed, in which case the observer is not attached.
+func (t *TeeConn) Attach(conn Conn) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return false
+	}
+
+	if _, ok := t.observers[conn]; ok {
+		return true
+	}
+
+	ch := make(chan interface{}, observerBuffer)
+	t.observers[conn] = ch
+
+	go t.observe(conn, ch) // Exits when the observer is detached.
+
+	return true
+}
+
+// Detach detaches an observer, closing it once all queued messages have
+// been sent. If the observer isn't attached, Detach does nothing.
+func (t *TeeConn) Detach(conn Conn) {
+	t.mu.Lock()
+	t.detach(conn)
+	t.mu.Unlock()
+}
+
+// Observers returns the number of currently attached observers.
+func (t *TeeConn) Observers() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.observers)
+}
+
+// Close detaches all observers, then closes the wrapped Conn.
+func (t *TeeConn) Close() error {
+	t.mu.Lock()
+	t.closed = true
+	for conn := range t.observers {
+		t.detach(conn)
+	}
+	t.mu.Unlock()
+
+	return t.Conn.Close()
+}
+
+func (t *TeeConn) detach(conn Conn) {
+	if ch, ok := t.observers[conn]; ok {
+		delete(t.observers, conn)
+		close(ch)
+	}
+}
+
+func (t *TeeConn) observe(conn Conn, ch <-chan interface{}) {
+	defer conn.Close()
+
+	raw := AsRaw(conn)
+	var carry runeCarry
+
+	for v := range ch {
+		var err error
+
+		if msg, ok := v.(rawMessage); ok {
+			if raw != nil {
+				err = raw.WriteRaw(msg)
+			} else {
+				err = conn.WriteJSON([]string{"stdout", carry.text(msg)})
+			}
+		} else {
+			err = conn.WriteJSON(v)
+		}
+
+		if err != nil {
+			t.Detach(conn)
+			return
+		}
+	}
+}
@@ -10,19 +10,33 @@ import (
 	"github.com/jakebailey/ua/pkg/errhack"
 )
 
+// RawProtocol is the websocket subprotocol a client can request to use raw
+// framing. With raw framing, terminal data is sent in both directions as
+// binary messages containing the raw bytes, rather than JSON encoded
+// "stdin" and "stdout" messages. All other messages (set_size, wipe, etc)
+// are sent as JSON text messages, as in the default protocol.
+const RawProtocol = "ua.raw"
+
 // WSConn wraps a gobwas/ws connection.
 type WSConn struct {
-	c  net.Conn
-	mu sync.Mutex
+	c   net.Conn
+	mu  sync.Mutex
+	raw bool
 }
 
-var _ Conn = (*WSConn)(nil)
+var _ RawConn = (*WSConn)(nil)
 
 // NewWSConn creates a new WSConn from a net.Conn.
 func NewWSConn(conn net.Conn) *WSConn {
 	return &WSConn{c: conn}
 }
 
+// NewRawWSConn creates a new WSConn from a net.Conn, where the client has
+// negotiated RawProtocol.
+func NewRawWSConn(conn net.Conn) *WSConn {
+	return &WSConn{c: conn, raw: true}
+}
+
 // ReadJSON parses the next text websocket text message into JSON.
 func (w *WSConn) ReadJSON(v interface{}) error {
 	buf, err := wsutil.ReadClientText(w.c)
@@ -47,6 +61,35 @@ func (w *WSConn) WriteJSON(v interface{}) error {
 	return err
 }
 
+// Raw returns true if the connection uses RawProtocol.
+func (w *WSConn) Raw() bool {
+	return w.raw
+}
+
+// ReadRaw reads the next websocket message. Binary messages are returned as
+// terminal data. Text messages are parsed into v as JSON.
+func (w *WSConn) ReadRaw(v interface{}) (data []byte, isData bool, err error) {
+	buf, op, err := wsutil.ReadClientData(w.c)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if op == ws.OpBinary {
+		return buf, true, nil
+	}
+
+	return nil, false, json.Unmarshal(buf, v)
+}
+
+// WriteRaw writes terminal data as a binary websocket message. It is safe
+// for concurrent use.
+func (w *WSConn) WriteRaw(p []byte) error {
+	w.mu.Lock()
+	err := wsutil.WriteServerBinary(w.c, p)
+	w.mu.Unlock()
+	return err
+}
+
 // Close closes the connection.
 func (w *WSConn) Close() error {
 	return w.c.Close()
@@ -0,0 +1,112 @@
+// Package events implements a simple publish/subscribe event bus.
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Bus broadcasts published events to all of its subscribers. Publishing never
+// blocks; if a subscriber isn't keeping up, events sent to it are dropped.
+type Bus struct {
+	buffer int
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewBus creates a new Bus, where each subscriber can have up to buffer
+// events waiting to be received.
+func NewBus(buffer int) *Bus {
+	return &Bus{
+		buffer: buffer,
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish sends an event to all current subscribers.
+func (b *Bus) Publish(event interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subs {
+		select {
+		case s.c <- event:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	}
+}
+
+// Subscribe creates a new subscription, which will receive all events
+// published until it is closed. If the bus has been closed, the returned
+// subscription's channel is already closed.
+func (b *Bus) Subscribe() *Subscription {
+	s := &Subscription{
+		bus: b,
+		c:   make(chan interface{}, b.buffer),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(s.c)
+		return s
+	}
+
+	b.subs[s] = struct{}{}
+	return s
+}
+
+// Close closes all subscriptions, and prevents new ones from receiving
+// events.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subs {
+		close(s.c)
+		delete(b.subs, s)
+	}
+
+	b.closed = true
+}
+
+// Len returns the number of current subscribers.
+func (b *Bus) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Subscription receives events from a Bus.
+type Subscription struct {
+	bus     *Bus
+	c       chan interface{}
+	dropped uint64
+}
+
+// C returns the channel events are received on. The channel is closed when
+// either the subscription or its bus is closed.
+func (s *Subscription) C() <-chan interface{} {
+	return s.c
+}
+
+// Dropped returns the number of events which were dropped because the
+// subscription's buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Close stops the subscription. It is safe to call Close more than once.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	if _, ok := s.bus.subs[s]; ok {
+		close(s.c)
+		delete(s.bus.subs, s)
+	}
+}
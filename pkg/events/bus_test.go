@@ -0,0 +1,55 @@
+package events
+
+import "testing"
+
+func TestBus(t *testing.T) {
+	b := NewBus(1)
+
+	s1 := b.Subscribe()
+	s2 := b.Subscribe()
+
+	b.Publish("a")
+
+	if got := <-s1.C(); got != "a" {
+		t.Errorf("expected s1 to receive a, got %v", got)
+	}
+
+	// s2 hasn't received "a" yet, so "b" should be dropped for it.
+	b.Publish("b")
+
+	if got := <-s1.C(); got != "b" {
+		t.Errorf("expected s1 to receive b, got %v", got)
+	}
+
+	if got := <-s2.C(); got != "a" {
+		t.Errorf("expected s2 to receive a, got %v", got)
+	}
+
+	if d := s2.Dropped(); d != 1 {
+		t.Errorf("expected s2 to have dropped 1 event, got %d", d)
+	}
+
+	s1.Close()
+	s1.Close()
+
+	if _, ok := <-s1.C(); ok {
+		t.Error("expected s1 channel to be closed")
+	}
+
+	if n := b.Len(); n != 1 {
+		t.Errorf("expected 1 subscriber, got %d", n)
+	}
+
+	b.Close()
+
+	if _, ok := <-s2.C(); ok {
+		t.Error("expected s2 channel to be closed after bus close")
+	}
+
+	s3 := b.Subscribe()
+	b.Publish("c")
+
+	if _, ok := <-s3.C(); ok {
+		t.Error("expected subscription to closed bus to be closed")
+	}
+}
@@ -109,6 +109,15 @@ func (m *Manager) Release(token *Token) {
 	m.mu.Unlock()
 }
 
+// Len returns the number of entries held by the manager.
+//
+// Len is safe for concurrent use.
+func (m *Manager) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.entries)
+}
+
 // ExpireAndRemove expires an entry in the manager by name, removing it
 // and running its onExpire function.
 func (m *Manager) ExpireAndRemove(name string) {
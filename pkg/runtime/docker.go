@@ -0,0 +1,476 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	dnetwork "github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/docker/image"
+	"go.uber.org/zap"
+)
+
+// Docker is the Docker implementation of Runtime.
+type Docker struct {
+	cli client.CommonAPIClient
+}
+
+var _ Runtime = (*Docker)(nil)
+
+// NewDocker creates a Runtime which uses the given Docker client.
+func NewDocker(cli client.CommonAPIClient) *Docker {
+	return &Docker{cli: cli}
+}
+
+// Client returns the runtime's Docker client, for operations which only
+// Docker supports.
+func (d *Docker) Client() client.CommonAPIClient {
+	return d.cli
+}
+
+// Build implements Runtime.
+func (d *Docker) Build(ctx context.Context, tag string, dockerfile string, contextPath string) (string, error) {
+	return image.Build(ctx, d.cli, tag, dockerfile, contextPath)
+}
+
+// EnsureImage implements Runtime.
+func (d *Docker) EnsureImage(ctx context.Context, ref string) error {
+	return image.PullIfNotFound(ctx, d.cli, ref)
+}
+
+// Pull implements Runtime.
+func (d *Docker) Pull(ctx context.Context, ref string) error {
+	return image.Pull(ctx, d.cli, ref)
+}
+
+// Tag implements Runtime.
+func (d *Docker) Tag(ctx context.Context, source string, target string) error {
+	return d.cli.ImageTag(ctx, source, target)
+}
+
+// ImageLabels implements Runtime.
+func (d *Docker) ImageLabels(ctx context.Context, ref string) (map[string]string, error) {
+	ii, _, err := d.cli.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if ii.Config == nil {
+		return nil, nil
+	}
+	return ii.Config.Labels, nil
+}
+
+// RemoveImage implements Runtime.
+func (d *Docker) RemoveImage(ctx context.Context, imageID string) error {
+	_, err := d.cli.ImageRemove(ctx, imageID, types.ImageRemoveOptions{PruneChildren: true})
+	return err
+}
+
+// Create implements Runtime.
+func (d *Docker) Create(ctx context.Context, name string, config *container.Config, hostConfig *container.HostConfig) (string, error) {
+	c, err := d.cli.ContainerCreate(ctx, config, hostConfig, nil, name)
+	if err != nil {
+		return "", err
+	}
+	return c.ID, nil
+}
+
+// Start implements Runtime.
+func (d *Docker) Start(ctx context.Context, containerID string) error {
+	return d.cli.ContainerStart(ctx, containerID, types.ContainerStartOptions{})
+}
+
+// Stop implements Runtime.
+func (d *Docker) Stop(ctx context.Context, containerID string, timeout time.Duration) error {
+	return d.cli.ContainerStop(ctx, containerID, &timeout)
+}
+
+// Kill implements Runtime.
+func (d *Docker) Kill(ctx context.Context, containerID string) error {
+	return d.cli.ContainerKill(ctx, containerID, "KILL")
+}
+
+// Remove implements Runtime.
+func (d *Docker) Remove(ctx context.Context, containerID string, force bool) error {
+	return d.cli.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{RemoveVolumes: true, Force: force})
+}
+
+// Rename implements Runtime.
+func (d *Docker) Rename(ctx context.Context, containerID string, name string) error {
+	return d.cli.ContainerRename(ctx, containerID, name)
+}
+
+// Inspect implements Runtime.
+func (d *Docker) Inspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	return d.cli.ContainerInspect(ctx, containerID)
+}
+
+// List implements Runtime.
+func (d *Docker) List(ctx context.Context, labels map[string]string) ([]Container, error) {
+	args := filters.NewArgs()
+	for k, v := range labels {
+		args.Add("label", k+"="+v)
+	}
+
+	list, err := d.cli.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: args,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]Container, 0, len(list))
+	for _, c := range list {
+		var name string
+		if len(c.Names) != 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+
+		containers = append(containers, Container{
+			ID:     c.ID,
+			Name:   name,
+			Labels: c.Labels,
+		})
+	}
+
+	return containers, nil
+}
+
+// Commit implements Runtime.
+func (d *Docker) Commit(ctx context.Context, containerID string, options CommitOptions) (string, error) {
+	commitOptions := types.ContainerCommitOptions{
+		Reference: options.Reference,
+		Comment:   options.Comment,
+	}
+
+	if len(options.Labels) != 0 {
+		// The daemon merges this with the container's configuration.
+		commitOptions.Config = &container.Config{Labels: options.Labels}
+	}
+
+	resp, err := d.cli.ContainerCommit(ctx, containerID, commitOptions)
+	if err != nil {
+		return "", err
+	}
+	return resp.ID, nil
+}
+
+// CopyTo implements Runtime.
+func (d *Docker) CopyTo(ctx context.Context, containerID string, dstPath string, content io.Reader) error {
+	return d.cli.CopyToContainer(ctx, containerID, dstPath, content, types.CopyToContainerOptions{})
+}
+
+// CopyFrom implements Runtime.
+func (d *Docker) CopyFrom(ctx context.Context, containerID string, srcPath string) (io.ReadCloser, types.ContainerPathStat, error) {
+	return d.cli.CopyFromContainer(ctx, containerID, srcPath)
+}
+
+// CreateNetwork implements Runtime.
+func (d *Docker) CreateNetwork(ctx context.Context, name string, options NetworkOptions) error {
+	if _, err := d.cli.NetworkInspect(ctx, name, types.NetworkInspectOptions{}); err == nil {
+		return nil
+	}
+
+	_, err := d.cli.NetworkCreate(ctx, name, types.NetworkCreate{
+		CheckDuplicate: true,
+		Internal:       options.Internal,
+		Labels:         options.Labels,
+	})
+	return err
+}
+
+// RemoveNetwork implements Runtime.
+func (d *Docker) RemoveNetwork(ctx context.Context, name string) error {
+	return d.cli.NetworkRemove(ctx, name)
+}
+
+// Connect implements Runtime.
+func (d *Docker) Connect(ctx context.Context, network string, containerID string, aliases []string) error {
+	var endpoint *dnetwork.EndpointSettings
+	if len(aliases) != 0 {
+		endpoint = &dnetwork.EndpointSettings{Aliases: aliases}
+	}
+	return d.cli.NetworkConnect(ctx, network, containerID, endpoint)
+}
+
+// Disconnect implements Runtime.
+func (d *Docker) Disconnect(ctx context.Context, network string, containerID string) error {
+	return d.cli.NetworkDisconnect(ctx, network, containerID, true)
+}
+
+// Exec implements Runtime.
+func (d *Docker) Exec(ctx context.Context, containerID string, config ExecConfig) (Process, error) {
+	execConfig := types.ExecConfig{
+		User:         config.User,
+		Cmd:          config.Cmd,
+		Env:          config.Env,
+		WorkingDir:   config.WorkingDir,
+		Tty:          config.Tty,
+		AttachStdin:  config.AttachStdin,
+		AttachStdout: config.AttachStdout,
+		AttachStderr: config.AttachStderr,
+	}
+
+	execResp, err := d.cli.ContainerExecCreate(ctx, containerID, execConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	hj, err := d.cli.ContainerExecAttach(ctx, execResp.ID, types.ExecStartCheck{Tty: config.Tty})
+	if err != nil {
+		return nil, err
+	}
+
+	e := &dockerExec{
+		cli:    d.cli,
+		execID: execResp.ID,
+		hj:     hj,
+	}
+
+	if config.Tty {
+		e.stdout = hj.Conn
+		e.stderr = hj.Reader
+		return e, nil
+	}
+
+	e.stdout, e.stderr = demux(hj.Reader)
+	return e, nil
+}
+
+// demux splits output multiplexed by the Docker daemon (see stdcopy), as is
+// done for processes without a TTY, into separate stdout and stderr readers.
+// Both readers must be drained.
+func demux(r io.Reader) (stdout, stderr io.Reader) {
+	stdoutR, stdoutW := io.Pipe()
+	stderrR, stderrW := io.Pipe()
+
+	go func() {
+		_, err := stdcopy.StdCopy(stdoutW, stderrW, r)
+		stdoutW.CloseWithError(err)
+		stderrW.CloseWithError(err)
+	}() // Exits when r returns EOF.
+
+	return stdoutR, stderrR
+}
+
+type dockerExec struct {
+	cli    client.CommonAPIClient
+	execID string
+	hj     types.HijackedResponse
+
+	stdout io.Reader
+	stderr io.Reader
+}
+
+func (e *dockerExec) Stdin() io.Writer {
+	return e.hj.Conn
+}
+
+func (e *dockerExec) CloseStdin() error {
+	return e.hj.CloseWrite()
+}
+
+func (e *dockerExec) Stdout() io.Reader {
+	return e.stdout
+}
+
+func (e *dockerExec) Stderr() io.Reader {
+	return e.stderr
+}
+
+func (e *dockerExec) Resize(ctx context.Context, height, width uint) error {
+	return e.cli.ContainerExecResize(ctx, e.execID, types.ResizeOptions{Height: height, Width: width})
+}
+
+func (e *dockerExec) Close() error {
+	e.hj.Close()
+	return nil
+}
+
+func (e *dockerExec) Wait(ctx context.Context) (int, error) {
+	for i := 0; ; i++ {
+		select {
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		default:
+		}
+
+		resp, err := e.cli.ContainerExecInspect(ctx, e.execID)
+		if err != nil {
+			return -1, err
+		}
+
+		if resp.Running {
+			time.Sleep(10 * time.Millisecond) // Arbitrary.
+			continue
+		}
+
+		if i != 0 {
+			logger := ctxlog.FromContext(ctx)
+			logger.Debug("exec wait",
+				zap.Int("retries", i),
+			)
+		}
+
+		return resp.ExitCode, nil
+	}
+}
+
+// Attach implements Runtime.
+func (d *Docker) Attach(ctx context.Context, containerID string) (Process, error) {
+	info, err := d.cli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	attachOptions := types.ContainerAttachOptions{
+		Stream: true,
+		Stdin:  true,
+		Stdout: true,
+		Stderr: true,
+	}
+
+	hj, err := d.cli.ContainerAttach(ctx, containerID, attachOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Like "docker run --rm", wait for removal rather than exit, as the
+	// container may be removed before its exit is seen.
+	condition := container.WaitConditionNextExit
+	if info.HostConfig != nil && info.HostConfig.AutoRemove {
+		condition = container.WaitConditionRemoved
+	}
+
+	resultC, errC := d.cli.ContainerWait(ctx, containerID, condition)
+
+	a := &dockerAttach{
+		cli:         d.cli,
+		containerID: containerID,
+		hj:          hj,
+		resultC:     resultC,
+		errC:        errC,
+	}
+
+	if info.Config != nil && info.Config.Tty {
+		a.stdout = hj.Reader
+		a.stderr = hj.Reader
+		return a, nil
+	}
+
+	// Without a TTY, attached output is multiplexed, and must be split with
+	// stdcopy (as in the Docker CLI's hijack.go).
+	a.stdout, a.stderr = demux(hj.Reader)
+	return a, nil
+}
+
+type dockerAttach struct {
+	cli         client.CommonAPIClient
+	containerID string
+	hj          types.HijackedResponse
+
+	stdout io.Reader
+	stderr io.Reader
+
+	resultC <-chan container.ContainerWaitOKBody
+	errC    <-chan error
+}
+
+func (a *dockerAttach) Stdin() io.Writer {
+	return a.hj.Conn
+}
+
+func (a *dockerAttach) CloseStdin() error {
+	return a.hj.CloseWrite()
+}
+
+func (a *dockerAttach) Stdout() io.Reader {
+	return a.stdout
+}
+
+func (a *dockerAttach) Stderr() io.Reader {
+	return a.stderr
+}
+
+func (a *dockerAttach) Resize(ctx context.Context, height, width uint) error {
+	return a.cli.ContainerResize(ctx, a.containerID, types.ResizeOptions{Height: height, Width: width})
+}
+
+func (a *dockerAttach) Close() error {
+	a.hj.Close()
+	return nil
+}
+
+func (a *dockerAttach) Wait(ctx context.Context) (int, error) {
+	select {
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	case result := <-a.resultC:
+		if result.Error != nil {
+			return -1, errors.New(result.Error.Message)
+		}
+		return int(result.StatusCode), nil
+	case err := <-a.errC:
+		return -1, err
+	}
+}
+
+// Prune implements Runtime.
+func (d *Docker) Prune(ctx context.Context, until time.Duration) (PruneReport, error) {
+	// Order: containers, networks, volumes, images (from docker system prune).
+	var report PruneReport
+	var firstErr error
+
+	setErr := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	untilArg := filters.Arg("until", until.String())
+
+	if r, err := d.cli.ContainersPrune(ctx, filters.NewArgs(filters.Arg("label", "ua.owned=true"), untilArg)); err != nil {
+		setErr(err)
+	} else {
+		report.SpaceReclaimed += r.SpaceReclaimed
+		report.Containers = r.ContainersDeleted
+	}
+
+	if r, err := d.cli.NetworksPrune(ctx, filters.NewArgs(untilArg)); err != nil {
+		setErr(err)
+	} else {
+		report.Networks = r.NetworksDeleted
+	}
+
+	if r, err := d.cli.VolumesPrune(ctx, filters.NewArgs()); err != nil {
+		setErr(err)
+	} else {
+		report.SpaceReclaimed += r.SpaceReclaimed
+		report.Volumes = r.VolumesDeleted
+	}
+
+	if r, err := d.cli.ImagesPrune(ctx, filters.NewArgs(filters.Arg("dangling", "true"), untilArg)); err != nil {
+		setErr(err)
+	} else {
+		report.SpaceReclaimed += r.SpaceReclaimed
+		for _, deleted := range r.ImagesDeleted {
+			if deleted.Deleted != "" {
+				report.Images = append(report.Images, deleted.Deleted)
+			} else {
+				report.Images = append(report.Images, deleted.Untagged)
+			}
+		}
+	}
+
+	return report, firstErr
+}
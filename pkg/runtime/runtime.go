@@ -0,0 +1,164 @@
+// Package runtime defines the container runtime used to build images and run
+// containers, so that runtimes other than Docker can be used. Code which
+// runs containers can be tested without a Docker daemon using the in-memory
+// runtime in pkg/runtime/runtimetest.
+//
+// Containers are configured and described with Docker's API types, as other
+// runtimes (like Podman) understand them as well.
+package runtime
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+)
+
+// DefaultStopTimeout is the timeout Docker uses when stopping containers,
+// if none is given.
+const DefaultStopTimeout = 10 * time.Second
+
+// Runtime is a container runtime. NewDocker returns the default Docker
+// implementation.
+type Runtime interface {
+	// Build builds an image from a Dockerfile (given as a string) and a
+	// build context directory, tags it, and returns its ID.
+	Build(ctx context.Context, tag string, dockerfile string, contextPath string) (imageID string, err error)
+	// EnsureImage makes sure an image is available, pulling it if needed.
+	EnsureImage(ctx context.Context, ref string) error
+	// Pull pulls an image, even if it is already available, to update it.
+	Pull(ctx context.Context, ref string) error
+	// Tag gives an image another name.
+	Tag(ctx context.Context, source string, target string) error
+	// ImageLabels returns an image's labels.
+	ImageLabels(ctx context.Context, ref string) (map[string]string, error)
+	// RemoveImage removes an image, and any untagged parents.
+	RemoveImage(ctx context.Context, imageID string) error
+
+	// Create creates a container, and returns its ID. If name is empty,
+	// the runtime picks one.
+	Create(ctx context.Context, name string, config *container.Config, hostConfig *container.HostConfig) (containerID string, err error)
+	// Start starts a created or stopped container.
+	Start(ctx context.Context, containerID string) error
+	// Stop stops a container, killing it if it doesn't stop within the
+	// timeout.
+	Stop(ctx context.Context, containerID string, timeout time.Duration) error
+	// Kill kills a running container.
+	Kill(ctx context.Context, containerID string) error
+	// Remove removes a container and its volumes. If force is set, the
+	// container is killed first if it is running.
+	Remove(ctx context.Context, containerID string, force bool) error
+	// Rename renames a container.
+	Rename(ctx context.Context, containerID string, name string) error
+	// Inspect returns the configuration and state of a container.
+	Inspect(ctx context.Context, containerID string) (types.ContainerJSON, error)
+	// List lists all containers, running or not, which have all of the
+	// given labels.
+	List(ctx context.Context, labels map[string]string) ([]Container, error)
+	// Commit creates an image from a container's filesystem, and returns
+	// its ID.
+	Commit(ctx context.Context, containerID string, options CommitOptions) (imageID string, err error)
+
+	// CopyTo extracts a tar archive into a directory in a container.
+	CopyTo(ctx context.Context, containerID string, dstPath string, content io.Reader) error
+	// CopyFrom returns a tar archive of a path in a container, along with
+	// information about the path.
+	CopyFrom(ctx context.Context, containerID string, srcPath string) (io.ReadCloser, types.ContainerPathStat, error)
+
+	// CreateNetwork creates a network. If a network with the name already
+	// exists, it is left as is.
+	CreateNetwork(ctx context.Context, name string, options NetworkOptions) error
+	// RemoveNetwork removes a network.
+	RemoveNetwork(ctx context.Context, name string) error
+	// Connect connects a container to a network, where it can be reached by
+	// the given aliases (as well as its name).
+	Connect(ctx context.Context, network string, containerID string, aliases []string) error
+	// Disconnect disconnects a container from a network.
+	Disconnect(ctx context.Context, network string, containerID string) error
+
+	// Exec runs a process in a running container.
+	Exec(ctx context.Context, containerID string, config ExecConfig) (Process, error)
+	// Attach attaches to a created container's main process, which starts
+	// running once the container is started.
+	Attach(ctx context.Context, containerID string) (Process, error)
+
+	// Prune removes unused objects owned by ua (containers labelled
+	// ua.owned), and unused networks, volumes, and dangling images,
+	// where they are older than until. Pruning continues after an error;
+	// the first error is returned along with what was pruned.
+	Prune(ctx context.Context, until time.Duration) (PruneReport, error)
+}
+
+// Container is a container returned by List.
+type Container struct {
+	ID     string
+	Name   string
+	Labels map[string]string
+}
+
+// CommitOptions configures an image created by Commit.
+type CommitOptions struct {
+	// Reference names the image, like "ua-snapshot-1".
+	Reference string
+	Comment   string
+	// Labels are added to the labels the image inherits from the container.
+	Labels map[string]string
+}
+
+// NetworkOptions configures a network created by CreateNetwork.
+type NetworkOptions struct {
+	// Internal networks have no access to the outside world.
+	Internal bool
+	Labels   map[string]string
+}
+
+// ExecConfig configures a process run by Exec.
+type ExecConfig struct {
+	User       string
+	Cmd        []string
+	Env        []string
+	WorkingDir string
+	Tty        bool
+
+	// AttachStdin, AttachStdout, and AttachStderr control which of the
+	// process's streams are available from the Process.
+	AttachStdin  bool
+	AttachStdout bool
+	AttachStderr bool
+}
+
+// Process is a running process, started by Exec or Attach.
+type Process interface {
+	// Stdin writes to the process's standard input.
+	Stdin() io.Writer
+	// CloseStdin closes the process's standard input.
+	CloseStdin() error
+	// Stdout and Stderr read from the process's output. For processes with
+	// a TTY, they may read from the same stream. Otherwise, each attached
+	// stream must be drained for the process to make progress.
+	Stdout() io.Reader
+	Stderr() io.Reader
+	// Resize resizes the process's TTY.
+	Resize(ctx context.Context, height, width uint) error
+	// Wait waits for the process to exit, and returns its exit code.
+	Wait(ctx context.Context) (exitCode int, err error)
+	// Close closes the process's streams. It does not stop the process.
+	Close() error
+}
+
+// PruneReport describes what was removed by Prune.
+type PruneReport struct {
+	SpaceReclaimed uint64
+	Containers     []string
+	Networks       []string
+	Volumes        []string
+	Images         []string
+}
+
+// IsNotFound reports whether err means an image or container doesn't exist.
+func IsNotFound(err error) bool {
+	return errdefs.IsNotFound(err)
+}
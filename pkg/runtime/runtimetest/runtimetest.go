@@ -0,0 +1,39 @@
+// Package runtimetest provides an in-memory container runtime for tests.
+//
+// The fake is the Docker runtime talking to the fake Docker client in
+// pkg/docker/dockertest, rather than a separate implementation of Runtime,
+// so that code tested against the runtime and the app's end-to-end tests
+// share one fake daemon.
+package runtimetest
+
+import (
+	"github.com/jakebailey/ua/pkg/docker/dockertest"
+	"github.com/jakebailey/ua/pkg/runtime"
+)
+
+// ExecFunc runs a process in the fake runtime. It returns the process's exit
+// code.
+type ExecFunc = dockertest.ExecFunc
+
+// Fake is an in-memory Runtime, for tests. No processes are actually run;
+// exec'd processes and the main processes of attached containers are run by
+// calling the daemon's ExecFunc.
+type Fake struct {
+	*runtime.Docker
+
+	// Daemon is the fake Docker daemon, which can be used to add images,
+	// set the ExecFunc, and inspect containers.
+	Daemon *dockertest.Client
+}
+
+var _ runtime.Runtime = (*Fake)(nil)
+
+// New creates a new Fake runtime, with no images or containers.
+func New() *Fake {
+	daemon := dockertest.New()
+
+	return &Fake{
+		Docker: runtime.NewDocker(daemon),
+		Daemon: daemon,
+	}
+}
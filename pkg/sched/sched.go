@@ -7,7 +7,8 @@ import (
 
 // Runner runs a scheduled tasks every specified duration.
 type Runner struct {
-	fn func()
+	fn    func()
+	every time.Duration
 
 	startOnce sync.Once
 	stopOnce  sync.Once
@@ -15,6 +16,23 @@ type Runner struct {
 	stopped chan struct{}
 	ticker  *time.Ticker
 	manual  chan struct{}
+
+	mu     sync.Mutex
+	status Status
+}
+
+// Status describes a Runner's runs.
+type Status struct {
+	// Every is the interval at which the task is run.
+	Every time.Duration
+	// Running is true if the task is currently running.
+	Running bool
+	// Runs is the number of times the task has finished.
+	Runs int
+	// LastStart and LastFinish are the times the task last started and
+	// finished, or zero if it has not.
+	LastStart  time.Time
+	LastFinish time.Time
 }
 
 // NewRunner creates a new Runner with the given function, and runs it every
@@ -25,6 +43,7 @@ type Runner struct {
 func NewRunner(fn func(), every time.Duration) *Runner {
 	return &Runner{
 		fn:      fn,
+		every:   every,
 		stopped: make(chan struct{}),
 		ticker:  time.NewTicker(every),
 		manual:  make(chan struct{}, 1),
@@ -51,6 +70,16 @@ func (r *Runner) Run() {
 	}
 }
 
+// Status returns the runner's current status.
+func (r *Runner) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := r.status
+	status.Every = r.every
+	return status
+}
+
 func (r *Runner) stop() {
 	r.ticker.Stop()
 	close(r.manual)
@@ -78,7 +107,18 @@ func (r *Runner) run() {
 }
 
 func (r *Runner) runFn() {
+	r.mu.Lock()
+	r.status.Running = true
+	r.status.LastStart = time.Now()
+	r.mu.Unlock()
+
 	if r.fn != nil {
 		r.fn()
 	}
+
+	r.mu.Lock()
+	r.status.Running = false
+	r.status.LastFinish = time.Now()
+	r.status.Runs++
+	r.mu.Unlock()
 }
@@ -2,6 +2,60 @@ define(['crypto'], function(crypto) {
 
     function SimpleCrypto() {}
 
+    const V2 = 2;
+    const AEAD_INFO = 'ua simplecrypto v2 aes-256-gcm';
+    const NONCE_SIZE = 12;
+    const TAG_SIZE = 16;
+
+    /**
+     * Derives the AES-256-GCM key used by seal and open from a key.
+     * @param {Buffer} key The encryption key.
+     * @return {Buffer} The derived key.
+     */
+    function aeadKey(key) {
+        return Buffer.from(crypto.hkdfSync('sha256', key, Buffer.alloc(0), AEAD_INFO, 32));
+    }
+
+    /**
+     * Encrypts and authenticates a payload with AES-GCM, using a key derived
+     * from the given key with HKDF.
+     * @param {Buffer} key The encryption key.
+     * @param {Buffer} payload The payload to be encrypted.
+     * @param {Buffer} additionalData Data authenticated along with the payload.
+     * @return {Buffer} Ciphertext (nonce + encrypted payload + tag).
+     */
+    SimpleCrypto.prototype.seal = function(key, payload, additionalData) {
+        const nonce = crypto.randomBytes(NONCE_SIZE);
+        const cipher = crypto.createCipheriv('aes-256-gcm', aeadKey(key), nonce);
+        cipher.setAAD(additionalData);
+
+        const encrypted = Buffer.concat([cipher.update(payload), cipher.final()]);
+        return Buffer.concat([nonce, encrypted, cipher.getAuthTag()]);
+    };
+
+    /**
+     * Authenticates and decrypts a ciphertext produced by seal.
+     * @param {Buffer} key The encryption key.
+     * @param {Buffer} ciphertext Ciphertext (nonce + encrypted payload + tag).
+     * @param {Buffer} additionalData Data authenticated along with the payload.
+     * @return {Buffer} The original payload.
+     */
+    SimpleCrypto.prototype.open = function(key, ciphertext, additionalData) {
+        if (ciphertext.length < NONCE_SIZE + TAG_SIZE) {
+            throw Error("simplecrypto: ciphertext too short");
+        }
+
+        const nonce = ciphertext.slice(0, NONCE_SIZE);
+        const tag = ciphertext.slice(ciphertext.length - TAG_SIZE);
+        const encrypted = ciphertext.slice(NONCE_SIZE, ciphertext.length - TAG_SIZE);
+
+        const decipher = crypto.createDecipheriv('aes-256-gcm', aeadKey(key), nonce);
+        decipher.setAAD(additionalData);
+        decipher.setAuthTag(tag);
+
+        return Buffer.concat([decipher.update(encrypted), decipher.final()]);
+    };
+
     /**
      * Generates an algorithm name based on a key's size. I.e., providing a
      * 16 byte key will return "aes-128-cfb".
@@ -82,13 +136,33 @@ define(['crypto'], function(crypto) {
      * Decodes a serialized message (data) using a key, and returns the
      * decrypted payload. If the decoded ciphertext does not match the
      * decoded HMAC, an error is thrown.
-     * @param {Buffer} key The encryption key.
+     * @param {Buffer} key The encryption key, used for messages without a
+     *     key ID.
      * @param {String} data The encoded message as a string.
+     * @param {Object<string, Buffer>=} keys Keys by ID, used for messages
+     *     with a key ID.
      * @return {Buffer} The decrypted payload.
      */
-    SimpleCrypto.prototype.decodeJSON = function(key, data) {
+    SimpleCrypto.prototype.decodeJSON = function(key, data, keys) {
         const obj = JSON.parse(data);
+
+        if (obj.kid) {
+            if (!keys || !keys.hasOwnProperty(obj.kid)) {
+                throw Error("simplecrypto: unknown key ID");
+            }
+            key = keys[obj.kid];
+        }
+
         const ciphertext = Buffer.from(obj.ciphertext, 'base64');
+
+        if (obj.v === V2) {
+            return this.open(key, ciphertext, Buffer.from(obj.kid || '', 'utf8'));
+        }
+
+        if (obj.v !== undefined && obj.v !== 1) {
+            throw Error("simplecrypto: unknown message version");
+        }
+
         const hmac = Buffer.from(obj.hmac, 'base64');
 
         if (!this.checkMAC(key, ciphertext, hmac)) {
@@ -99,20 +173,26 @@ define(['crypto'], function(crypto) {
     };
 
     /**
-     * Encodes a payload using a key, then encodes it as a JSON object,
-     * which includes the ciphertext and its HMAC.
+     * Encrypts a payload using a key (with seal), then encodes it as a JSON
+     * object, which includes the version, ciphertext, and key ID.
      * @param {Buffer} key The encryption key.
      * @param {Buffer} payload The payload to be encrypted.
+     * @param {string=} keyID The key's ID, if it has one.
      * @return {String} The encoded message as a string.
      */
-    SimpleCrypto.prototype.encodeJSON = function(key, payload) {
-        const ciphertext = this.encrypt(key, payload);
-        const hmac = this.hmac(key, ciphertext);
-
-        return JSON.stringify({
-            ciphertext: ciphertext,
-            hmac: hmac
-        });
+    SimpleCrypto.prototype.encodeJSON = function(key, payload, keyID) {
+        const ciphertext = this.seal(key, payload, Buffer.from(keyID || '', 'utf8'));
+
+        const obj = {
+            v: V2,
+            ciphertext: ciphertext.toString('base64')
+        };
+
+        if (keyID) {
+            obj.kid = keyID;
+        }
+
+        return JSON.stringify(obj);
     };
 
     return new SimpleCrypto();
@@ -0,0 +1,78 @@
+package simplecrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// aeadInfo is the HKDF info used to derive AES-GCM keys, so that the derived
+// key is never the same as the provided key (which may also be used for the
+// legacy format).
+const aeadInfo = "ua simplecrypto v2 aes-256-gcm"
+
+// ErrOpen is returned when an AEAD ciphertext can't be authenticated.
+var ErrOpen = errors.New("simplecrypto: message authentication failed")
+
+// Seal encrypts and authenticates a payload using AES-256-GCM, with a key
+// derived from the given key using HKDF-SHA256. It returns a byte slice with
+// the random nonce, followed by the sealed payload. The additional data is
+// authenticated but not encrypted, and must be given again to Open.
+func Seal(key, payload, additionalData []byte) (ciphertext []byte, err error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(payload)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, payload, additionalData), nil
+}
+
+// Open authenticates and decrypts a ciphertext produced by Seal.
+func Open(key, ciphertext, additionalData []byte) (payload []byte, err error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrCiphertextTooShort
+	}
+
+	nonce := ciphertext[:aead.NonceSize()]
+	ciphertext = ciphertext[aead.NonceSize():]
+
+	payload, err = aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, ErrOpen
+	}
+
+	return payload, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	derived := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(aeadInfo)), derived); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,69 @@
+package simplecrypto
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	data := []byte("Hello, World!")
+	ad := []byte("key-id")
+	keySizes := []int{128, 192, 256}
+
+	for _, keySize := range keySizes {
+		keySize := keySize
+		t.Run(fmt.Sprintf("%d-bit", keySize), func(t *testing.T) {
+			key := makeKey(keySize / 8)
+
+			ciphertext, err := Seal(key, data, ad)
+			if err != nil {
+				t.Fatalf("expected nil error on Seal, got %s", err.Error())
+			}
+
+			orig, err := Open(key, ciphertext, ad)
+			if err != nil {
+				t.Fatalf("expected nil error on Open, got %s", err.Error())
+			}
+
+			if !bytes.Equal(data, orig) {
+				t.Fatalf("decrypted data differs from original payload")
+			}
+
+			if _, err := Open(key, ciphertext, []byte("other-id")); err != ErrOpen {
+				t.Fatalf("expected ErrOpen for wrong additional data, got %v", err)
+			}
+
+			ciphertext[len(ciphertext)-1] ^= 1
+
+			if _, err := Open(key, ciphertext, ad); err != ErrOpen {
+				t.Fatalf("expected ErrOpen for modified ciphertext, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDecodeJSONVersions(t *testing.T) {
+	data := []byte("Hello, World!")
+	key := makeKey(32)
+
+	v2, err := EncodeJSON(key, data)
+	if err != nil {
+		t.Fatalf("expected nil error on EncodeJSON, got %s", err.Error())
+	}
+
+	for name, message := range map[string][]byte{"v1": encodeV1(t, key, data), "v2": v2} {
+		payload, err := DecodeJSON(key, message)
+		if err != nil {
+			t.Fatalf("%s: expected nil error on DecodeJSON, got %s", name, err.Error())
+		}
+
+		if !bytes.Equal(data, payload) {
+			t.Fatalf("%s: decrypted data differs from original payload", name)
+		}
+	}
+
+	if _, err := DecodeJSON(key, []byte(`{"v":3,"ciphertext":""}`)); err != ErrUnknownVersion {
+		t.Fatalf("expected ErrUnknownVersion, got %v", err)
+	}
+}
@@ -2,29 +2,59 @@ package simplecrypto
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 )
 
-// JSONMessage defines a serialization format for a ciphertext and its HMAC.
-// The two fields are encoded by encoding/json as base64 strings.
+// Versions of the JSONMessage format.
+const (
+	// V1 is the legacy format: AES-CFB, with an HMAC-SHA256 of the
+	// ciphertext, both using the same key. Messages without a version are
+	// V1 messages.
+	V1 = 1
+	// V2 uses AES-GCM (see Seal), authenticating the key ID (if any) as
+	// additional data. The message has no separate HMAC.
+	V2 = 2
+)
+
+var (
+	// ErrUnknownVersion is returned when a message's version is not known.
+	ErrUnknownVersion = errors.New("simplecrypto: unknown message version")
+
+	// ErrLegacyDisabled is returned when decoding a V1 message with a
+	// Keyring which doesn't accept them.
+	ErrLegacyDisabled = errors.New("simplecrypto: legacy messages are disabled")
+)
+
+// JSONMessage defines a serialization format for a ciphertext, encoded by
+// encoding/json as a base64 string. The version determines how the message
+// was encrypted; V1 messages also include the ciphertext's HMAC. Messages
+// encoded by a Keyring also include the ID of the key used; messages without
+// one are decoded with the Keyring's primary key.
 //
 // This type is not intended to be used directly, but is exported to show
 // the JSON format.
 type JSONMessage struct {
+	Version    int    `json:"v,omitempty"`
+	KeyID      string `json:"kid,omitempty"`
 	Ciphertext []byte `json:"ciphertext"`
-	HMAC       []byte `json:"hmac"`
+	HMAC       []byte `json:"hmac,omitempty"`
+}
+
+func (m *JSONMessage) legacy() bool {
+	return m.Version == 0 || m.Version == V1
 }
 
 // DecodeJSON decodes a serialized JSON message (data) using a key,
-// and returns the decrypted payload. If the decoded cyphertext does not
-// match the decoded HMAC, then an error is returned.
+// and returns the decrypted payload. The message may be of any version. If
+// the message can't be authenticated, then an error is returned.
 func DecodeJSON(key, data []byte) ([]byte, error) {
 	var m JSONMessage
 	if err := json.Unmarshal(data, &m); err != nil {
 		return nil, err
 	}
 
-	return CheckAndDecrypt(key, m.Ciphertext, m.HMAC)
+	return decodeMessage(key, &m)
 }
 
 // DecodeJSONReader performs the same task as DecodeJSON, but reads from
@@ -35,37 +65,51 @@ func DecodeJSONReader(key []byte, r io.Reader) ([]byte, error) {
 		return nil, err
 	}
 
-	return CheckAndDecrypt(key, m.Ciphertext, m.HMAC)
+	return decodeMessage(key, &m)
 }
 
-// EncodeJSON encrypts a payload using a key, then encodes it as a JSON
-// object, which includes the ciphertext and its HMAC.
+// EncodeJSON encrypts a payload using a key, then encodes it as a V2 JSON
+// message.
 func EncodeJSON(key, payload []byte) ([]byte, error) {
-	ciphertext, hmac, err := EncryptAndHMAC(key, payload)
+	m, err := encodeMessage(key, "", payload)
 	if err != nil {
 		return nil, err
 	}
 
-	m := JSONMessage{
-		Ciphertext: ciphertext,
-		HMAC:       hmac,
-	}
-
 	return json.Marshal(m)
 }
 
 // EncodeJSONWriter performs the same task as EncodeJSON, but writes to
 // a Writer.
 func EncodeJSONWriter(key, payload []byte, w io.Writer) error {
-	ciphertext, hmac, err := EncryptAndHMAC(key, payload)
+	m, err := encodeMessage(key, "", payload)
 	if err != nil {
 		return err
 	}
 
-	m := JSONMessage{
-		Ciphertext: ciphertext,
-		HMAC:       hmac,
+	return json.NewEncoder(w).Encode(m)
+}
+
+func decodeMessage(key []byte, m *JSONMessage) ([]byte, error) {
+	switch {
+	case m.legacy():
+		return CheckAndDecrypt(key, m.Ciphertext, m.HMAC)
+	case m.Version == V2:
+		return Open(key, m.Ciphertext, []byte(m.KeyID))
+	default:
+		return nil, ErrUnknownVersion
 	}
+}
 
-	return json.NewEncoder(w).Encode(m)
+func encodeMessage(key []byte, keyID string, payload []byte) (*JSONMessage, error) {
+	ciphertext, err := Seal(key, payload, []byte(keyID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONMessage{
+		Version:    V2,
+		KeyID:      keyID,
+		Ciphertext: ciphertext,
+	}, nil
 }
@@ -0,0 +1,214 @@
+package simplecrypto
+
+import (
+	"crypto/aes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrUnknownKeyID is returned when a message's key ID doesn't match any
+	// key in a Keyring.
+	ErrUnknownKeyID = errors.New("simplecrypto: unknown key ID")
+
+	// ErrNoPrimaryKey is returned when a message without a key ID is decoded
+	// with a Keyring which has no primary key.
+	ErrNoPrimaryKey = errors.New("simplecrypto: no primary key for message without key ID")
+)
+
+// Keyring holds the keys used to encode and decode JSON messages, so that
+// keys can be rotated without breaking messages encoded with older keys.
+//
+// Messages are encoded with the active key, and tagged with its ID. Messages
+// are decoded with the key matching their ID, whether or not it's active;
+// keys which are kept only to decode older messages are "retired". Messages
+// without a key ID (those produced by EncodeJSON) are decoded with the
+// primary key.
+//
+// Messages are always encoded in the V2 format. V1 messages are accepted
+// unless DisableLegacy is called.
+type Keyring struct {
+	primary  []byte
+	activeID string
+	keys     map[string][]byte
+
+	disableLegacy bool
+}
+
+// NewKeyring creates a Keyring with the given primary key, which may be nil.
+// Until another key is made active, the primary key is used to encode
+// messages, without a key ID.
+func NewKeyring(primary []byte) (*Keyring, error) {
+	if primary != nil {
+		if err := checkKey(primary); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Keyring{
+		primary: primary,
+		keys:    make(map[string][]byte),
+	}, nil
+}
+
+// Add adds a key with the given ID.
+func (k *Keyring) Add(id string, key []byte) error {
+	if id == "" {
+		return errors.New("simplecrypto: key ID cannot be empty")
+	}
+
+	if _, ok := k.keys[id]; ok {
+		return fmt.Errorf("simplecrypto: duplicate key ID %q", id)
+	}
+
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	k.keys[id] = key
+	return nil
+}
+
+// SetActive sets the key used to encode messages. If id is empty, the
+// primary key is used.
+func (k *Keyring) SetActive(id string) error {
+	if id == "" {
+		if k.primary == nil {
+			return ErrNoPrimaryKey
+		}
+	} else if _, ok := k.keys[id]; !ok {
+		return ErrUnknownKeyID
+	}
+
+	k.activeID = id
+	return nil
+}
+
+// DisableLegacy stops the keyring from decoding V1 messages.
+func (k *Keyring) DisableLegacy() {
+	k.disableLegacy = true
+}
+
+// ActiveID returns the ID of the active key, or an empty string if the
+// primary key is active.
+func (k *Keyring) ActiveID() string {
+	return k.activeID
+}
+
+// Key returns the key with the given ID, or the primary key if id is empty.
+func (k *Keyring) Key(id string) ([]byte, error) {
+	if id == "" {
+		if k.primary == nil {
+			return nil, ErrNoPrimaryKey
+		}
+		return k.primary, nil
+	}
+
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	return key, nil
+}
+
+// DecodeJSON decodes a serialized JSON message using the key named by its
+// key ID, and returns the decrypted payload.
+func (k *Keyring) DecodeJSON(data []byte) ([]byte, error) {
+	var m JSONMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	return k.decode(&m)
+}
+
+// DecodeJSONReader performs the same task as DecodeJSON, but reads from
+// a Reader.
+func (k *Keyring) DecodeJSONReader(r io.Reader) ([]byte, error) {
+	var m JSONMessage
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+
+	return k.decode(&m)
+}
+
+func (k *Keyring) decode(m *JSONMessage) ([]byte, error) {
+	if k.disableLegacy && m.legacy() {
+		return nil, ErrLegacyDisabled
+	}
+
+	key, err := k.Key(m.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeMessage(key, m)
+}
+
+// EncodeJSON encrypts a payload using the active key, then encodes it as a
+// V2 JSON message, which includes the key's ID.
+func (k *Keyring) EncodeJSON(payload []byte) ([]byte, error) {
+	m, err := k.encode(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(m)
+}
+
+// EncodeJSONWriter performs the same task as EncodeJSON, but writes to
+// a Writer.
+func (k *Keyring) EncodeJSONWriter(payload []byte, w io.Writer) error {
+	m, err := k.encode(payload)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(m)
+}
+
+func (k *Keyring) encode(payload []byte) (*JSONMessage, error) {
+	key, err := k.Key(k.activeID)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeMessage(key, k.activeID, payload)
+}
+
+// ParseKey parses a key in the form "id:key", where key is base64 encoded,
+// as produced by FormatKey.
+func ParseKey(s string) (id string, key []byte, err error) {
+	i := strings.IndexByte(s, ':')
+	if i <= 0 {
+		return "", nil, errors.New(`simplecrypto: key must be of the form "id:key"`)
+	}
+
+	id = s[:i]
+
+	key, err = base64.StdEncoding.DecodeString(s[i+1:])
+	if err != nil {
+		return "", nil, err
+	}
+
+	return id, key, nil
+}
+
+// FormatKey formats a key and its ID in the form read by ParseKey.
+func FormatKey(id string, key []byte) string {
+	return id + ":" + base64.StdEncoding.EncodeToString(key)
+}
+
+func checkKey(key []byte) error {
+	switch len(key) {
+	case 16, 24, 32:
+		return nil
+	default:
+		return aes.KeySizeError(len(key))
+	}
+}
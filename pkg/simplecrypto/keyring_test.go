@@ -0,0 +1,135 @@
+package simplecrypto
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func encodeV1(t *testing.T, key, payload []byte) []byte {
+	ciphertext, hmac, err := EncryptAndHMAC(key, payload)
+	if err != nil {
+		t.Fatalf("expected nil error on EncryptAndHMAC, got %s", err.Error())
+	}
+
+	data, err := json.Marshal(JSONMessage{Ciphertext: ciphertext, HMAC: hmac})
+	if err != nil {
+		t.Fatalf("expected nil error on Marshal, got %s", err.Error())
+	}
+
+	return data
+}
+
+func TestKeyringRotation(t *testing.T) {
+	data := []byte("Hello, World!")
+	primary := makeKey(32)
+
+	k, err := NewKeyring(primary)
+	if err != nil {
+		t.Fatalf("expected nil error on NewKeyring, got %s", err.Error())
+	}
+
+	// Messages from before key IDs existed use the primary key.
+	legacy := encodeV1(t, primary, data)
+
+	if err := k.Add("old", makeKey(16)); err != nil {
+		t.Fatalf("expected nil error on Add, got %s", err.Error())
+	}
+
+	if err := k.SetActive("old"); err != nil {
+		t.Fatalf("expected nil error on SetActive, got %s", err.Error())
+	}
+
+	old, err := k.EncodeJSON(data)
+	if err != nil {
+		t.Fatalf("expected nil error on EncodeJSON, got %s", err.Error())
+	}
+
+	if err := k.Add("new", makeKey(32)); err != nil {
+		t.Fatalf("expected nil error on Add, got %s", err.Error())
+	}
+
+	if err := k.SetActive("new"); err != nil {
+		t.Fatalf("expected nil error on SetActive, got %s", err.Error())
+	}
+
+	current, err := k.EncodeJSON(data)
+	if err != nil {
+		t.Fatalf("expected nil error on EncodeJSON, got %s", err.Error())
+	}
+
+	for name, message := range map[string][]byte{"legacy": legacy, "old": old, "current": current} {
+		payload, err := k.DecodeJSON(message)
+		if err != nil {
+			t.Fatalf("%s: expected nil error on DecodeJSON, got %s", name, err.Error())
+		}
+
+		if !bytes.Equal(data, payload) {
+			t.Fatalf("%s: decrypted data differs from original payload", name)
+		}
+	}
+
+	other, err := NewKeyring(nil)
+	if err != nil {
+		t.Fatalf("expected nil error on NewKeyring, got %s", err.Error())
+	}
+
+	if _, err := other.DecodeJSON(current); err != ErrUnknownKeyID {
+		t.Fatalf("expected ErrUnknownKeyID, got %v", err)
+	}
+
+	if _, err := other.DecodeJSON(legacy); err != ErrNoPrimaryKey {
+		t.Fatalf("expected ErrNoPrimaryKey, got %v", err)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	key := makeKey(24)
+
+	id, parsed, err := ParseKey(FormatKey("2019-01", key))
+	if err != nil {
+		t.Fatalf("expected nil error on ParseKey, got %s", err.Error())
+	}
+
+	if id != "2019-01" {
+		t.Fatalf("expected ID 2019-01, got %q", id)
+	}
+
+	if !bytes.Equal(key, parsed) {
+		t.Fatalf("parsed key differs from original key")
+	}
+
+	if _, _, err := ParseKey(":abcd"); err == nil {
+		t.Fatalf("expected error for key without ID")
+	}
+}
+
+func TestKeyringDisableLegacy(t *testing.T) {
+	data := []byte("Hello, World!")
+	key := makeKey(16)
+
+	k, err := NewKeyring(key)
+	if err != nil {
+		t.Fatalf("expected nil error on NewKeyring, got %s", err.Error())
+	}
+
+	k.DisableLegacy()
+
+	if _, err := k.DecodeJSON(encodeV1(t, key, data)); err != ErrLegacyDisabled {
+		t.Fatalf("expected ErrLegacyDisabled, got %v", err)
+	}
+
+	message, err := EncodeJSON(key, data)
+	if err != nil {
+		t.Fatalf("expected nil error on EncodeJSON, got %s", err.Error())
+	}
+
+	payload, err := k.DecodeJSON(message)
+	if err != nil {
+		t.Fatalf("expected nil error on DecodeJSON, got %s", err.Error())
+	}
+
+	if !bytes.Equal(data, payload) {
+		t.Fatalf("decrypted data differs from original payload")
+	}
+}
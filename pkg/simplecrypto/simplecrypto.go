@@ -1,5 +1,6 @@
 // Package simplecrypto implements a simplified interface for encryption and
-// decryption using AES encryption in CFB mode.
+// decryption using AES encryption, either authenticated in GCM mode (see
+// Seal), or in CFB mode with a separate HMAC (the legacy format).
 //
 // It is implemented using examples given in crypto/cipher and crypto/hmac.
 package simplecrypto
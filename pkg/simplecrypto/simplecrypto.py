@@ -3,6 +3,12 @@ import json
 from Crypto.Cipher import AES
 from Crypto.Random import get_random_bytes
 from Crypto.Hash import HMAC, SHA256
+from Crypto.Protocol.KDF import HKDF
+
+V2 = 2
+AEAD_INFO = b"ua simplecrypto v2 aes-256-gcm"
+NONCE_SIZE = 12
+TAG_SIZE = 16
 
 
 def encrypt(key, payload):
@@ -50,16 +56,59 @@ def check_and_decrypt(key, ciphertext, h):
     return decrypt(key, ciphertext)
 
 
-def encode_json(key, payload):
-    ciphertext, h = encrypt_and_hmac(key, payload)
-    return json.dumps({
+def _aead_key(key):
+    return HKDF(key, 32, None, SHA256, context=AEAD_INFO)
+
+
+def seal(key, payload, additional_data=b""):
+    nonce = get_random_bytes(NONCE_SIZE)
+    cipher = AES.new(_aead_key(key), AES.MODE_GCM, nonce=nonce)
+    cipher.update(additional_data)
+    ciphertext, tag = cipher.encrypt_and_digest(payload)
+    return nonce + ciphertext + tag
+
+
+def open_sealed(key, ciphertext, additional_data=b""):
+    if len(ciphertext) < NONCE_SIZE + TAG_SIZE:
+        return None
+
+    nonce = ciphertext[:NONCE_SIZE]
+    tag = ciphertext[-TAG_SIZE:]
+    ciphertext = ciphertext[NONCE_SIZE:-TAG_SIZE]
+
+    cipher = AES.new(_aead_key(key), AES.MODE_GCM, nonce=nonce)
+    cipher.update(additional_data)
+    try:
+        return cipher.decrypt_and_verify(ciphertext, tag)
+    except ValueError:
+        return None
+
+
+def encode_json(key, payload, key_id=None):
+    ciphertext = seal(key, payload, (key_id or "").encode("utf-8"))
+    d = {
+        "v": V2,
         "ciphertext": str(base64.standard_b64encode(ciphertext), "utf-8"),
-        "hmac": str(base64.standard_b64encode(h), "utf-8"),
-    })
+    }
+    if key_id:
+        d["kid"] = key_id
+    return json.dumps(d)
 
 
-def decode_json(key, data):
+def decode_json(key, data, keys=None):
+    """Decodes a message. Messages with a key ID are decoded with the
+    matching key in keys (a dict of key IDs to keys), others with key."""
     d = json.loads(data)
+    key_id = d.get("kid")
+    if key_id:
+        if keys is None or key_id not in keys:
+            return None
+        key = keys[key_id]
     ciphertext = base64.standard_b64decode(d["ciphertext"])
+    version = d.get("v", 1)
+    if version == V2:
+        return open_sealed(key, ciphertext, (key_id or "").encode("utf-8"))
+    if version != 1:
+        return None
     h = base64.standard_b64decode(d["hmac"])
     return check_and_decrypt(key, ciphertext, h)
@@ -0,0 +1,151 @@
+// Package storage opens uAssign's database. Two backends are supported:
+// PostgreSQL, and SQLite for single-node and local deployments where running
+// a database server isn't worth the trouble. The backend is selected by the
+// scheme of the database connection string.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"net/url"
+	"strings"
+
+	_ "github.com/lib/pq"           // postgresql driver
+	_ "github.com/mattn/go-sqlite3" // sqlite3 driver
+
+	"github.com/jakebailey/ua/migrations"
+	"github.com/jakebailey/ua/models"
+)
+
+// Names of the database/sql drivers used by each backend.
+const (
+	Postgres = "postgres"
+	SQLite   = "sqlite3"
+)
+
+// sqliteSchemes are the connection string schemes which select SQLite. The
+// rest of the connection string is the path to the database file, optionally
+// followed by go-sqlite3 options, like "sqlite:///var/lib/ua/ua.db" or
+// "sqlite://ua.db?_busy_timeout=10000".
+var sqliteSchemes = []string{"sqlite://", "sqlite3://"}
+
+// sqliteDefaults are the go-sqlite3 options used unless overridden in the
+// connection string. Foreign keys must be enabled for cascading deletes.
+// Cleanup loops over instances while updating them, which requires WAL
+// mode, and transactions take the write lock immediately so that concurrent
+// transactions wait on each other rather than failing.
+var sqliteDefaults = map[string]string{
+	"_foreign_keys": "1",
+	"_journal_mode": "WAL",
+	"_busy_timeout": "5000",
+	"_txlock":       "immediate",
+}
+
+// DB is an open database, along with the repository which uses it.
+type DB struct {
+	*sql.DB
+
+	// Driver is the database/sql driver of the backend, either Postgres or
+	// SQLite.
+	Driver string
+
+	Repository models.Repository
+}
+
+// Open opens the database described by a connection string. Connection
+// strings with a "sqlite://" or "sqlite3://" scheme open a SQLite database
+// file, and all others are passed to the PostgreSQL driver. Like sql.Open,
+// Open does not connect to the database.
+func Open(conn string) (*DB, error) {
+	for _, scheme := range sqliteSchemes {
+		if strings.HasPrefix(conn, scheme) {
+			return openSQLite(strings.TrimPrefix(conn, scheme))
+		}
+	}
+
+	db, err := sql.Open(Postgres, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(db, Postgres), nil
+}
+
+func openSQLite(path string) (*DB, error) {
+	var rawQuery string
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path, rawQuery = path[:i], path[i+1:]
+	}
+
+	options, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range sqliteDefaults {
+		if _, ok := options[k]; !ok {
+			options.Set(k, v)
+		}
+	}
+
+	db, err := sql.Open(SQLite, "file:"+path+"?"+options.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	return New(db, SQLite), nil
+}
+
+// New wraps a database opened with the given driver, either Postgres or
+// SQLite.
+func New(db *sql.DB, driver string) *DB {
+	return &DB{
+		DB:         db,
+		Driver:     driver,
+		Repository: models.NewSQLRepository(db),
+	}
+}
+
+// Up brings the database up to date, running the backend's migrations.
+func (db *DB) Up() error {
+	return migrations.Up(db.DB, db.Driver)
+}
+
+// Reset resets the database, then brings it up to date.
+func (db *DB) Reset() error {
+	return migrations.Reset(db.DB, db.Driver)
+}
+
+// TryLock tries to take a named lock shared by every node using the
+// database, returning false if another node holds it. The lock is held
+// until unlock is called, or until ctx is done.
+//
+// On PostgreSQL, this is a transaction-level advisory lock, so it is
+// released even if the connection is lost. SQLite databases can't be shared
+// between nodes, so there is no one to exclude, and the lock is always
+// taken.
+func (db *DB) TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error) {
+	if db.Driver != Postgres {
+		return func() {}, true, nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.QueryRowContext(ctx, "SELECT pg_try_advisory_xact_lock($1)", lockKey(name)).Scan(&ok); err != nil || !ok {
+		_ = tx.Rollback()
+		return nil, false, err
+	}
+
+	return func() { _ = tx.Rollback() }, true, nil
+}
+
+// lockKey hashes a lock name into an advisory lock key.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return int64(h.Sum64())
+}
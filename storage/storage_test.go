@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jakebailey/ua/models"
+)
+
+func openSQLiteTest(t *testing.T) (*DB, func()) {
+	dir, err := ioutil.TempDir("", "ua-storage-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := Open("sqlite://" + filepath.Join(dir, "ua.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if db.Driver != SQLite {
+		t.Fatalf("expected driver %s, got %s", SQLite, db.Driver)
+	}
+
+	if err := db.Up(); err != nil {
+		t.Fatalf("expected nil error migrating up, got %v", err)
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestOpenPostgres(t *testing.T) {
+	db, err := Open("postgres://localhost/ua?sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if db.Driver != Postgres {
+		t.Errorf("expected driver %s, got %s", Postgres, db.Driver)
+	}
+}
+
+func TestSQLite(t *testing.T) {
+	db, cleanup := openSQLiteTest(t)
+	defer cleanup()
+
+	// Migrating up again should do nothing.
+	if err := db.Up(); err != nil {
+		t.Fatalf("expected nil error migrating up twice, got %v", err)
+	}
+
+	spec := models.NewSpec()
+	spec.AssignmentName = "echo"
+	spec.Data = map[string]interface{}{"secret": "hunter2"}
+
+	if err := db.Repository.InsertSpec(spec); err != nil {
+		t.Fatal(err)
+	}
+
+	instance := models.NewInstance()
+	instance.Spec = spec
+	instance.Active = true
+	instance.Command.Cmd = []string{"cat"}
+
+	if err := db.Repository.InsertInstance(instance); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := db.Repository.FindActiveBySpec(spec.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(found) != 1 || found[0].ID != instance.ID || found[0].Spec.AssignmentName != "echo" || len(found[0].Command.Cmd) != 1 {
+		t.Fatalf("expected to find inserted instance, got %+v", found)
+	}
+
+	if data, ok := found[0].Spec.Data.(map[string]interface{}); !ok || data["secret"] != "hunter2" {
+		t.Errorf("expected spec data to round trip, got %#v", found[0].Spec.Data)
+	}
+
+	if err := db.Repository.MarkCleaned(found[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if found, err := db.Repository.FindActiveBySpec(spec.ID); err != nil || len(found) != 0 {
+		t.Errorf("expected no active instances after update, got %d and error %v", len(found), err)
+	}
+
+	if _, err := db.Repository.FindActiveInstance(instance.ID); err != models.ErrNotFound {
+		t.Errorf("expected ErrNotFound finding cleaned instance, got %v", err)
+	}
+
+	yes := true
+	cleaned, err := db.Repository.ListInstances(models.InstanceFilter{AssignmentName: "echo", Cleaned: &yes, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cleaned) != 1 || cleaned[0].Active || !cleaned[0].Cleaned {
+		t.Errorf("expected to list the cleaned instance, got %+v", cleaned)
+	}
+
+	usages, err := models.NewUsageStore(db.DB).Assignments()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(usages) != 1 || usages[0].Instances != 1 || usages[0].LastCreated == nil {
+		t.Errorf("expected usage of one instance with a creation time, got %+v", usages)
+	}
+
+	if err := db.Reset(); err != nil {
+		t.Fatalf("expected nil error resetting, got %v", err)
+	}
+
+	specs, err := db.Repository.ListSpecs(models.SpecFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(specs) != 0 {
+		t.Errorf("expected no specs after reset, got %d", len(specs))
+	}
+}
@@ -0,0 +1,499 @@
+// Code generated by qtc from "admin.qtpl". DO NOT EDIT.
+// See https://github.com/valyala/quicktemplate for details.
+
+// line admin.qtpl:1
+package templates
+
+// line admin.qtpl:1
+import (
+	"time"
+
+	"github.com/jakebailey/ua/models"
+)
+
+// line admin.qtpl:7
+import (
+	qtio422016 "io"
+
+	qt422016 "github.com/valyala/quicktemplate"
+)
+
+// line admin.qtpl:7
+var (
+	_ = qtio422016.Copy
+	_ = qt422016.AcquireByteBuffer
+)
+
+// AdminPage is the data shown on the admin dashboard.
+//
+// line admin.qtpl:8
+type AdminPage struct {
+	Message string
+
+	Instances      []*models.Instance
+	Assignments    []*models.AssignmentUsage
+	FailedBuilds   []*models.BuildLog
+	DiskUsage      []AdminDiskUsage
+	DiskUsageError string
+	Runners        []AdminRunner
+	Triggers       []string
+}
+
+// AdminDiskUsage is the disk space used by one kind of Docker object.
+type AdminDiskUsage struct {
+	Kind  string
+	Count int
+	Size  string
+}
+
+// AdminRunner is the status of a scheduled task.
+type AdminRunner struct {
+	Name       string
+	Every      time.Duration
+	Running    bool
+	Runs       int
+	LastStart  time.Time
+	LastFinish time.Time
+}
+
+// line admin.qtpl:39
+func streamadminTime(qw422016 *qt422016.Writer, t time.Time) {
+	// line admin.qtpl:39
+	if t.IsZero() {
+		// line admin.qtpl:39
+		qw422016.N().S(`never`)
+		// line admin.qtpl:39
+	} else {
+		// line admin.qtpl:39
+		qw422016.E().S(t.Format("2006-01-02 15:04:05"))
+		// line admin.qtpl:39
+	}
+	// line admin.qtpl:39
+}
+
+// line admin.qtpl:39
+func writeadminTime(qq422016 qtio422016.Writer, t time.Time) {
+	// line admin.qtpl:39
+	qw422016 := qt422016.AcquireWriter(qq422016)
+	// line admin.qtpl:39
+	streamadminTime(qw422016, t)
+	// line admin.qtpl:39
+	qt422016.ReleaseWriter(qw422016)
+	// line admin.qtpl:39
+}
+
+// line admin.qtpl:39
+func adminTime(t time.Time) string {
+	// line admin.qtpl:39
+	qb422016 := qt422016.AcquireByteBuffer()
+	// line admin.qtpl:39
+	writeadminTime(qb422016, t)
+	// line admin.qtpl:39
+	qs422016 := string(qb422016.B)
+	// line admin.qtpl:39
+	qt422016.ReleaseByteBuffer(qb422016)
+	// line admin.qtpl:39
+	return qs422016
+	// line admin.qtpl:39
+}
+
+// line admin.qtpl:41
+func StreamAdmin(qw422016 *qt422016.Writer, p *AdminPage) {
+	// line admin.qtpl:41
+	qw422016.N().S(`
+<!doctype html>
+<html>
+
+<head>
+    <title>uAssign admin</title>
+    <style>
+        body {
+            font-family: sans-serif;
+            margin: 2em;
+        }
+
+        table {
+            border-collapse: collapse;
+            margin-bottom: 2em;
+        }
+
+        th, td {
+            border: 1px solid #ccc;
+            padding: 0.25em 0.5em;
+            text-align: left;
+        }
+
+        .message {
+            background: #eef;
+            padding: 0.5em;
+        }
+
+        .error {
+            color: #a00;
+        }
+
+        form {
+            display: inline;
+        }
+    </style>
+</head>
+
+<body>
+    <h1>uAssign admin</h1>
+
+    `)
+	// line admin.qtpl:82
+	if p.Message != "" {
+		// line admin.qtpl:82
+		qw422016.N().S(`
+    <p class="message">`)
+		// line admin.qtpl:83
+		qw422016.E().S(p.Message)
+		// line admin.qtpl:83
+		qw422016.N().S(`</p>
+    `)
+		// line admin.qtpl:84
+	}
+	// line admin.qtpl:84
+	qw422016.N().S(`
+
+    <h2>Actions</h2>
+    `)
+	// line admin.qtpl:87
+	for _, name := range p.Triggers {
+		// line admin.qtpl:87
+		qw422016.N().S(`
+    <form action="/admin/trigger/`)
+		// line admin.qtpl:88
+		qw422016.E().S(name)
+		// line admin.qtpl:88
+		qw422016.N().S(`" method="post">
+        <button type="submit">`)
+		// line admin.qtpl:89
+		qw422016.E().S(name)
+		// line admin.qtpl:89
+		qw422016.N().S(`</button>
+    </form>
+    `)
+		// line admin.qtpl:91
+	}
+	// line admin.qtpl:91
+	qw422016.N().S(`
+
+    <h2>Live instances (`)
+	// line admin.qtpl:93
+	qw422016.N().D(len(p.Instances))
+	// line admin.qtpl:93
+	qw422016.N().S(`)</h2>
+    <table>
+        <tr>
+            <th>Instance</th>
+            <th>Assignment</th>
+            <th>Created</th>
+            <th>Expires</th>
+        </tr>
+        `)
+	// line admin.qtpl:101
+	for _, instance := range p.Instances {
+		// line admin.qtpl:101
+		qw422016.N().S(`
+        <tr>
+            <td><a href="/admin/instances/`)
+		// line admin.qtpl:103
+		qw422016.E().S(instance.ID.String())
+		// line admin.qtpl:103
+		qw422016.N().S(`">`)
+		// line admin.qtpl:103
+		qw422016.E().S(instance.ID.String())
+		// line admin.qtpl:103
+		qw422016.N().S(`</a></td>
+            <td>`)
+		// line admin.qtpl:104
+		if instance.Spec != nil {
+			// line admin.qtpl:104
+			qw422016.E().S(instance.Spec.AssignmentName)
+			// line admin.qtpl:104
+		}
+		// line admin.qtpl:104
+		qw422016.N().S(`</td>
+            <td>`)
+		// line admin.qtpl:105
+		streamadminTime(qw422016, instance.CreatedAt)
+		// line admin.qtpl:105
+		qw422016.N().S(`</td>
+            <td>`)
+		// line admin.qtpl:106
+		if instance.ExpiresAt == nil {
+			// line admin.qtpl:106
+			qw422016.N().S(`in use`)
+			// line admin.qtpl:106
+		} else {
+			// line admin.qtpl:106
+			streamadminTime(qw422016, *instance.ExpiresAt)
+			// line admin.qtpl:106
+		}
+		// line admin.qtpl:106
+		qw422016.N().S(`</td>
+        </tr>
+        `)
+		// line admin.qtpl:108
+	}
+	// line admin.qtpl:108
+	qw422016.N().S(`
+    </table>
+
+    <h2>Assignments</h2>
+    <table>
+        <tr>
+            <th>Assignment</th>
+            <th>Specs</th>
+            <th>Instances</th>
+            <th>Active</th>
+            <th>Last instance</th>
+        </tr>
+        `)
+	// line admin.qtpl:120
+	for _, u := range p.Assignments {
+		// line admin.qtpl:120
+		qw422016.N().S(`
+        <tr>
+            <td><a href="/admin/instances?assignment=`)
+		// line admin.qtpl:122
+		qw422016.N().U(u.AssignmentName)
+		// line admin.qtpl:122
+		qw422016.N().S(`">`)
+		// line admin.qtpl:122
+		qw422016.E().S(u.AssignmentName)
+		// line admin.qtpl:122
+		qw422016.N().S(`</a></td>
+            <td>`)
+		// line admin.qtpl:123
+		qw422016.N().D(u.Specs)
+		// line admin.qtpl:123
+		qw422016.N().S(`</td>
+            <td>`)
+		// line admin.qtpl:124
+		qw422016.N().D(u.Instances)
+		// line admin.qtpl:124
+		qw422016.N().S(`</td>
+            <td>`)
+		// line admin.qtpl:125
+		qw422016.N().D(u.ActiveInstances)
+		// line admin.qtpl:125
+		qw422016.N().S(`</td>
+            <td>`)
+		// line admin.qtpl:126
+		if u.LastCreated == nil {
+			// line admin.qtpl:126
+			qw422016.N().S(`never`)
+			// line admin.qtpl:126
+		} else {
+			// line admin.qtpl:126
+			streamadminTime(qw422016, *u.LastCreated)
+			// line admin.qtpl:126
+		}
+		// line admin.qtpl:126
+		qw422016.N().S(`</td>
+        </tr>
+        `)
+		// line admin.qtpl:128
+	}
+	// line admin.qtpl:128
+	qw422016.N().S(`
+    </table>
+
+    <h2>Failed builds</h2>
+    <table>
+        <tr>
+            <th>Time</th>
+            <th>Assignment</th>
+            <th>Instance</th>
+            <th>Error</th>
+        </tr>
+        `)
+	// line admin.qtpl:139
+	for _, l := range p.FailedBuilds {
+		// line admin.qtpl:139
+		qw422016.N().S(`
+        <tr>
+            <td>`)
+		// line admin.qtpl:141
+		streamadminTime(qw422016, l.CreatedAt)
+		// line admin.qtpl:141
+		qw422016.N().S(`</td>
+            <td>`)
+		// line admin.qtpl:142
+		qw422016.E().S(l.AssignmentName)
+		// line admin.qtpl:142
+		qw422016.N().S(`</td>
+            <td><a href="/debug/buildlogs/`)
+		// line admin.qtpl:143
+		qw422016.E().S(l.InstanceID.String())
+		// line admin.qtpl:143
+		qw422016.N().S(`">`)
+		// line admin.qtpl:143
+		qw422016.E().S(l.InstanceID.String())
+		// line admin.qtpl:143
+		qw422016.N().S(`</a></td>
+            <td>`)
+		// line admin.qtpl:144
+		qw422016.E().S(l.Error)
+		// line admin.qtpl:144
+		qw422016.N().S(`</td>
+        </tr>
+        `)
+		// line admin.qtpl:146
+	}
+	// line admin.qtpl:146
+	qw422016.N().S(`
+    </table>
+
+    <h2>Docker disk usage</h2>
+    `)
+	// line admin.qtpl:150
+	if p.DiskUsageError != "" {
+		// line admin.qtpl:150
+		qw422016.N().S(`
+    <p class="error">`)
+		// line admin.qtpl:151
+		qw422016.E().S(p.DiskUsageError)
+		// line admin.qtpl:151
+		qw422016.N().S(`</p>
+    `)
+		// line admin.qtpl:152
+	} else {
+		// line admin.qtpl:152
+		qw422016.N().S(`
+    <table>
+        <tr>
+            <th>Kind</th>
+            <th>Count</th>
+            <th>Size</th>
+        </tr>
+        `)
+		// line admin.qtpl:159
+		for _, du := range p.DiskUsage {
+			// line admin.qtpl:159
+			qw422016.N().S(`
+        <tr>
+            <td>`)
+			// line admin.qtpl:161
+			qw422016.E().S(du.Kind)
+			// line admin.qtpl:161
+			qw422016.N().S(`</td>
+            <td>`)
+			// line admin.qtpl:162
+			qw422016.N().D(du.Count)
+			// line admin.qtpl:162
+			qw422016.N().S(`</td>
+            <td>`)
+			// line admin.qtpl:163
+			qw422016.E().S(du.Size)
+			// line admin.qtpl:163
+			qw422016.N().S(`</td>
+        </tr>
+        `)
+			// line admin.qtpl:165
+		}
+		// line admin.qtpl:165
+		qw422016.N().S(`
+    </table>
+    `)
+		// line admin.qtpl:167
+	}
+	// line admin.qtpl:167
+	qw422016.N().S(`
+
+    <h2>Scheduled tasks</h2>
+    <table>
+        <tr>
+            <th>Task</th>
+            <th>Every</th>
+            <th>Running</th>
+            <th>Runs</th>
+            <th>Last started</th>
+            <th>Last finished</th>
+        </tr>
+        `)
+	// line admin.qtpl:179
+	for _, runner := range p.Runners {
+		// line admin.qtpl:179
+		qw422016.N().S(`
+        <tr>
+            <td>`)
+		// line admin.qtpl:181
+		qw422016.E().S(runner.Name)
+		// line admin.qtpl:181
+		qw422016.N().S(`</td>
+            <td>`)
+		// line admin.qtpl:182
+		qw422016.E().S(runner.Every.String())
+		// line admin.qtpl:182
+		qw422016.N().S(`</td>
+            <td>`)
+		// line admin.qtpl:183
+		if runner.Running {
+			// line admin.qtpl:183
+			qw422016.N().S(`yes`)
+			// line admin.qtpl:183
+		} else {
+			// line admin.qtpl:183
+			qw422016.N().S(`no`)
+			// line admin.qtpl:183
+		}
+		// line admin.qtpl:183
+		qw422016.N().S(`</td>
+            <td>`)
+		// line admin.qtpl:184
+		qw422016.N().D(runner.Runs)
+		// line admin.qtpl:184
+		qw422016.N().S(`</td>
+            <td>`)
+		// line admin.qtpl:185
+		streamadminTime(qw422016, runner.LastStart)
+		// line admin.qtpl:185
+		qw422016.N().S(`</td>
+            <td>`)
+		// line admin.qtpl:186
+		streamadminTime(qw422016, runner.LastFinish)
+		// line admin.qtpl:186
+		qw422016.N().S(`</td>
+        </tr>
+        `)
+		// line admin.qtpl:188
+	}
+	// line admin.qtpl:188
+	qw422016.N().S(`
+    </table>
+</body>
+
+</html>
+`)
+	// line admin.qtpl:193
+}
+
+// line admin.qtpl:193
+func WriteAdmin(qq422016 qtio422016.Writer, p *AdminPage) {
+	// line admin.qtpl:193
+	qw422016 := qt422016.AcquireWriter(qq422016)
+	// line admin.qtpl:193
+	StreamAdmin(qw422016, p)
+	// line admin.qtpl:193
+	qt422016.ReleaseWriter(qw422016)
+	// line admin.qtpl:193
+}
+
+// line admin.qtpl:193
+func Admin(p *AdminPage) string {
+	// line admin.qtpl:193
+	qb422016 := qt422016.AcquireByteBuffer()
+	// line admin.qtpl:193
+	WriteAdmin(qb422016, p)
+	// line admin.qtpl:193
+	qs422016 := string(qb422016.B)
+	// line admin.qtpl:193
+	qt422016.ReleaseByteBuffer(qb422016)
+	// line admin.qtpl:193
+	return qs422016
+	// line admin.qtpl:193
+}
@@ -46,11 +46,17 @@ func StreamContainer(qw422016 *qt422016.Writer, url string) {
             protocol = "wss:";
         }
 
+        // Request raw framing; if the server doesn't support it, the
+        // socket falls back to the JSON protocol.
         var socket = new WebSocket(protocol + "//`)
-	// line container.qtpl:27
+	// line container.qtpl:29
 	qw422016.E().S(url)
-	// line container.qtpl:27
-	qw422016.N().S(`");
+	// line container.qtpl:29
+	qw422016.N().S(`", ["ua.raw"]);
+        socket.binaryType = "arraybuffer";
+
+        var encoder = new TextEncoder();
+        var decoder = new TextDecoder();
 
         socket.onopen = function() {
             hterm.defaultStorage = new lib.Storage.Memory();
@@ -67,7 +73,11 @@ func StreamContainer(qw422016 *qt422016.Writer, url string) {
                 io.onVTKeystroke = function(str) {
                     // Do something useful with str here.
                     // For example, Secure Shell forwards the string onto the NaCl plugin.
-                    socket.send(JSON.stringify(['stdin', str]));
+                    if (socket.protocol == "ua.raw") {
+                        socket.send(encoder.encode(str));
+                    } else {
+                        socket.send(JSON.stringify(['stdin', str]));
+                    }
                 };
 
                 io.sendString = io.onVTKeystroke;
@@ -89,6 +99,11 @@ func StreamContainer(qw422016 *qt422016.Writer, url string) {
             t.decorate(document.querySelector('#terminal'));
 
             socket.addEventListener("message", function(ev) {
+                if (ev.data instanceof ArrayBuffer) {
+                    t.io.print(decoder.decode(ev.data, {stream: true}));
+                    return;
+                }
+
                 var data = JSON.parse(ev.data)
 
                 if (data.length == 0) {
@@ -118,31 +133,31 @@ func StreamContainer(qw422016 *qt422016.Writer, url string) {
 
 </html>
 `)
-	// line container.qtpl:94
+	// line container.qtpl:109
 }
 
-// line container.qtpl:94
+// line container.qtpl:109
 func WriteContainer(qq422016 qtio422016.Writer, url string) {
-	// line container.qtpl:94
+	// line container.qtpl:109
 	qw422016 := qt422016.AcquireWriter(qq422016)
-	// line container.qtpl:94
+	// line container.qtpl:109
 	StreamContainer(qw422016, url)
-	// line container.qtpl:94
+	// line container.qtpl:109
 	qt422016.ReleaseWriter(qw422016)
-	// line container.qtpl:94
+	// line container.qtpl:109
 }
 
-// line container.qtpl:94
+// line container.qtpl:109
 func Container(url string) string {
-	// line container.qtpl:94
+	// line container.qtpl:109
 	qb422016 := qt422016.AcquireByteBuffer()
-	// line container.qtpl:94
+	// line container.qtpl:109
 	WriteContainer(qb422016, url)
-	// line container.qtpl:94
+	// line container.qtpl:109
 	qs422016 := string(qb422016.B)
-	// line container.qtpl:94
+	// line container.qtpl:109
 	qt422016.ReleaseByteBuffer(qb422016)
-	// line container.qtpl:94
+	// line container.qtpl:109
 	return qs422016
-	// line container.qtpl:94
+	// line container.qtpl:109
 }
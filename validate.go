@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/jakebailey/ua/app"
+	"github.com/jakebailey/ua/app/specbuild"
+	"github.com/jakebailey/ua/pkg/ctxlog"
+	"github.com/jakebailey/ua/pkg/runtime"
+	"github.com/jessevdk/go-flags"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type validateArgs struct {
+	AssignmentPath string        `long:"assignment-path" env:"UA_ASSIGNMENT_PATH" description:"Path to assignments directory"`
+	Data           string        `long:"data" description:"Path to a JSON file containing the spec data (defaults to {})"`
+	Docker         bool          `long:"docker" description:"Build the image and run the post-build actions against Docker"`
+	Timeout        time.Duration `long:"timeout" default:"10m" description:"Maximum duration of the Docker build"`
+	Verbose        bool          `long:"verbose" short:"v" description:"Show debug logs"`
+
+	Positional struct {
+		Assignment string `positional-arg-name:"assignment" required:"true"`
+	} `positional-args:"true"`
+}
+
+// validateMain runs the validate subcommand, which checks that an
+// assignment's generate function works without needing to run the server,
+// and returns the process's exit code.
+func validateMain(argv []string) int {
+	args := validateArgs{
+		AssignmentPath: app.DefaultConfig.AssignmentPath,
+	}
+
+	parser := flags.NewParser(&args, flags.Default)
+	parser.Name = filepath.Base(os.Args[0]) + " validate"
+	parser.Usage = "[OPTIONS]"
+
+	if _, err := parser.ParseArgs(argv); err != nil {
+		return 2
+	}
+
+	logConfig := zap.NewDevelopmentConfig()
+	logConfig.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	logConfig.DisableStacktrace = true
+	if !args.Verbose {
+		logConfig.Level.SetLevel(zap.ErrorLevel)
+	}
+
+	logger, err := logConfig.Build()
+	if err != nil {
+		panic(err)
+	}
+
+	ctx := ctxlog.WithLogger(context.Background(), logger)
+
+	var specData interface{} = map[string]interface{}{}
+
+	if args.Data != "" {
+		buf, err := ioutil.ReadFile(args.Data)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error reading data:", err)
+			return 1
+		}
+
+		if err := json.Unmarshal(buf, &specData); err != nil {
+			fmt.Fprintln(os.Stderr, "error decoding data:", err)
+			return 1
+		}
+	}
+
+	name := args.Positional.Assignment
+	path := specbuild.AssignmentPath(args.AssignmentPath, name)
+
+	fmt.Printf("assignment: %s (%s)\n", name, path)
+
+	out, err := specbuild.Generate(ctx, path, specData)
+	if err != nil {
+		if err == specbuild.ErrNoJS {
+			fmt.Println("FAIL: no index.js found (legacy assignments cannot be validated)")
+		} else {
+			fmt.Println("FAIL: generate:", err)
+		}
+		return 1
+	}
+
+	printGenerateOutput(os.Stdout, out)
+
+	if errs := specbuild.Validate(out); len(errs) != 0 {
+		fmt.Println()
+		for _, err := range errs {
+			fmt.Println("FAIL:", err.Error())
+		}
+		return 1
+	}
+
+	if !args.Docker {
+		fmt.Println("\nOK")
+		return 0
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, args.Timeout)
+	defer cancel()
+
+	if !validateDocker(ctx, path, out) {
+		return 1
+	}
+
+	fmt.Println("\nOK")
+	return 0
+}
+
+func printGenerateOutput(w io.Writer, out *specbuild.GenerateOutput) {
+	if out.ImageName != "" {
+		fmt.Fprintf(w, "image: %s\n", out.ImageName)
+	} else if out.Dockerfile != "" {
+		fmt.Fprintln(w, "image: (dockerfile)")
+	}
+
+	init := out.Init != nil && *out.Init
+	fmt.Fprintf(w, "init: %v\n", init)
+	fmt.Fprintf(w, "command: user=%q cmd=%q env=%q workingDir=%q\n", out.User, out.Cmd, out.Env, out.WorkingDir)
+
+	if l := out.Limits; l != nil {
+		fmt.Fprintf(w, "limits: memory=%q cpus=%v pids=%d diskSize=%q ulimits=%d tmpfs=%d\n", l.Memory, l.CPUs, l.Pids, l.DiskSize, len(l.Ulimits), len(l.Tmpfs))
+	}
+
+	if n := out.Network; n != nil {
+		fmt.Fprintf(w, "network: name=%q isolated=%v\n", n.Name, n.Isolated)
+	}
+
+	if out.Grade != nil {
+		fmt.Fprintf(w, "grade: user=%q cmd=%q timeout=%d structured=%v\n", out.Grade.User, out.Grade.Cmd, out.Grade.Timeout, out.Grade.Structured)
+	}
+
+	fmt.Fprintln(w, "postBuild:")
+
+	specbuild.WalkActions(out.PostBuild, func(path []int, ac *specbuild.Action) {
+		fmt.Fprintf(w, "  %-8s %s\n", specbuild.FormatActionPath(path), ac.Action)
+	})
+
+	for _, svc := range out.Services {
+		fmt.Fprintf(w, "service %s: image=%s cmd=%q\n", svc.Name, svc.ImageName, svc.Cmd)
+
+		specbuild.WalkActions(svc.PostBuild, func(path []int, ac *specbuild.Action) {
+			fmt.Fprintf(w, "  %-8s %s\n", specbuild.FormatActionPath(path), ac.Action)
+		})
+	}
+}
+
+// validateDocker builds the assignment's image and performs its post-build
+// actions on a temporary container, printing the result of each action. The
+// image and container are removed afterward.
+func validateDocker(ctx context.Context, assignmentPath string, out *specbuild.GenerateOutput) bool {
+	cli, err := client.NewClientWithOpts(client.FromEnv)
+	if err != nil {
+		fmt.Println("FAIL: docker client:", err)
+		return false
+	}
+	defer cli.Close()
+
+	rt := runtime.NewDocker(cli)
+
+	fmt.Println("\nbuilding image...")
+
+	imageTag := fmt.Sprintf("ua-validate-%d", time.Now().UnixNano())
+	imageID := imageTag
+
+	if out.ImageName != "" {
+		err = specbuild.TagImage(ctx, rt, out.ImageName, imageTag, true)
+	} else {
+		imageID, err = rt.Build(ctx, imageTag, out.Dockerfile, filepath.Join(assignmentPath, "context"))
+	}
+
+	if err != nil {
+		fmt.Println("FAIL: build:", err)
+		return false
+	}
+
+	defer func() {
+		// Use another context just in case the old context was cancelled.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := rt.RemoveImage(ctx, imageID); err != nil {
+			fmt.Println("warning: error removing image:", err)
+		}
+	}()
+
+	containerConfig := &container.Config{
+		Image:     imageID,
+		OpenStdin: true,
+		Cmd:       []string{"/bin/cat"},
+		Labels: map[string]string{
+			"ua.validate": "true",
+		},
+	}
+	hostConfig := &container.HostConfig{
+		Init: out.Init,
+	}
+
+	containerID, err := rt.Create(ctx, imageTag, containerConfig, hostConfig)
+	if err != nil {
+		fmt.Println("FAIL: create container:", err)
+		return false
+	}
+
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := rt.Remove(ctx, containerID, true); err != nil {
+			fmt.Println("warning: error removing container:", err)
+		}
+	}()
+
+	if err := rt.Start(ctx, containerID); err != nil {
+		fmt.Println("FAIL: start container:", err)
+		return false
+	}
+
+	specbuild.WalkActions(out.PostBuild, func(_ []int, ac *specbuild.Action) {
+		if ac.Action == "gobuild" {
+			ac.SrcPath = filepath.Join(assignmentPath, "gosrc")
+		}
+	})
+
+	fmt.Println("performing post-build actions...")
+
+	log := &specbuild.ActionLog{}
+	err = specbuild.PerformActions(specbuild.WithActionLog(ctx, log), rt, containerID, out.PostBuild)
+
+	for _, entry := range log.Entries() {
+		status := "ok  "
+		if entry.Error != "" {
+			status = "FAIL"
+		}
+
+		fmt.Printf("  %s %-8s %-8s %v\n", status, specbuild.FormatActionPath(entry.Path), entry.Action, entry.Duration)
+
+		if entry.Error == "" {
+			continue
+		}
+
+		fmt.Printf("       error: %s\n", entry.Error)
+
+		if entry.Stdout != "" {
+			fmt.Printf("       stdout:\n%s\n", entry.Stdout)
+		}
+
+		if entry.Stderr != "" {
+			fmt.Printf("       stderr:\n%s\n", entry.Stderr)
+		}
+	}
+
+	if err != nil {
+		fmt.Println("\nFAIL:", err)
+		return false
+	}
+
+	return true
+}